@@ -0,0 +1,297 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A filePatch holds the hunks of a patch that apply to a single file.
+type filePatch struct {
+	path string
+	// created is set if the patch creates the file.
+	created bool
+	hunks   []hunk
+}
+
+// A hunk replaces the lines old, starting at line oldStart, with the lines
+// new. Like in the hunk header, an empty old range starts at the line just
+// before it.
+type hunk struct {
+	oldStart int
+	old, new []string
+}
+
+// parsePatch parses the file patches in data, which is in the format written
+// by -golden_patch_dir and by git diff.
+func parsePatch(data string) ([]filePatch, error) {
+	var patches []filePatch
+	var h *hunk
+	var oldLeft, newLeft int
+	// last points to the lines a "\ No newline at end of file" marker
+	// applies to.
+	var last [][]string
+	for n, line := range patchLines(data) {
+		text := strings.TrimSuffix(line, "\n")
+		if strings.HasPrefix(line, "\\") {
+			for _, lines := range last {
+				lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "\n")
+			}
+			last = nil
+			continue
+		}
+		if h != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, " ") || line == "\n":
+				h.old, h.new = append(h.old, strings.TrimPrefix(line, " ")), append(h.new, strings.TrimPrefix(line, " "))
+				last = [][]string{h.old, h.new}
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			case strings.HasPrefix(line, "-"):
+				h.old = append(h.old, line[1:])
+				last = [][]string{h.old}
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				h.new = append(h.new, line[1:])
+				last = [][]string{h.new}
+				newLeft--
+			default:
+				return nil, fmt.Errorf("line %d: truncated hunk", n+1)
+			}
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, fmt.Errorf("line %d: hunk longer than its header says", n+1)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			patches = append(patches, filePatch{})
+			h = nil
+		case text == "--- /dev/null":
+			if len(patches) == 0 {
+				patches = append(patches, filePatch{})
+			}
+			patches[len(patches)-1].created = true
+		case strings.HasPrefix(line, "+++ "):
+			if len(patches) == 0 {
+				patches = append(patches, filePatch{})
+			}
+			p := strings.TrimPrefix(text, "+++ ")
+			if !strings.HasPrefix(p, "b/") {
+				return nil, fmt.Errorf("line %d: unsupported target %q", n+1, p)
+			}
+			patches[len(patches)-1].path = strings.TrimPrefix(p, "b/")
+		case strings.HasPrefix(line, "@@ "):
+			if len(patches) == 0 || patches[len(patches)-1].path == "" {
+				return nil, fmt.Errorf("line %d: hunk before file header", n+1)
+			}
+			fields := strings.Fields(text)
+			if len(fields) < 4 || fields[3] != "@@" {
+				return nil, fmt.Errorf("line %d: malformed hunk header", n+1)
+			}
+			oldStart, oldLen, err := parseRange(fields[1], "-")
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			_, newLen, err := parseRange(fields[2], "+")
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			p := &patches[len(patches)-1]
+			p.hunks = append(p.hunks, hunk{oldStart: oldStart})
+			h, oldLeft, newLeft = &p.hunks[len(p.hunks)-1], oldLen, newLen
+		}
+	}
+	if oldLeft > 0 || newLeft > 0 {
+		return nil, fmt.Errorf("truncated hunk at end of patch")
+	}
+	return patches, nil
+}
+
+// parseRange parses a hunk header range such as "-3,4", or "+3" for a single
+// line.
+func parseRange(r string, prefix string) (start int, length int, err error) {
+	if !strings.HasPrefix(r, prefix) {
+		return 0, 0, fmt.Errorf("malformed range %q", r)
+	}
+	r = strings.TrimPrefix(r, prefix)
+	length = 1
+	if i := strings.Index(r, ","); i >= 0 {
+		if length, err = strconv.Atoi(r[i+1:]); err != nil {
+			return 0, 0, fmt.Errorf("malformed range %q", r)
+		}
+		r = r[:i]
+	}
+	if start, err = strconv.Atoi(r); err != nil {
+		return 0, 0, fmt.Errorf("malformed range %q", r)
+	}
+	return start, length, nil
+}
+
+// apply returns contents with the hunks of p applied. Each hunk must match
+// exactly where its header says.
+func (p filePatch) apply(contents string) (string, error) {
+	a := patchLines(contents)
+	var out []string
+	pos := 0
+	for i, h := range p.hunks {
+		start := h.oldStart - 1
+		if len(h.old) == 0 {
+			start = h.oldStart
+		}
+		if start < pos || start+len(h.old) > len(a) || strings.Join(a[start:start+len(h.old)], "") != strings.Join(h.old, "") {
+			return "", fmt.Errorf("hunk %d does not apply; the golden file changed since the patch was made", i+1)
+		}
+		out = append(append(out, a[pos:start]...), h.new...)
+		pos = start + len(h.old)
+	}
+	return strings.Join(append(out, a[pos:]...), ""), nil
+}
+
+// ApplyPatches applies every patch found under patchDir, such as those saved
+// by -golden_patch_dir, to golden files under the current directory, which
+// should be the root of the repository. It returns the updated golden files.
+// Patches from /dev/null, such as those saved for golden files missing with
+// WithMissingAsEmpty, create their golden files.
+//
+// Either all patches apply or none of them does: patches that do not target a
+// golden file within the current directory, that do not apply cleanly, or
+// that create a file that already exists, fail the whole operation before any
+// file is written.
+func ApplyPatches(patchDir string) ([]string, error) {
+	updated := map[string]string{}
+	err := filepath.Walk(patchDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".patch") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		patches, err := parsePatch(string(data))
+		if err != nil {
+			return fmt.Errorf("%v: %v", p, err)
+		}
+		for _, fp := range patches {
+			if fp.path == "" || path.IsAbs(fp.path) || path.Clean(fp.path) != fp.path || strings.HasPrefix(fp.path, "../") || !isGoldenFile(path.Base(fp.path)) {
+				return fmt.Errorf("%v: %q is not a golden file within the repository", p, fp.path)
+			}
+			if _, ok := updated[fp.path]; ok {
+				return fmt.Errorf("%v: %v is patched more than once", p, fp.path)
+			}
+			previous, err := ioutil.ReadFile(filepath.FromSlash(fp.path))
+			switch {
+			case fp.created && err == nil:
+				return fmt.Errorf("%v: %v already exists", p, fp.path)
+			case fp.created && os.IsNotExist(err):
+				previous = nil
+			case err != nil:
+				return fmt.Errorf("%v: %v", p, err)
+			}
+			if updated[fp.path], err = fp.apply(string(previous)); err != nil {
+				return fmt.Errorf("%v: %v: %v", p, fp.path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(updated))
+	for f := range updated {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	if err := replaceFiles(files, updated); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// rename is os.Rename. Tests replace it.
+var rename = os.Rename
+
+// replaceFiles replaces each of files with its contents in updated. All new
+// contents are first written to temporary files next to their targets, which
+// are then renamed over them, so that a failure to write leaves every target
+// untouched. The previous contents are kept as hard links until every rename
+// succeeded, so that a failed rename puts back the files already replaced.
+func replaceFiles(files []string, updated map[string]string) error {
+	temps := make([]string, 0, len(files))
+	// backups holds the hard link to the previous contents of each file, or
+	// the empty string for files that did not exist.
+	backups := make([]string, 0, len(files))
+	defer func() {
+		for _, p := range append(temps, backups...) {
+			if p != "" {
+				os.Remove(p)
+			}
+		}
+	}()
+	for _, f := range files {
+		target := filepath.FromSlash(f)
+		if err := os.MkdirAll(filepath.Dir(target), 0770); err != nil {
+			return err
+		}
+		tmp, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".")
+		if err != nil {
+			return err
+		}
+		temps = append(temps, tmp.Name())
+		if _, err := tmp.WriteString(updated[f]); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		// New files get the mode that their patches record.
+		mode := os.FileMode(0644)
+		backup := ""
+		if info, err := os.Stat(target); err == nil {
+			mode = info.Mode()
+			backup = tmp.Name() + ".orig"
+			if err := os.Link(target, backup); err != nil {
+				return err
+			}
+		}
+		backups = append(backups, backup)
+		if err := os.Chmod(tmp.Name(), mode); err != nil {
+			return err
+		}
+	}
+	for i, f := range files {
+		if err := rename(temps[i], filepath.FromSlash(f)); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if backups[j] != "" {
+					os.Rename(backups[j], filepath.FromSlash(files[j]))
+				} else {
+					os.Remove(filepath.FromSlash(files[j]))
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
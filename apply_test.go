@@ -0,0 +1,171 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatchRoundTrip(t *testing.T) {
+	long := strings.Repeat("line\n", 20)
+	tests := []struct {
+		previous, updated string
+	}{
+		{"a\nb\nc\n", "a\nB\nc\n"},
+		{"a\nb", "a\nb\n"},
+		{"a\nb\n", "a\nc"},
+		{"a\nb", "a\nc"},
+		{"", "new\n"},
+		{"old\n", ""},
+		{"first\n" + long + "last\n", "First\n" + long + "Last\n"},
+		{"a\n\nb\n", "a\n\nc\n"},
+	}
+	for _, tt := range tests {
+		patches, err := parsePatch(formatPatch("x.golden", tt.previous, tt.updated))
+		if err != nil || len(patches) != 1 || patches[0].path != "x.golden" {
+			t.Errorf("parsePatch of the patch from %q to %q: got %+v, %v", tt.previous, tt.updated, patches, err)
+			continue
+		}
+		if got, err := patches[0].apply(tt.previous); err != nil || got != tt.updated {
+			t.Errorf("apply of the patch from %q to %q: got %q, %v", tt.previous, tt.updated, got, err)
+		}
+		if _, err := patches[0].apply("unrelated\n" + tt.previous); err == nil && tt.previous != "" {
+			t.Errorf("apply of the patch from %q to %q to other data: got nil error", tt.previous, tt.updated)
+		}
+	}
+
+	created := formatCreationPatch("x.golden", "new\n")
+	if want := "diff --git a/x.golden b/x.golden\nnew file mode 100644\n--- /dev/null\n+++ b/x.golden\n@@ -0,0 +1 @@\n+new\n"; created != want {
+		t.Errorf("formatCreationPatch: got %q, want %q", created, want)
+	}
+	if patches, err := parsePatch(created); err != nil || len(patches) != 1 || !patches[0].created {
+		t.Errorf("parsePatch of a creation patch: got %+v, %v", patches, err)
+	}
+
+	for _, malformed := range []string{
+		"@@ -1 +1 @@\n-a\n+b\n",
+		"--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-a\n+b\n",
+		"--- a/x\n+++ b/x\n@@ -x +1 @@\n-a\n+b\n",
+		"--- a/x\n+++ /dev/null\n",
+	} {
+		if _, err := parsePatch(malformed); err == nil {
+			t.Errorf("parsePatch(%q): got nil error", malformed)
+		}
+	}
+}
+
+func TestApplyPatches(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, contents string) {
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	write("pkg/testdata/a.golden", "a\nold\n")
+	write("pkg/testdata/b.golden", "b\nold\n")
+	write("patches/pkg/testdata/a.golden.patch", formatPatch("pkg/testdata/a.golden", "a\nold\n", "a\nnew\n"))
+	write("patches/pkg/testdata/b.golden.patch", formatPatch("pkg/testdata/b.golden", "b\nstale\n", "b\nnew\n"))
+
+	// A patch that does not apply keeps all files untouched.
+	if _, err := ApplyPatches("patches"); err == nil || !strings.Contains(err.Error(), "hunk 1 does not apply") {
+		t.Errorf("ApplyPatches with a stale patch: got %v, want error", err)
+	}
+	if got := read("pkg/testdata/a.golden"); got != "a\nold\n" {
+		t.Errorf("a.golden after failed ApplyPatches: got %q", got)
+	}
+
+	write("patches/pkg/testdata/b.golden.patch", formatPatch("pkg/testdata/b.golden", "b\nold\n", "b\nnew\n"))
+	files, err := ApplyPatches("patches")
+	if want := []string{"pkg/testdata/a.golden", "pkg/testdata/b.golden"}; err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("ApplyPatches: got %v, %v, want %v", files, err, want)
+	}
+	if got := read("pkg/testdata/a.golden") + read("pkg/testdata/b.golden"); got != "a\nnew\nb\nnew\n" {
+		t.Errorf("golden files after ApplyPatches: got %q", got)
+	}
+	if names, _ := filepath.Glob("pkg/testdata/.*"); len(names) != 0 {
+		t.Errorf("temporary files left behind: %v", names)
+	}
+
+	// A failed rename puts back the files already replaced.
+	write("patches/pkg/testdata/a.golden.patch", formatPatch("pkg/testdata/a.golden", "a\nnew\n", "a\nnewer\n"))
+	write("patches/pkg/testdata/b.golden.patch", formatPatch("pkg/testdata/b.golden", "b\nnew\n", "b\nnewer\n"))
+	defer func() { rename = os.Rename }()
+	rename = func(from, to string) error {
+		if strings.HasSuffix(to, "b.golden") {
+			return errors.New("rename failed")
+		}
+		return os.Rename(from, to)
+	}
+	if files, err := ApplyPatches("patches"); err == nil || files != nil {
+		t.Errorf("ApplyPatches with a failing rename: got %v, %v, want error", files, err)
+	}
+	rename = os.Rename
+	if got := read("pkg/testdata/a.golden") + read("pkg/testdata/b.golden"); got != "a\nnew\nb\nnew\n" {
+		t.Errorf("golden files after a failed rename: got %q", got)
+	}
+	if names, _ := filepath.Glob("pkg/testdata/.*"); len(names) != 0 {
+		t.Errorf("temporary files left behind after a failed rename: %v", names)
+	}
+	os.RemoveAll("patches")
+
+	// Creation patches create their golden files, but not over existing ones.
+	write("patches/pkg/testdata/new/c.golden.patch", formatCreationPatch("pkg/testdata/new/c.golden", "c\n"))
+	files, err = ApplyPatches("patches")
+	if want := []string{"pkg/testdata/new/c.golden"}; err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("ApplyPatches of a creation patch: got %v, %v, want %v", files, err, want)
+	}
+	if got := read("pkg/testdata/new/c.golden"); got != "c\n" {
+		t.Errorf("golden file created by ApplyPatches: got %q", got)
+	}
+	if _, err := ApplyPatches("patches"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("ApplyPatches of a creation patch for an existing file: got %v, want error", err)
+	}
+
+	for _, target := range []string{"pkg/main.go", "../outside.golden", "/abs.golden"} {
+		os.RemoveAll("patches")
+		write("patches/p.patch", formatPatch(target, "x\n", "y\n"))
+		if _, err := ApplyPatches("patches"); err == nil || !strings.Contains(err.Error(), "is not a golden file within the repository") {
+			t.Errorf("ApplyPatches of a patch to %v: got %v, want error", target, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// T is the part of testing.TB used by helpers that take the running test.
+type T interface {
+	Helper()
+	Name() string
+	Error(args ...interface{})
+}
+
+// Approve compares actual to the approved data of the running test, following
+// the naming scheme of ApprovalTests: the approved data is kept in
+// testdata/<TestName>.approved.txt next to the test, where subtest names have
+// their slashes replaced with dots. On a mismatch, Approve writes actual to
+// testdata/<TestName>.received.txt and reports an error; once the data
+// matches again, the received file is deleted. Approving a change therefore
+// means renaming the received file over the approved one, or running the test
+// with -update_golden.
+func Approve(t T, actual string, opts ...Option) {
+	t.Helper()
+	approved := approvedFileName(t.Name())
+	received := receivedFileName(approved)
+	o := newOptions(opts)
+	if shouldUpdateGolden() {
+		if err := writeGolden(approved, actual, o); err != nil {
+			t.Error(fmt.Sprintf("Error while updating approved file: %v", err))
+		}
+		removeReceived(t, received)
+		return
+	}
+	r := check(actual, approved, o)
+	switch {
+	case r.err != nil && !os.IsNotExist(r.err):
+		t.Error(r.String())
+		return
+	case r.equal:
+		removeReceived(t, received)
+		return
+	}
+	if err := ioutil.WriteFile(received, []byte(actual), 0660); err != nil {
+		t.Error(fmt.Sprintf("Error while writing received file: %v", err))
+		return
+	}
+	if r.err != nil {
+		t.Error(fmt.Sprintf("No approved data in %v; received data written to %v", approved, received))
+		return
+	}
+	t.Error(fmt.Sprintf("%vReceived data written to %v\n", r, received))
+}
+
+// approvedFileName returns the approved file of the test called testName.
+func approvedFileName(testName string) string {
+	return "./" + filepath.ToSlash(filepath.Join("testdata", strings.Replace(testName, "/", ".", -1)+".approved.txt"))
+}
+
+// receivedFileName returns the received file next to an approved file.
+func receivedFileName(approved string) string {
+	i := strings.LastIndex(approved, ".approved.")
+	if i < 0 {
+		return approved
+	}
+	return approved[:i] + ".received." + approved[i+len(".approved."):]
+}
+
+func removeReceived(t T, received string) {
+	if err := os.Remove(received); err != nil && !os.IsNotExist(err) {
+		t.Error(fmt.Sprintf("Error while removing received file: %v", err))
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeT records the errors reported by a test.
+type fakeT struct {
+	name   string
+	errors []string
+}
+
+func (t *fakeT) Helper()      {}
+func (t *fakeT) Name() string { return t.name }
+func (t *fakeT) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func TestApprovalFileNames(t *testing.T) {
+	approved := approvedFileName("TestFoo/bar_baz")
+	if want := "./testdata/TestFoo.bar_baz.approved.txt"; approved != want {
+		t.Errorf("approvedFileName: got %q want %q", approved, want)
+	}
+	if got, want := receivedFileName(approved), "./testdata/TestFoo.bar_baz.received.txt"; got != want {
+		t.Errorf("receivedFileName: got %q want %q", got, want)
+	}
+	if got, want := actualFileName(approved), "./testdata/TestFoo.bar_baz.received.txt"; got != want {
+		t.Errorf("actualFileName: got %q want %q", got, want)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	approved := filepath.Join(dir, "testdata/TestX.case.approved.txt")
+	received := filepath.Join(dir, "testdata/TestX.case.received.txt")
+
+	ft := &fakeT{name: "TestX/case"}
+	Approve(ft, "one\n")
+	if len(ft.errors) != 1 || !strings.HasPrefix(ft.errors[0], "No approved data in ./testdata/TestX.case.approved.txt") {
+		t.Errorf("Approve without approved file: got errors %q", ft.errors)
+	}
+	if got, _ := ioutil.ReadFile(received); string(got) != "one\n" {
+		t.Errorf("received file: got %q, want %q", got, "one\n")
+	}
+
+	if err := os.Rename(received, approved); err != nil {
+		t.Fatal(err)
+	}
+	ft = &fakeT{name: "TestX/case"}
+	Approve(ft, "two\n")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "-one\n+two\n") || !strings.HasSuffix(ft.errors[0], "Received data written to ./testdata/TestX.case.received.txt\n") {
+		t.Errorf("Approve with different data: got errors %q", ft.errors)
+	}
+
+	ft = &fakeT{name: "TestX/case"}
+	Approve(ft, "one\n")
+	if len(ft.errors) != 0 {
+		t.Errorf("Approve with approved data: got errors %q", ft.errors)
+	}
+	if _, err := os.Stat(received); !os.IsNotExist(err) {
+		t.Errorf("received file not removed after success: %v", err)
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	ft = &fakeT{name: "TestX/case"}
+	Approve(ft, "three\n")
+	if got, _ := ioutil.ReadFile(approved); len(ft.errors) != 0 || string(got) != "three\n" {
+		t.Errorf("Approve while updating: got errors %q and approved data %q", ft.errors, got)
+	}
+}
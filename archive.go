@@ -0,0 +1,118 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CompareZip compares a zip archive to a golden zip archive entry by entry.
+// Both archives are rendered as a listing of entry names, modes and contents,
+// sorted by name, so that timestamps and entry order do not matter; any
+// mismatch is reported as a diff of the listings. Updating the golden file
+// writes the archive itself.
+func CompareZip(data []byte, goldenFile string, opts ...Option) string {
+	return Compare(string(data), goldenFile, append(opts, withCanonicalizer(renderZip))...)
+}
+
+// CompareTar is like CompareZip for tar archives. Ownership and timestamps
+// are ignored; symbolic link targets are compared.
+func CompareTar(data []byte, goldenFile string, opts ...Option) string {
+	return Compare(string(data), goldenFile, append(opts, withCanonicalizer(renderTar))...)
+}
+
+// archiveEntry is the part of an archive entry that golden files care about.
+type archiveEntry struct {
+	name     string
+	mode     os.FileMode
+	linkname string
+	contents []byte
+}
+
+func renderZip(data string) (string, error) {
+	r, err := zip.NewReader(strings.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	var entries []archiveEntry
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("%v: %v", f.Name, err)
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("%v: %v", f.Name, err)
+		}
+		entries = append(entries, archiveEntry{name: f.Name, mode: f.Mode(), contents: contents})
+	}
+	return renderArchive(entries), nil
+}
+
+func renderTar(data string) (string, error) {
+	r := tar.NewReader(strings.NewReader(data))
+	var entries []archiveEntry
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		contents, err := ioutil.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("%v: %v", h.Name, err)
+		}
+		entries = append(entries, archiveEntry{name: h.Name, mode: h.FileInfo().Mode(), linkname: h.Linkname, contents: contents})
+	}
+	return renderArchive(entries), nil
+}
+
+// renderArchive lists entries sorted by name. Text contents are shown as is,
+// and binary contents by their size and SHA-256 sum.
+func renderArchive(entries []archiveEntry) string {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	buf := &bytes.Buffer{}
+	for _, e := range entries {
+		fmt.Fprintf(buf, "== %v %v", e.name, e.mode)
+		if e.linkname != "" {
+			fmt.Fprintf(buf, " -> %v", e.linkname)
+		}
+		buf.WriteString("\n")
+		switch {
+		case len(e.contents) == 0:
+		case utf8.Valid(e.contents) && bytes.IndexByte(e.contents, 0) < 0:
+			buf.Write(e.contents)
+			if !bytes.HasSuffix(e.contents, []byte("\n")) {
+				buf.WriteString("\n\\ No newline at end of entry\n")
+			}
+		default:
+			fmt.Fprintf(buf, "binary, %d bytes, sha256 %x\n", len(e.contents), sha256.Sum256(e.contents))
+		}
+	}
+	return buf.String()
+}
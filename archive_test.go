@@ -0,0 +1,169 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeArchiveFile struct {
+	name, contents string
+	mode           os.FileMode
+	linkname       string
+}
+
+func makeZip(t *testing.T, modified time.Time, files ...fakeArchiveFile) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, f := range files {
+		h := &zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: modified}
+		h.SetMode(f.mode)
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(f.contents))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeTar(t *testing.T, modified time.Time, files ...fakeArchiveFile) []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	for _, f := range files {
+		h := &tar.Header{Name: f.name, Mode: int64(f.mode.Perm()), Size: int64(len(f.contents)), ModTime: modified, Typeflag: tar.TypeReg}
+		if f.linkname != "" {
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, f.linkname, 0
+		}
+		if err := w.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.contents))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompareArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+
+	a := fakeArchiveFile{name: "a.txt", contents: "hello\n", mode: 0644}
+	b := fakeArchiveFile{name: "bin/b", contents: "\x00\x01", mode: 0755}
+	changedA := fakeArchiveFile{name: "a.txt", contents: "goodbye", mode: 0644}
+	then, now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	link := fakeArchiveFile{name: "link", linkname: "a.txt", mode: 0777}
+	changedLink := fakeArchiveFile{name: "link", linkname: "bin/b", mode: 0777}
+
+	var tests = []struct {
+		desc    string
+		compare func(data []byte, goldenFile string, opts ...Option) string
+		golden  []byte
+		actual  []byte
+		want    string
+	}{
+		{
+			desc:    "zip with different timestamps and order",
+			compare: CompareZip,
+			golden:  makeZip(t, then, a, b),
+			actual:  makeZip(t, now, b, a),
+			want:    "",
+		},
+		{
+			desc:    "zip with changed contents",
+			compare: CompareZip,
+			golden:  makeZip(t, then, a, b),
+			actual:  makeZip(t, then, changedA, b),
+			want: ` == a.txt -rw-r--r--
+-hello
++goodbye
++\ No newline at end of entry
+ == bin/b -rwxr-xr-x
+`,
+		},
+		{
+			desc:    "tar with different timestamps and order",
+			compare: CompareTar,
+			golden:  makeTar(t, then, a, link),
+			actual:  makeTar(t, now, link, a),
+			want:    "",
+		},
+		{
+			desc:    "tar with changed link",
+			compare: CompareTar,
+			golden:  makeTar(t, then, a, link),
+			actual:  makeTar(t, then, a, changedLink),
+			want: `-== link Lrwxrwxrwx -> a.txt
++== link Lrwxrwxrwx -> bin/b
+`,
+		},
+	}
+	for _, test := range tests {
+		goldenPath := path.Join(dir, "src/fake/testdata/archive.golden")
+		if err := ioutil.WriteFile(goldenPath, test.golden, 0600); err != nil {
+			t.Fatalf("Cannot write fake golden file: %v", err)
+		}
+		got := test.compare(test.actual, "fake/testdata/archive.golden")
+		if !strings.Contains(got, test.want) || (test.want == "") != (got == "") {
+			t.Errorf("%v: got %q, want a diff containing %q", test.desc, got, test.want)
+		}
+	}
+
+	// Updating writes the archive itself.
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	actual := makeZip(t, now, a)
+	CompareZip(actual, "fake/testdata/archive.golden")
+	if got, _ := ioutil.ReadFile(path.Join(dir, "src/fake/testdata/archive.golden")); !bytes.Equal(got, actual) {
+		t.Errorf("updated golden archive differs from the actual archive")
+	}
+}
+
+func TestRenderArchive(t *testing.T) {
+	got := renderArchive([]archiveEntry{
+		{name: "z/", mode: os.ModeDir | 0755},
+		{name: "b.bin", mode: 0644, contents: []byte{0xff, 0x00}},
+		{name: "a.txt", mode: 0600, contents: []byte("text\n")},
+	})
+	want := `== a.txt -rw-------
+text
+== b.bin -rw-r--r--
+binary, 2 bytes, sha256 ea5dbf9596d187e9500f23e9a680109475341cf4e81f7e043f7d97152c10772f
+== z/ drwxr-xr-x
+`
+	if got != want {
+		t.Errorf("renderArchive: got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Backend adapts golden to a build system. It decides where golden files
+// live and how the user should be told to update them.
+//
+// The following backends are built in:
+//
+//     gopath  Paths are relative to the src directory of a GOPATH entry, or
+//             to the directories passed to SetSearchRoots. This is the
+//             default.
+//     gomod   Paths are relative to the main module's directory, optionally
+//             prefixed with its module path.
+//     bazel   Paths are relative to the workspace root. Golden files are read
+//             from the test's runfiles and written to the workspace by
+//             "bazel run".
+//
+// Other backends can be added with RegisterBackend.
+type Backend interface {
+	// PathForRead returns the path of the existing golden file relPath.
+	PathForRead(relPath string) (string, error)
+	// PathForWrite returns the path that the golden file relPath should be
+	// written to.
+	PathForWrite(relPath string) (string, error)
+	// UpdateCommand returns the command to run to update golden files.
+	UpdateCommand() string
+}
+
+var backends = struct {
+	sync.Mutex
+	byName  map[string]Backend
+	current string
+}{
+	byName: map[string]Backend{
+		"gopath": gopathBackend{},
+		"gomod":  gomodBackend{},
+		"bazel":  bazelBackend{},
+	},
+}
+
+// RegisterBackend makes a backend available under name, so that it can be
+// selected with SetBackend or the GOLDEN_BACKEND environment variable. It
+// panics if a backend is already registered under name.
+func RegisterBackend(name string, b Backend) {
+	backends.Lock()
+	defer backends.Unlock()
+	if b == nil {
+		panic("golden: RegisterBackend backend is nil")
+	}
+	if _, dup := backends.byName[name]; dup {
+		panic("golden: RegisterBackend called twice for backend " + name)
+	}
+	backends.byName[name] = b
+}
+
+// SetBackend selects the backend registered under name. It takes precedence
+// over the GOLDEN_BACKEND environment variable. Passing the empty string
+// restores the default, which is to use GOLDEN_BACKEND if set, and the
+// gopath backend otherwise.
+func SetBackend(name string) error {
+	backends.Lock()
+	defer backends.Unlock()
+	if _, ok := backends.byName[name]; name != "" && !ok {
+		return fmt.Errorf("unknown golden backend %q; registered backends are %v", name, registeredBackends())
+	}
+	backends.current = name
+	return nil
+}
+
+// registeredBackends returns the sorted names of all backends. backends must
+// be locked.
+func registeredBackends() []string {
+	var names []string
+	for name := range backends.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func currentBackend() (Backend, error) {
+	backends.Lock()
+	defer backends.Unlock()
+	name := backends.current
+	if name == "" {
+		name = os.Getenv("GOLDEN_BACKEND")
+	}
+	if name == "" {
+		name = "gopath"
+	}
+	b, ok := backends.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown golden backend %q in GOLDEN_BACKEND; registered backends are %v", name, registeredBackends())
+	}
+	return b, nil
+}
+
+// isLiteralPath reports whether p should be used as is rather than resolved
+// by the backend: absolute paths and paths explicitly relative to the working
+// directory, which is the package directory when running tests.
+func isLiteralPath(p string) bool {
+	return filepath.IsAbs(p) || strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../")
+}
+
+func getFullPathForRead(relPath string) (string, error) {
+	if isLiteralPath(relPath) {
+		return relPath, nil
+	}
+	b, err := currentBackend()
+	if err != nil {
+		return "", err
+	}
+	// A golden file created earlier by Run is not on disk yet, but only
+	// buffered where it will be written.
+	if hasPendingWrites() {
+		if fullPath, err := b.PathForWrite(relPath); err == nil && hasPendingWrite(fullPath) {
+			return fullPath, nil
+		}
+	}
+	return b.PathForRead(relPath)
+}
+
+func getFullPathForWrite(relPath string) (string, error) {
+	if isLiteralPath(relPath) {
+		return relPath, nil
+	}
+	b, err := currentBackend()
+	if err != nil {
+		return "", err
+	}
+	return b.PathForWrite(relPath)
+}
+
+var defaultUpdateCommand struct {
+	sync.Mutex
+	command string
+}
+
+// SetUpdateCommand sets the command that failure messages tell the user to
+// run to update golden files, such as "make update-golden". It overrides the
+// backend's command; WithUpdateCommand in turn overrides it for a single
+// comparison. Passing the empty string restores the backend's command.
+func SetUpdateCommand(command string) {
+	defaultUpdateCommand.Lock()
+	defer defaultUpdateCommand.Unlock()
+	defaultUpdateCommand.command = command
+}
+
+func formatUpdateCommand() string {
+	defaultUpdateCommand.Lock()
+	command := defaultUpdateCommand.command
+	defaultUpdateCommand.Unlock()
+	if command != "" {
+		return command
+	}
+	b, err := currentBackend()
+	if err != nil {
+		return gopathBackend{}.UpdateCommand()
+	}
+	return b.UpdateCommand()
+}
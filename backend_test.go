@@ -0,0 +1,103 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"testing"
+)
+
+type fakeBackend struct{}
+
+func (fakeBackend) PathForRead(relPath string) (string, error) {
+	return "/read/" + relPath, nil
+}
+
+func (fakeBackend) PathForWrite(relPath string) (string, error) {
+	return "/write/" + relPath, nil
+}
+
+func (fakeBackend) UpdateCommand() string {
+	return "make update"
+}
+
+func TestBackends(t *testing.T) {
+	RegisterBackend("fake", fakeBackend{})
+	defer func() {
+		backends.Lock()
+		delete(backends.byName, "fake")
+		backends.Unlock()
+	}()
+	originalBackend := os.Getenv("GOLDEN_BACKEND")
+	defer os.Setenv("GOLDEN_BACKEND", originalBackend)
+	defer SetBackend("")
+
+	check := func(desc, wantRead, wantWrite, wantCommand string) {
+		if got, err := getFullPathForRead("a/b.golden"); got != wantRead || err != nil {
+			t.Errorf("%v: getFullPathForRead: got (%q, %v) want %q", desc, got, err, wantRead)
+		}
+		if got, err := getFullPathForWrite("a/b.golden"); got != wantWrite || err != nil {
+			t.Errorf("%v: getFullPathForWrite: got (%q, %v) want %q", desc, got, err, wantWrite)
+		}
+		if got := formatUpdateCommand(); got != wantCommand {
+			t.Errorf("%v: formatUpdateCommand: got %q want %q", desc, got, wantCommand)
+		}
+	}
+
+	os.Setenv("GOLDEN_BACKEND", "fake")
+	check("GOLDEN_BACKEND", "/read/a/b.golden", "/write/a/b.golden", "make update")
+
+	os.Setenv("GOLDEN_BACKEND", "nosuchbackend")
+	if _, err := getFullPathForRead("a/b.golden"); err == nil {
+		t.Errorf("getFullPathForRead with unknown GOLDEN_BACKEND: got nil error")
+	}
+	if err := SetBackend("fake"); err != nil {
+		t.Fatalf("SetBackend: %v", err)
+	}
+	check("SetBackend", "/read/a/b.golden", "/write/a/b.golden", "make update")
+	if _, err := getFullPathForRead("/abs/b.golden"); err != nil {
+		t.Errorf("getFullPathForRead of absolute path: %v", err)
+	}
+	if err := SetBackend("nosuchbackend"); err == nil {
+		t.Errorf("SetBackend of unknown backend: got nil error")
+	}
+}
+
+func TestRegisterBackendTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterBackend of an existing name did not panic")
+		}
+	}()
+	RegisterBackend("gopath", fakeBackend{})
+}
+
+func TestUpdateCommand(t *testing.T) {
+	differ := WithDiffer(DifferFunc(func(expected, actual string) string { return "diff\n" }))
+	compare := func(opts ...Option) string {
+		return Compare("", "github.com/google/golden/testdata/haiku.txt.golden", append(opts, differ)...)
+	}
+	if got, want := compare(), "Actual data differs from golden data; run \"go test -update_golden\" to update\ntestdata/haiku.txt.golden:1:1: first difference (byte offset 0)\ndiff\n"; got != want {
+		t.Errorf("default: got %q want %q", got, want)
+	}
+	SetUpdateCommand("make golden")
+	defer SetUpdateCommand("")
+	if got, want := compare(), "Actual data differs from golden data; run \"make golden\" to update\ntestdata/haiku.txt.golden:1:1: first difference (byte offset 0)\ndiff\n"; got != want {
+		t.Errorf("SetUpdateCommand: got %q want %q", got, want)
+	}
+	if got, want := compare(WithUpdateCommand("./update.sh")), "Actual data differs from golden data; run \"./update.sh\" to update\ntestdata/haiku.txt.golden:1:1: first difference (byte offset 0)\ndiff\n"; got != want {
+		t.Errorf("WithUpdateCommand: got %q want %q", got, want)
+	}
+}
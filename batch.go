@@ -0,0 +1,333 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompareAll compares many actual outputs to their golden files in one call,
+// which is handy for code generators emitting dozens of outputs per test.
+// files maps each golden file, as passed to Compare, to its actual data. The
+// result maps each golden file that did not match to the message Compare
+// would have returned for it, and is empty if everything matched.
+//
+// With WithParallelism, several golden files are compared concurrently. With
+// WithPrune, golden files left over in the directories it names are reported,
+// or removed.
+func CompareAll(files map[string]string, opts ...Option) map[string]string {
+	o := newOptions(opts)
+	goldenFiles := make([]string, 0, len(files))
+	for goldenFile := range files {
+		goldenFiles = append(goldenFiles, goldenFile)
+	}
+	sort.Strings(goldenFiles)
+
+	diffs := map[string]string{}
+	var mu sync.Mutex
+	forEachParallel(goldenFiles, o.parallelism, func(goldenFile string) {
+		if diff := Compare(files[goldenFile], goldenFile, opts...); diff != "" {
+			mu.Lock()
+			diffs[goldenFile] = diff
+			mu.Unlock()
+		}
+	})
+	if o.prune && o.storage == nil {
+		for _, goldenFile := range staleGoldenFiles(files, o) {
+			if diff := pruneGolden(goldenFile, o); diff != "" {
+				diffs[goldenFile] = diff
+			}
+		}
+	}
+	return diffs
+}
+
+// staleGoldenFiles returns the golden files in the directories given to
+// WithPrune that are not in files.
+func staleGoldenFiles(files map[string]string, o *options) []string {
+	known, dirs := map[string]bool{}, map[string]bool{}
+	for goldenFile := range files {
+		known[o.prefixed(goldenFile)] = true
+	}
+	for _, dir := range o.pruneDirs {
+		dirs[o.prefixed(strings.TrimSuffix(dir, "/"))] = true
+	}
+	var stale []string
+	for dir := range dirs {
+		fullPath, err := getFullPathForRead(dir)
+		if err != nil {
+			continue
+		}
+		entries, err := ioutil.ReadDir(fullPath)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if goldenFile := dir + "/" + e.Name(); e.Mode().IsRegular() && isGoldenFile(e.Name()) && !known[goldenFile] {
+				stale = append(stale, goldenFile)
+			}
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// pruneGolden removes the stale golden file goldenFile if -update_golden is
+// set, and otherwise describes it.
+func pruneGolden(goldenFile string, o *options) string {
+	if !shouldUpdateGolden() {
+		return fmt.Sprintf("Golden file %v has no actual data; run %q to remove it\n", goldenFile, o.updateCommandOrDefault())
+	}
+	fullPath, err := getFullPathForWrite(goldenFile)
+	if err == nil {
+		err = os.Remove(fullPath)
+	}
+	if err != nil {
+		return Fatalf("Error while removing golden file: %v", err)
+	}
+	return ""
+}
+
+// CompareDir compares every file under actualDir, such as the output of a
+// code generator, to the golden file with the same relative path and
+// ".golden" appended under goldenDir. goldenDir is resolved like the golden
+// files passed to Compare. Golden files under goldenDir without a
+// counterpart under actualDir are reported as well, and removed by
+// -update_golden with WithPrune. The result maps each mismatching golden file
+// to the message describing the mismatch, and is empty if everything
+// matched. Only the contents of files are compared, not metadata such as
+// their modes or modification times, unless requested with WithFileMetadata.
+// WithIncludeFiles and WithExcludeFiles select the files to compare, leaving
+// out noise such as .DS_Store files. An actual file named .metadata at the
+// root of actualDir is an error, since its golden file would be the one
+// holding the metadata.
+//
+// With -update_golden, CompareDir instead makes goldenDir mirror actualDir,
+// creating and updating golden files as needed, and removing them too with
+// WithPrune.
+//
+// Files are read and compared concurrently by GOMAXPROCS workers, or as many
+// as set with WithParallelism.
+func CompareDir(actualDir string, goldenDir string, opts ...Option) map[string]string {
+	o := newOptions(opts)
+	goldenDir = o.prefixed(strings.TrimSuffix(goldenDir, "/"))
+	for _, p := range append(append([]string(nil), o.includeFiles...), o.excludeFiles...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return map[string]string{goldenDir: Fatalf("Error while listing actual data: bad pattern %q: %v", p, err)}
+		}
+	}
+	actualFiles, err := listFiles(actualDir, "", o)
+	if err != nil {
+		return map[string]string{goldenDir: Fatalf("Error while listing actual data: %v", err)}
+	}
+	if name := strings.TrimSuffix(dirMetadataFile, ".golden"); actualFiles[name] {
+		return map[string]string{goldenDir: Fatalf("Error while listing actual data: %v is reserved for the golden file %v", filepath.Join(actualDir, name), dirMetadataFile)}
+	}
+	// A golden directory that cannot be found has no golden files yet; any
+	// other problem resolving it shows up when comparing its files.
+	goldenFiles := map[string]bool{}
+	if goldenDirPath, err := getFullPathForRead(goldenDir); err == nil && o.storage == nil {
+		if goldenFiles, err = listFiles(goldenDirPath, ".golden", o); err != nil {
+			return map[string]string{goldenDir: Fatalf("Error while listing golden files: %v", err)}
+		}
+	}
+	names := make([]string, 0, len(actualFiles)+len(goldenFiles))
+	for name := range actualFiles {
+		names = append(names, name)
+	}
+	for name := range goldenFiles {
+		if !actualFiles[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parallelism := o.parallelism
+	if parallelism == 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	diffs := map[string]string{}
+	var mu sync.Mutex
+	forEachParallel(names, parallelism, func(name string) {
+		goldenFile := goldenDir + "/" + name + ".golden"
+		if diff := compareDirEntry(actualDir, name, goldenFile, actualFiles[name], goldenFiles[name], o); diff != "" {
+			mu.Lock()
+			diffs[goldenFile] = diff
+			mu.Unlock()
+		}
+	})
+	if o.fileMetadata != 0 {
+		if diff := compareDirMetadata(actualDir, goldenDir, o); diff != "" {
+			diffs[goldenDir+"/"+dirMetadataFile] = diff
+		}
+	}
+	return diffs
+}
+
+// compareDirEntry compares the file name under actualDir to goldenFile for
+// CompareDir, given which of them exist.
+func compareDirEntry(actualDir string, name string, goldenFile string, inActual, inGolden bool, o *options) string {
+	if !inActual {
+		if !o.prune {
+			return fmt.Sprintf("Golden file %v has no counterpart in %v; remove it, or use WithPrune to have %q remove it\n", goldenFile, actualDir, o.updateCommandOrDefault())
+		}
+		if !shouldUpdateGolden() {
+			return fmt.Sprintf("Golden file %v has no counterpart in %v; run %q to remove it\n", goldenFile, actualDir, o.updateCommandOrDefault())
+		}
+		fullPath, err := getFullPathForWrite(goldenFile)
+		if err == nil {
+			err = os.Remove(fullPath)
+		}
+		if err != nil {
+			return Fatalf("Error while removing golden file: %v", err)
+		}
+		return ""
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(actualDir, filepath.FromSlash(name)))
+	if err != nil {
+		return Fatalf("Error while reading actual data: %v", err)
+	}
+	if shouldUpdateGolden() {
+		if o.storage == nil {
+			fullPath, err := getFullPathForWrite(goldenFile)
+			if err == nil {
+				err = os.MkdirAll(filepath.Dir(fullPath), 0770)
+			}
+			if err != nil {
+				return Fatalf("Error while updating golden file: %v", err)
+			}
+		}
+		if err := writeGolden(goldenFile, string(actual), o); err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	if !inGolden && o.storage == nil {
+		return fmt.Sprintf("Golden file %v does not exist; run %q to create it\n", goldenFile, o.updateCommandOrDefault())
+	}
+	r := check(string(actual), goldenFile, o)
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	return r.String()
+}
+
+// listFiles returns the slash-separated paths relative to dir of the regular
+// files under dir whose names end in suffix, with suffix removed, leaving out
+// those excluded by the options.
+func listFiles(dir string, suffix string, o *options) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() && rel != "." && o.excludesFile(rel) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() || !strings.HasSuffix(rel, suffix) {
+			return nil
+		}
+		if suffix == ".golden" && rel == dirMetadataFile {
+			return nil
+		}
+		if rel = strings.TrimSuffix(rel, suffix); o.includesFile(rel) && !o.excludesFile(rel) {
+			files[rel] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// includesFile reports whether the file at the slash-separated relative
+// path rel matches the patterns of WithIncludeFiles, if there are any.
+func (o *options) includesFile(rel string) bool {
+	if len(o.includeFiles) == 0 {
+		return true
+	}
+	for _, p := range o.includeFiles {
+		if matchesFile(p, rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesFile reports whether the file or directory at the slash-separated
+// relative path rel matches the patterns of WithExcludeFiles.
+func (o *options) excludesFile(rel string) bool {
+	for _, p := range o.excludeFiles {
+		if matchesFile(p, rel, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesFile reports whether pattern matches the relative path rel: its
+// last element if pattern has no slash, or any of its elements if anyElement
+// is set, and the whole path otherwise.
+func matchesFile(pattern string, rel string, anyElement bool) bool {
+	if strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, rel)
+		return ok
+	}
+	elements := strings.Split(rel, "/")
+	if !anyElement {
+		elements = elements[len(elements)-1:]
+	}
+	for _, e := range elements {
+		if ok, _ := path.Match(pattern, e); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachParallel calls f on each item, running up to parallelism calls
+// concurrently. It returns once all calls have returned.
+func forEachParallel(items []string, parallelism int, f func(string)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism && i < len(items); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				f(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		work <- item
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,206 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCompareAll(t *testing.T) {
+	haiku := "It reads many bits\nIt exchanges many bits\nIt writes many bits\n"
+	for _, parallelism := range []int{0, 1, 4} {
+		got := CompareAll(map[string]string{
+			"github.com/google/golden/testdata/haiku.txt.golden":    haiku,
+			"./testdata/haiku.txt.golden":                           haiku,
+			"github.com/google/golden/testdata/fragment.txt.golden": "It exchanges many bits\n",
+		}, WithParallelism(parallelism))
+		want := map[string]string{
+			"github.com/google/golden/testdata/fragment.txt.golden": `Actual data differs from golden data; run "go test -update_golden" to update
+testdata/fragment.txt.golden:2:1: first difference (byte offset 23)
+--- testdata/fragment.txt.golden
++++ testdata/fragment.txt.actual
+@@ -1,3 +1,2 @@
+ It exchanges many bits
+-It writes many bits
+ 
+`,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("CompareAll with parallelism %v: got %q want %q", parallelism, got, want)
+		}
+	}
+}
+
+func TestCompareAllWithPrune(t *testing.T) {
+	env := TestEnv(t)
+	if err := os.MkdirAll(env.Path("gen/sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"gen/a.golden", "gen/stale.golden", "gen/stale.txt", "gen/sub/nested.golden"} {
+		if err := ioutil.WriteFile(env.Path(name), []byte("a\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{"gen/a.golden": "a\n"}
+
+	if got := CompareAll(files); len(got) != 0 {
+		t.Errorf("CompareAll without WithPrune: got %q, want no diffs", got)
+	}
+	// The directories of the golden files may hold those of other tests.
+	if got := CompareAll(files, WithPrune()); len(got) != 0 {
+		t.Errorf("CompareAll with WithPrune without directories: got %q, want no diffs", got)
+	}
+	got := CompareAll(files, WithPrune("gen/"))
+	want := map[string]string{"gen/stale.golden": "Golden file gen/stale.golden has no actual data; run \"go test -update_golden\" to remove it\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareAll with WithPrune: got %q, want %q", got, want)
+	}
+
+	env.SetUpdating(true)
+	if got := CompareAll(files, WithPrune("gen")); len(got) != 0 {
+		t.Errorf("CompareAll with WithPrune and -update_golden: got %q, want no diffs", got)
+	}
+	for name, wantExist := range map[string]bool{"gen/a.golden": true, "gen/stale.golden": false, "gen/stale.txt": true, "gen/sub/nested.golden": true} {
+		if _, err := os.Stat(env.Path(name)); (err == nil) != wantExist {
+			t.Errorf("%v after pruning: got %v, want existing %v", name, err, wantExist)
+		}
+	}
+}
+
+func TestCompareDirWithFileFilters(t *testing.T) {
+	env := TestEnv(t)
+	actualDir := env.Path("actual")
+	for _, name := range []string{"actual/a.go", "actual/b.txt", "actual/.DS_Store", "actual/x.tmp", "actual/node_modules/m.go", "actual/api/v1.json", "actual/api/sub/v2.json"} {
+		if err := os.MkdirAll(filepath.Dir(env.Path(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(env.Path(name), []byte(name+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		opts []Option
+		want []string
+	}{
+		{
+			opts: []Option{WithExcludeFiles(".DS_Store", "*.tmp", "node_modules")},
+			want: []string{"a.go", "api/sub/v2.json", "api/v1.json", "b.txt"},
+		},
+		{
+			opts: []Option{WithIncludeFiles("*.go", "api/*.json"), WithExcludeFiles("node_modules")},
+			want: []string{"a.go", "api/v1.json"},
+		},
+	}
+	for _, test := range tests {
+		got := CompareDir(actualDir, "golden", test.opts...)
+		var names []string
+		for goldenFile := range got {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(goldenFile, "golden/"), ".golden"))
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("CompareDir with %d options: got mismatches for %q, want %q", len(test.opts), names, test.want)
+		}
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	var mu sync.Mutex
+	var got []string
+	forEachParallel(items, 3, func(item string) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, item)
+	})
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, items) {
+		t.Errorf("forEachParallel visited %q, want %q", got, items)
+	}
+}
+
+func TestCompareDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, contents string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("out/same.txt", "same\n")
+	write("out/sub/changed.txt", "new\n")
+	write("out/created.txt", "created\n")
+	write("src/fake/testdata/gen/same.txt.golden", "same\n")
+	write("src/fake/testdata/gen/sub/changed.txt.golden", "old\n")
+	write("src/fake/testdata/gen/removed.txt.golden", "removed\n")
+	defer setGoPathForTest(dir)()
+	actualDir := filepath.Join(dir, "out")
+
+	for _, parallelism := range []int{0, 1, 3} {
+		got := CompareDir(actualDir, "fake/testdata/gen/", WithParallelism(parallelism))
+		var gotFiles []string
+		for goldenFile := range got {
+			gotFiles = append(gotFiles, goldenFile)
+		}
+		sort.Strings(gotFiles)
+		want := []string{"fake/testdata/gen/created.txt.golden", "fake/testdata/gen/removed.txt.golden", "fake/testdata/gen/sub/changed.txt.golden"}
+		if !reflect.DeepEqual(gotFiles, want) {
+			t.Errorf("CompareDir with parallelism %v: got mismatches in %v want %v", parallelism, gotFiles, want)
+		}
+		if msg := got["fake/testdata/gen/created.txt.golden"]; !strings.HasPrefix(msg, "Golden file fake/testdata/gen/created.txt.golden does not exist") {
+			t.Errorf("CompareDir message for a new file: got %q", msg)
+		}
+		if msg := got["fake/testdata/gen/removed.txt.golden"]; !strings.HasPrefix(msg, "Golden file fake/testdata/gen/removed.txt.golden has no counterpart in "+actualDir) {
+			t.Errorf("CompareDir message for a removed file: got %q", msg)
+		}
+		if msg := got["fake/testdata/gen/sub/changed.txt.golden"]; !strings.Contains(msg, "-old\n+new\n") {
+			t.Errorf("CompareDir message for a changed file: got %q", msg)
+		}
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	defer resetUpdatesForTest()()
+	got := CompareDir(actualDir, "fake/testdata/gen")
+	if msg := got["fake/testdata/gen/removed.txt.golden"]; len(got) != 1 || !strings.Contains(msg, "use WithPrune") {
+		t.Errorf("CompareDir with -update_golden: got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src/fake/testdata/gen/removed.txt.golden")); err != nil {
+		t.Errorf("golden file without counterpart removed by update without WithPrune: %v", err)
+	}
+	if got := CompareDir(actualDir, "fake/testdata/gen", WithPrune()); len(got) != 0 {
+		t.Errorf("CompareDir with -update_golden and WithPrune: got %q", got)
+	}
+	updateGolden.Store(false)
+	if got := CompareDir(actualDir, "fake/testdata/gen"); len(got) != 0 {
+		t.Errorf("CompareDir after update: got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src/fake/testdata/gen/removed.txt.golden")); !os.IsNotExist(err) {
+		t.Errorf("golden file without counterpart not removed by update with WithPrune: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// bazelBackend resolves golden files relative to the workspace root. Tests
+// run by "bazel test" can only read their runfiles, so updates have to go
+// through "bazel run", which tells the test where the workspace is.
+type bazelBackend struct{}
+
+func (bazelBackend) PathForRead(relPath string) (string, error) {
+	srcDir, workspace := os.Getenv("TEST_SRCDIR"), os.Getenv("TEST_WORKSPACE")
+	if srcDir == "" || workspace == "" {
+		return "", fmt.Errorf("TEST_SRCDIR and TEST_WORKSPACE must be set; is this test running under bazel?")
+	}
+	return filepath.Join(srcDir, workspace, filepath.FromSlash(relPath)), nil
+}
+
+func (bazelBackend) PathForWrite(relPath string) (string, error) {
+	workspaceDir := os.Getenv("BUILD_WORKSPACE_DIRECTORY")
+	if workspaceDir == "" {
+		return "", categorize(fmt.Errorf("BUILD_WORKSPACE_DIRECTORY is not set; golden files can only be updated with %q", bazelBackend{}.UpdateCommand()), ErrUpdateRefused)
+	}
+	return filepath.Join(workspaceDir, filepath.FromSlash(relPath)), nil
+}
+
+func (bazelBackend) UpdateCommand() string {
+	target := os.Getenv("TEST_TARGET")
+	if target == "" {
+		target = "<test target>"
+	}
+	return "bazel run " + target + " -- -update_golden"
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setenvForTest sets environment variables and returns a function restoring
+// their previous values.
+func setenvForTest(kv map[string]string) func() {
+	original := map[string]string{}
+	for k, v := range kv {
+		original[k] = os.Getenv(k)
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range original {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+func TestBazelBackend(t *testing.T) {
+	defer setenvForTest(map[string]string{
+		"TEST_SRCDIR":               "/runfiles",
+		"TEST_WORKSPACE":            "_main",
+		"BUILD_WORKSPACE_DIRECTORY": "",
+		"TEST_TARGET":               "//pkg:pkg_test",
+	})()
+	b := bazelBackend{}
+	if got, err := b.PathForRead("pkg/testdata/a.golden"); got != filepath.FromSlash("/runfiles/_main/pkg/testdata/a.golden") || err != nil {
+		t.Errorf("PathForRead: got (%q, %v)", got, err)
+	}
+	if got, want := b.UpdateCommand(), "bazel run //pkg:pkg_test -- -update_golden"; got != want {
+		t.Errorf("UpdateCommand: got %q want %q", got, want)
+	}
+	wantErr := `BUILD_WORKSPACE_DIRECTORY is not set; golden files can only be updated with "bazel run //pkg:pkg_test -- -update_golden"`
+	if _, err := b.PathForWrite("pkg/testdata/a.golden"); err == nil || err.Error() != wantErr {
+		t.Errorf("PathForWrite under bazel test: got %v want %v", err, wantErr)
+	}
+
+	os.Setenv("BUILD_WORKSPACE_DIRECTORY", "/workspace")
+	if got, err := b.PathForWrite("pkg/testdata/a.golden"); got != filepath.FromSlash("/workspace/pkg/testdata/a.golden") || err != nil {
+		t.Errorf("PathForWrite under bazel run: got (%q, %v)", got, err)
+	}
+}
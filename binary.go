@@ -0,0 +1,57 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// binarySniffLen is how much of the data isBinary looks at, as in Git.
+const binarySniffLen = 8000
+
+// isBinary guesses whether data is binary rather than text: it is if its
+// beginning holds a NUL byte, or if more than a tenth of it is not valid
+// UTF-8.
+func isBinary(data string) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+	}
+	if strings.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	invalid := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRuneInString(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRuneInString(data[i:]) {
+				// A character cut off by binarySniffLen.
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return invalid*10 > len(data)
+}
+
+// binaryDiff describes how binary data differs from the golden data, since a
+// line diff would only print garbage to the terminal.
+func binaryDiff(expected, actual string) string {
+	return fmt.Sprintf("Binary data differs:\ngolden: %d bytes, sha256 %x\nactual: %d bytes, sha256 %x\n",
+		len(expected), sha256.Sum256([]byte(expected)), len(actual), sha256.Sum256([]byte(actual)))
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	var tests = []struct {
+		data string
+		want bool
+	}{
+		{"", false},
+		{"plain text\n", false},
+		{"héllo wörld ☃\n", false},
+		{"a\x00b", true},
+		{"\x89PNG\r\n\x1a\n\xff\xfe\xfd", true},
+		{"latin-1 caf\xe9 in a long enough line of text\n", false},
+		{strings.Repeat("x", binarySniffLen) + "\x00", false},
+		{strings.Repeat("x", binarySniffLen-1) + "☃", false},
+	}
+	for _, test := range tests {
+		if got := isBinary(test.data); got != test.want {
+			t.Errorf("isBinary(%.30q): got %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestCompareBinary(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	Compare("\x00\x01\x02\x03", "data.bin.golden")
+	env.SetUpdating(false)
+
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+data.bin.golden: first difference at byte offset 2
+Binary data differs:
+golden: 4 bytes, sha256 054edec1d0211f624fed0cbca9d4f9400b0e491c43742af2c5b0abebf0c990d8
+actual: 3 bytes, sha256 faee935763044f124d7526755a5058a33f9402a595994d59eddd4be8546ff201
+`
+	if got := Compare("\x00\x01\x00", "data.bin.golden"); got != want {
+		t.Errorf("Compare of binary data: got %q, want %q", got, want)
+	}
+	if got := Compare("\x00\x01\x00", "data.bin.golden", WithDiffer(unifiedDiffer{})); strings.Contains(got, "Binary data differs") {
+		t.Errorf("Compare of binary data WithDiffer: got %q, want a diff", got)
+	}
+}
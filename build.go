@@ -13,7 +13,7 @@
 // limitations under the License.
 
 // Build system specific logic. Forks that use custom build systems can modify
-// this file.
+// this file, or register a Backend of their own.
 
 package golden
 
@@ -22,37 +22,260 @@ import (
 	"fmt"
 	"go/build"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 var (
 	// This flag is ONLY for use in tests.
-	updateGolden = flag.Bool("update_golden", false, "Whether to update the golden files if they differ.")
+	updateGolden = newBoolFlag("update_golden", false, "Whether to update the golden files if they differ.")
+	backupGolden = newBoolFlag("backup_golden", false, "When updating golden files, whether to save the previous contents to <file>.bak.")
+	// updateGoldenDir implies update_golden.
+	updateGoldenDir = newStringFlag("update_golden_dir", "", "If set, golden files are updated in a tree under this directory that mirrors the repository instead of in place, for review before running golden promote.")
 )
 
-func getFullPathForRead(relPath string) (string, error) {
-	goPaths := filepath.SplitList(build.Default.GOPATH)
+var goPath struct {
+	sync.Mutex
+	value    string
+	resolved bool
+}
+
+// effectiveGoPath returns the GOPATH the go tool would use, which unlike
+// build.Default.GOPATH takes into account settings made with "go env -w". It
+// falls back to build.Default.GOPATH if the go tool cannot be run. The result
+// is cached for the lifetime of the process.
+func effectiveGoPath() string {
+	goPath.Lock()
+	defer goPath.Unlock()
+	if !goPath.resolved {
+		goPath.value = build.Default.GOPATH
+		if out, err := exec.Command("go", "env", "GOPATH").Output(); err == nil {
+			goPath.value = strings.TrimSpace(string(out))
+		}
+		goPath.resolved = true
+	}
+	return goPath.value
+}
+
+func setGoPathForTest(p string) func() {
+	goPath.Lock()
+	defer goPath.Unlock()
+	originalValue, originalResolved := goPath.value, goPath.resolved
+	goPath.value, goPath.resolved = p, true
+	return func() {
+		goPath.Lock()
+		defer goPath.Unlock()
+		goPath.value, goPath.resolved = originalValue, originalResolved
+	}
+}
+
+var explicitRoots struct {
+	sync.Mutex
+	dirs []string
+}
+
+// SetSearchRoots makes relative golden file paths resolve against dirs
+// instead of the src directories of the GOPATH entries. This suits
+// repositories that are not laid out as a GOPATH, such as monorepos. Calling
+// SetSearchRoots without arguments restores the default.
+//
+// Search roots can also be set with the GOLDEN_ROOTS environment variable,
+// which holds a list of directories separated by os.PathListSeparator.
+// SetSearchRoots takes precedence over the environment variable.
+func SetSearchRoots(dirs ...string) {
+	explicitRoots.Lock()
+	defer explicitRoots.Unlock()
+	explicitRoots.dirs = append([]string(nil), dirs...)
+}
+
+var writeRoot struct {
+	sync.Mutex
+	policy string
+}
+
+// SetWriteRoot sets where new golden files go when relative paths resolve
+// against several GOPATH entries or search roots and none of them has the
+// golden file's directory yet. By default writing fails, since the right
+// root cannot be guessed. The policy is one of:
+//
+//     first    the first root
+//     package  the root containing the current directory, which is the
+//              package directory when running tests
+//     <index>  the root at that index, starting at 0
+//
+// The missing directories are then created. The policy can also be set with
+// the GOLDEN_WRITE_ROOT environment variable; SetWriteRoot takes precedence
+// over it. Passing the empty string restores the default.
+func SetWriteRoot(policy string) error {
+	if _, err := parseWriteRoot(policy); err != nil {
+		return err
+	}
+	writeRoot.Lock()
+	defer writeRoot.Unlock()
+	writeRoot.policy = policy
+	return nil
+}
+
+// parseWriteRoot checks policy and returns the index it names, or -1 if it
+// is not an index.
+func parseWriteRoot(policy string) (int, error) {
+	switch policy {
+	case "", "first", "package":
+		return -1, nil
+	}
+	i, err := strconv.Atoi(policy)
+	if err != nil || i < 0 {
+		return -1, fmt.Errorf("invalid write root policy %q; want first, package or an index", policy)
+	}
+	return i, nil
+}
+
+// chooseWriteRoot returns the root that new golden files go to according to
+// the write root policy, or the empty string if there is no policy.
+func chooseWriteRoot(roots []string, where string) (string, error) {
+	writeRoot.Lock()
+	policy, source := writeRoot.policy, "SetWriteRoot"
+	writeRoot.Unlock()
+	if policy == "" {
+		policy, source = os.Getenv("GOLDEN_WRITE_ROOT"), "GOLDEN_WRITE_ROOT"
+	}
+	i, err := parseWriteRoot(policy)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", source, err)
+	}
+	switch policy {
+	case "":
+		return "", nil
+	case "first":
+		return roots[0], nil
+	case "package":
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		for _, root := range roots {
+			if rel, err := filepath.Rel(root, wd); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return root, nil
+			}
+		}
+		return "", fmt.Errorf("%v: none of the %v contain the current directory %v", source, where, wd)
+	}
+	if i >= len(roots) {
+		return "", fmt.Errorf("%v: index %d is out of range for the %d entries in the %v", source, i, len(roots), where)
+	}
+	return roots[i], nil
+}
+
+// searchRoots returns the directories that relative golden file paths are
+// resolved against, along with a description of where they came from for
+// use in error messages.
+func searchRoots() ([]string, string, error) {
+	explicitRoots.Lock()
+	roots := explicitRoots.dirs
+	explicitRoots.Unlock()
+	if len(roots) > 0 {
+		return roots, "search roots", nil
+	}
+	if roots := filepath.SplitList(os.Getenv("GOLDEN_ROOTS")); len(roots) > 0 {
+		return roots, "GOLDEN_ROOTS", nil
+	}
+	goPaths := filepath.SplitList(effectiveGoPath())
 	if len(goPaths) == 0 {
-		return "", fmt.Errorf("GOPATH is empty")
+		return nil, "", ErrGOPATHEmpty
+	}
+	for i, p := range goPaths {
+		goPaths[i] = path.Join(p, "src")
+	}
+	return goPaths, "GOPATH", nil
+}
+
+// gopathBackend resolves golden files against the src directories of the
+// GOPATH entries, or against the search roots if there are any.
+type gopathBackend struct{}
+
+func (gopathBackend) PathForRead(relPath string) (string, error) {
+	return gopathPathForRead(relPath)
+}
+
+func (gopathBackend) PathForWrite(relPath string) (string, error) {
+	return gopathPathForWrite(relPath)
+}
+
+func (gopathBackend) UpdateCommand() string {
+	return "go test -update_golden"
+}
+
+// resolvedPaths caches where golden files were found, so that suites making
+// thousands of comparisons only stat the search roots once per golden file.
+// Entries are keyed by the search roots as well as the relative path, so that
+// they no longer apply once GOPATH or the search roots change. Golden files
+// are not expected to move between roots while tests run.
+var resolvedPaths struct {
+	sync.Mutex
+	byKey map[string]string
+}
+
+func resolvedPathKey(roots []string, relPath string) string {
+	return strings.Join(roots, "\x00") + "\x00" + relPath
+}
+
+func resetResolvedPathsForTest() {
+	resolvedPaths.Lock()
+	defer resolvedPaths.Unlock()
+	resolvedPaths.byKey = nil
+}
+
+func gopathPathForRead(relPath string) (string, error) {
+	roots, where, err := searchRoots()
+	if err != nil {
+		return "", err
+	}
+	key := resolvedPathKey(roots, relPath)
+	resolvedPaths.Lock()
+	fullPath, ok := resolvedPaths.byKey[key]
+	resolvedPaths.Unlock()
+	if ok {
+		return fullPath, nil
 	}
-	err := error(nil)
-	for _, p := range goPaths {
-		fullPath := path.Join(p, "src", relPath)
+	for _, root := range roots {
+		fullPath := path.Join(root, relPath)
 		_, err = os.Stat(fullPath)
 		if err == nil {
+			resolvedPaths.Lock()
+			if resolvedPaths.byKey == nil {
+				resolvedPaths.byKey = map[string]string{}
+			}
+			resolvedPaths.byKey[key] = fullPath
+			resolvedPaths.Unlock()
 			return fullPath, nil
 		}
 	}
 
 	if os.IsNotExist(err) {
-		return "", fmt.Errorf("%v: file not found in GOPATH", relPath)
-
+		return "", &notFoundError{relPath, where}
 	}
 	return "", err
 }
 
+// notFoundError reports a golden file missing from all of the places it was
+// looked for. It satisfies errors.Is(err, ErrGoldenNotFound).
+type notFoundError struct {
+	relPath, where string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%v: file not found in %v", e.relPath, e.where)
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrGoldenNotFound
+}
+
 func sortedKeys(m map[string]bool) []string {
 	result := make([]string, len(m))
 	i := 0
@@ -64,20 +287,20 @@ func sortedKeys(m map[string]bool) []string {
 	return result
 }
 
-func getFullPathForWrite(relPath string) (string, error) {
-	goPaths := filepath.SplitList(build.Default.GOPATH)
-	if len(goPaths) == 0 {
-		return "", fmt.Errorf("GOPATH is empty")
+func gopathPathForWrite(relPath string) (string, error) {
+	roots, where, err := searchRoots()
+	if err != nil {
+		return "", err
 	}
-	if len(goPaths) == 1 {
-		// If there is only a single GOPATH, just use it
-		return path.Join(goPaths[0], "src", relPath), nil
+	if len(roots) == 1 {
+		// If there is only a single root, just use it
+		return path.Join(roots[0], relPath), nil
 	}
 	existingFiles := map[string]bool{}
 	possibleDirectories := map[string]bool{}
 	filesWithExistingDir := map[string]bool{}
-	for _, p := range goPaths {
-		fullPath := path.Join(p, "src", relPath)
+	for _, root := range roots {
+		fullPath := path.Join(root, relPath)
 		_, err := os.Stat(fullPath)
 		if err == nil {
 			existingFiles[fullPath] = true
@@ -90,7 +313,7 @@ func getFullPathForWrite(relPath string) (string, error) {
 		}
 	}
 	if len(existingFiles) > 1 {
-		return "", fmt.Errorf("there are multiple files in the GOPATH with the same relative path %q: %v", relPath, sortedKeys(existingFiles))
+		return "", categorize(fmt.Errorf("there are multiple files in the %v with the same relative path %q: %v", where, relPath, sortedKeys(existingFiles)), ErrAmbiguousPath)
 	}
 
 	if len(existingFiles) == 1 {
@@ -99,7 +322,7 @@ func getFullPathForWrite(relPath string) (string, error) {
 		}
 	}
 	if len(filesWithExistingDir) > 1 {
-		return "", fmt.Errorf("there are multiple suitable directories in the GOPATH: %v", sortedKeys(filesWithExistingDir))
+		return "", categorize(fmt.Errorf("there are multiple suitable directories in the %v: %v", where, sortedKeys(filesWithExistingDir)), ErrAmbiguousPath)
 	}
 
 	if len(filesWithExistingDir) == 1 {
@@ -107,27 +330,72 @@ func getFullPathForWrite(relPath string) (string, error) {
 			return fullPath, nil
 		}
 	}
-	return "", fmt.Errorf("none of these directories in the GOPATH exist: %v", sortedKeys(possibleDirectories))
+	root, err := chooseWriteRoot(roots, where)
+	if err != nil {
+		return "", err
+	}
+	if root != "" {
+		fullPath := path.Join(root, relPath)
+		return fullPath, os.MkdirAll(filepath.Dir(fullPath), 0770)
+	}
+	return "", fmt.Errorf("none of these directories in the %v exist: %v; see SetWriteRoot", where, sortedKeys(possibleDirectories))
+}
+
+// flagNames lists the flags that golden registers on flag.CommandLine.
+var flagNames = []string{"update_golden", "update_golden_dir", "backup_golden", "golden_artifacts_dir", "golden_patch_dir", "golden_missing_as_empty"}
+
+// RegisterFlags registers golden's flags, such as -update_golden, on fs, for
+// test frameworks and TestMain functions that parse their own FlagSet rather
+// than flag.CommandLine. The flags on fs and on flag.CommandLine share their
+// values, so either can be used to set them; Updating reports the result.
+// RegisterFlags panics if fs already has a flag of the same name, as
+// flag.FlagSet does.
+func RegisterFlags(fs *flag.FlagSet) {
+	for _, name := range flagNames {
+		f := flag.CommandLine.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	registerUpdateAlias(fs)
+}
+
+// registerUpdateAlias registers -update on fs as a short alias of
+// -update_golden, as used by goldie, cupaloy and many hand-written golden
+// tests, and reports whether it did. It leaves fs alone if another package
+// already registered an -update flag there, since defining it again would
+// panic; -update_golden then has to be used instead.
+func registerUpdateAlias(fs *flag.FlagSet) bool {
+	if fs.Lookup("update") != nil {
+		return false
+	}
+	fs.Var(flag.CommandLine.Lookup("update_golden").Value, "update", "Alias of -update_golden.")
+	return true
 }
 
 func shouldUpdateGolden() bool {
-	return *updateGolden
+	return updateGolden.Load() || updateGoldenDir.Load() != ""
 }
 
-func formatUpdateCommand() string {
-	return "go test -update_golden"
+// Updating reports whether golden files are being updated, that is, whether
+// the -update_golden or -update_golden_dir flag is set. It is meant for
+// helpers built on Check, which unlike Compare never updates golden files by
+// itself.
+func Updating() bool {
+	return shouldUpdateGolden()
+}
+
+func shouldBackupGolden() bool {
+	return backupGolden.Load()
 }
 
 func enableUpdateGoldenForTest(tmpdir string) func() {
-	originalGoPath := build.Default.GOPATH
-	originalUpdateGolden := *updateGolden
+	restoreGoPath := setGoPathForTest(tmpdir)
+	originalUpdateGolden := updateGolden.Load()
 
-	build.Default.GOPATH = tmpdir
-	*updateGolden = true
+	updateGolden.Store(true)
 
 	restoreFunc := func() {
-		build.Default.GOPATH = originalGoPath
-		*updateGolden = originalUpdateGolden
+		restoreGoPath()
+		updateGolden.Store(originalUpdateGolden)
 	}
 	return restoreFunc
 }
@@ -16,10 +16,12 @@ package golden
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"go/build"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -80,6 +82,26 @@ func TestGetFullPath(t *testing.T) {
 					in:       "github.com/google/foobar/hi.txt",
 					err:      "GOPATH is empty",
 				},
+				{
+					function: getFullPathForRead,
+					in:       "/abs/foobar/hi.txt",
+					out:      "/abs/foobar/hi.txt",
+				},
+				{
+					function: getFullPathForWrite,
+					in:       "/abs/foobar/hi.txt",
+					out:      "/abs/foobar/hi.txt",
+				},
+				{
+					function: getFullPathForRead,
+					in:       "./testdata/hi.txt",
+					out:      "./testdata/hi.txt",
+				},
+				{
+					function: getFullPathForWrite,
+					in:       "../foobar/testdata/hi.txt",
+					out:      "../foobar/testdata/hi.txt",
+				},
 			},
 		},
 		{
@@ -179,7 +201,7 @@ func TestGetFullPath(t *testing.T) {
 				{
 					function: getFullPathForWrite,
 					in:       "github.com/google/nosuchdir/a.txt",
-					err:      "none of these directories in the GOPATH exist: [{{.TempDir}}/p1/src/github.com/google/nosuchdir {{.TempDir}}/p2/src/github.com/google/nosuchdir]",
+					err:      "none of these directories in the GOPATH exist: [{{.TempDir}}/p1/src/github.com/google/nosuchdir {{.TempDir}}/p2/src/github.com/google/nosuchdir]; see SetWriteRoot",
 				},
 			},
 		},
@@ -208,11 +230,7 @@ func TestGetFullPath(t *testing.T) {
 				return buf.String()
 			}
 
-			originalGoPath := build.Default.GOPATH
-			build.Default.GOPATH = expandTemplate(env.goPath)
-			defer func() {
-				build.Default.GOPATH = originalGoPath
-			}()
+			defer setGoPathForTest(expandTemplate(env.goPath))()
 			for _, pathTemplate := range env.fakeFiles {
 				fullPath := expandTemplate(pathTemplate)
 				fullDir := path.Dir(fullPath)
@@ -244,3 +262,212 @@ func TestGetFullPath(t *testing.T) {
 		}()
 	}
 }
+
+func TestEffectiveGoPath(t *testing.T) {
+	defer setGoPathForTest("")()
+	goPath.Lock()
+	goPath.resolved = false
+	goPath.Unlock()
+	want := build.Default.GOPATH
+	if out, err := exec.Command("go", "env", "GOPATH").Output(); err == nil {
+		want = strings.TrimSpace(string(out))
+	}
+	if got := effectiveGoPath(); got != want {
+		t.Errorf("effectiveGoPath(): got %q want %q", got, want)
+	}
+}
+
+func TestSearchRoots(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	for _, dir := range []string{"r1/foo", "r2/bar"} {
+		if err := os.MkdirAll(path.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Error making 'fake' directory: %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(path.Join(tempDir, "r2/bar/hi.txt"), []byte(""), 0644); err != nil {
+		t.Fatalf("Cannot write fake file: %v", err)
+	}
+	r1, r2 := path.Join(tempDir, "r1"), path.Join(tempDir, "r2")
+
+	check := func(desc string) {
+		if got, err := getFullPathForRead("bar/hi.txt"); got != path.Join(r2, "bar/hi.txt") || err != nil {
+			t.Errorf("%v: getFullPathForRead: got (%q, %v)", desc, got, err)
+		}
+		if got, err := getFullPathForWrite("foo/new.txt"); got != path.Join(r1, "foo/new.txt") || err != nil {
+			t.Errorf("%v: getFullPathForWrite: got (%q, %v)", desc, got, err)
+		}
+	}
+
+	originalRoots := os.Getenv("GOLDEN_ROOTS")
+	defer os.Setenv("GOLDEN_ROOTS", originalRoots)
+	os.Setenv("GOLDEN_ROOTS", r1+string(filepath.ListSeparator)+r2)
+	check("GOLDEN_ROOTS")
+	_, err = getFullPathForRead("nosuchfile.txt")
+	if want := "nosuchfile.txt: file not found in GOLDEN_ROOTS"; err == nil || err.Error() != want {
+		t.Errorf("getFullPathForRead of missing file: got %v want %v", err, want)
+	}
+
+	os.Setenv("GOLDEN_ROOTS", "")
+	SetSearchRoots(r1, r2)
+	defer SetSearchRoots()
+	check("SetSearchRoots")
+	_, err = getFullPathForWrite("nosuchdir/new.txt")
+	if want := "none of these directories in the search roots exist"; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("getFullPathForWrite into missing directory: got %v want prefix %v", err, want)
+	}
+}
+
+func TestResolvedPathCache(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	defer resetResolvedPathsForTest()
+	r1, r2 := path.Join(tempDir, "r1"), path.Join(tempDir, "r2")
+	for _, dir := range []string{r1, r2} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Error making 'fake' directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, "hi.txt"), []byte(""), 0644); err != nil {
+			t.Fatalf("Cannot write fake file: %v", err)
+		}
+	}
+	defer SetSearchRoots()
+	var tests = []struct {
+		desc  string
+		roots []string
+		setup func()
+		want  string
+	}{
+		{"first lookup", []string{r1, r2}, func() {}, path.Join(r1, "hi.txt")},
+		// The cached path is returned without checking the roots again.
+		{"cached", []string{r1, r2}, func() { os.Remove(path.Join(r1, "hi.txt")) }, path.Join(r1, "hi.txt")},
+		{"roots changed", []string{tempDir, r2}, func() {}, path.Join(r2, "hi.txt")},
+		{"cache reset", []string{r1, r2}, resetResolvedPathsForTest, path.Join(r2, "hi.txt")},
+	}
+	for _, test := range tests {
+		SetSearchRoots(test.roots...)
+		test.setup()
+		if got, err := getFullPathForRead("hi.txt"); got != test.want || err != nil {
+			t.Errorf("%v: getFullPathForRead: got (%q, %v) want %q", test.desc, got, err, test.want)
+		}
+	}
+}
+
+func TestSetWriteRoot(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "golden_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	r1, r2 := path.Join(tempDir, "r1"), path.Join(tempDir, "r2")
+	if err := os.MkdirAll(path.Join(r2, "pkg"), 0755); err != nil {
+		t.Fatalf("Error making 'fake' directory: %v", err)
+	}
+	SetSearchRoots(r1, r2)
+	defer SetSearchRoots()
+	defer setenvForTest(map[string]string{"GOLDEN_WRITE_ROOT": ""})()
+	defer SetWriteRoot("")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(path.Join(r2, "pkg")); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		policy, env string
+		out, err    string
+	}{
+		{policy: "", err: "none of these directories in the search roots exist"},
+		{policy: "first", out: r1},
+		{policy: "package", out: r2},
+		{policy: "1", out: r2},
+		{policy: "2", err: "SetWriteRoot: index 2 is out of range for the 2 entries in the search roots"},
+		{env: "0", out: r1},
+		{env: "last", err: "GOLDEN_WRITE_ROOT: invalid write root policy \"last\""},
+		{policy: "package", env: "first", out: r2},
+	}
+	for _, test := range tests {
+		if err := SetWriteRoot(test.policy); err != nil {
+			t.Fatalf("SetWriteRoot(%q): %v", test.policy, err)
+		}
+		os.Setenv("GOLDEN_WRITE_ROOT", test.env)
+		dir := fmt.Sprintf("new%d", len(test.policy+test.env))
+		got, err := getFullPathForWrite(path.Join(dir, "a.golden"))
+		if test.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("policy %q, env %q: got (%q, %v) want error %v", test.policy, test.env, got, err, test.err)
+			}
+			continue
+		}
+		if want := path.Join(test.out, dir, "a.golden"); got != want || err != nil {
+			t.Errorf("policy %q, env %q: got (%q, %v) want %v", test.policy, test.env, got, err, want)
+		}
+		if _, err := os.Stat(path.Join(test.out, dir)); err != nil {
+			t.Errorf("policy %q, env %q: directory not created: %v", test.policy, test.env, err)
+		}
+		os.RemoveAll(path.Join(test.out, dir))
+	}
+	if err := SetWriteRoot("-1"); err == nil {
+		t.Errorf("SetWriteRoot(\"-1\"): got nil error")
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	defer enableUpdateGoldenForTest(os.TempDir())()
+	updateGolden.Store(false)
+	originalPatchDir := patchDir.Load()
+	defer func() { patchDir.Store(originalPatchDir) }()
+
+	fs := flag.NewFlagSet("custom", flag.ContinueOnError)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-update_golden", "-golden_patch_dir=patches"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !Updating() {
+		t.Errorf("Updating() after parsing -update_golden on a custom FlagSet: got false")
+	}
+	if patchDir.Load() != "patches" {
+		t.Errorf("-golden_patch_dir on a custom FlagSet: got %q", patchDir.Load())
+	}
+	for _, name := range flagNames {
+		if fs.Lookup(name) == nil {
+			t.Errorf("flag %v not registered", name)
+		}
+	}
+}
+
+func TestUpdateAlias(t *testing.T) {
+	defer enableUpdateGoldenForTest(os.TempDir())()
+	updateGolden.Store(false)
+
+	fs := flag.NewFlagSet("custom", flag.ContinueOnError)
+	if !registerUpdateAlias(fs) {
+		t.Fatalf("registerUpdateAlias on an empty FlagSet: got false")
+	}
+	if err := fs.Parse([]string{"-update"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !Updating() {
+		t.Errorf("Updating() after parsing -update: got false")
+	}
+
+	updateGolden.Store(false)
+	fs = flag.NewFlagSet("conflict", flag.ContinueOnError)
+	theirs := fs.Bool("update", false, "Somebody else's flag.")
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-update"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !*theirs || Updating() {
+		t.Errorf("-update already defined by another package: got theirs %v, Updating() %v, want true, false", *theirs, Updating())
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// callingTest returns the name of the outermost test function, such as
+// "TestRender", on the current call stack, or the empty string if there is
+// none. Closures passed to t.Run are attributed to the enclosing test.
+func callingTest() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var test string
+	for {
+		frame, more := frames.Next()
+		if name := testFuncName(frame.Function); name != "" {
+			test = name
+		}
+		if !more {
+			return test
+		}
+	}
+}
+
+// currentGoroutine returns the ID of the current goroutine, as shown in stack
+// traces, or 0 if it cannot be found.
+func currentGoroutine() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseUint(string(buf), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// testFuncName returns the name of the test function that function, a fully
+// qualified function name as reported by runtime.Frame, is or belongs to, or
+// the empty string if it is not part of a test function.
+func testFuncName(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	parts := strings.Split(function, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	name := parts[1]
+	if !strings.HasPrefix(name, "Test") {
+		return ""
+	}
+	// As in go test, TestMain and Testing do not name tests.
+	if name == "TestMain" {
+		return ""
+	}
+	if r, _ := utf8.DecodeRuneInString(name[len("Test"):]); len(name) > len("Test") && unicode.IsLower(r) {
+		return ""
+	}
+	return name
+}
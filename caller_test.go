@@ -0,0 +1,52 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "testing"
+
+func TestTestFuncName(t *testing.T) {
+	tests := []struct {
+		function string
+		want     string
+	}{
+		{"github.com/google/golden.TestRender", "TestRender"},
+		{"github.com/google/golden.TestRender.func1", "TestRender"},
+		{"github.com/google/golden.TestRender.func1.2", "TestRender"},
+		{"example.com/pkg_test.Test", "Test"},
+		{"example.com/pkg_test.Test_render", "Test_render"},
+		{"example.com/pkg.Testing", ""},
+		{"example.com/pkg.TestMain", ""},
+		{"example.com/pkg.render", ""},
+		{"example.com/pkg.(*T).TestRender", ""},
+		{"testing.tRunner", ""},
+		{"main", ""},
+	}
+	for _, tt := range tests {
+		if got := testFuncName(tt.function); got != tt.want {
+			t.Errorf("testFuncName(%q) = %q, want %q", tt.function, got, tt.want)
+		}
+	}
+}
+
+func TestCallingTest(t *testing.T) {
+	if got, want := callingTest(), "TestCallingTest"; got != want {
+		t.Errorf("callingTest() = %q, want %q", got, want)
+	}
+	t.Run("subtest", func(t *testing.T) {
+		if got, want := callingTest(), "TestCallingTest"; got != want {
+			t.Errorf("callingTest() in subtest = %q, want %q", got, want)
+		}
+	})
+}
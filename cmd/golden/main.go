@@ -0,0 +1,159 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command golden maintains golden files outside of tests.
+//
+// Usage:
+//
+//     golden apply <patchdir>
+//         Apply the patches saved by tests run with -golden_patch_dir, for
+//         example downloaded from CI, to the golden files they update. Run it
+//         from the root of the repository. Either all patches apply or no
+//         golden file is changed.
+//     golden manifest <dir>
+//         Write a MANIFEST file listing the SHA-256 sum of every golden file
+//         under dir.
+//     golden verify <dir>
+//         Check the golden files under dir against dir/MANIFEST and fail if
+//         any was modified, removed or added.
+//     golden promote <dir>
+//         Copy the golden files updated by tests run with
+//         -update_golden_dir=<dir> into place. Run it from the root of the
+//         repository once the files under dir have been reviewed.
+//     golden quarantine <file> <YYYY-MM-DD> <owner>
+//         Let the golden file mismatch until the end of the given day.
+//     golden unquarantine <file>
+//         Lift the quarantine of the golden file.
+//     golden reads <report> <dir>
+//         Check the read report written by tests run with GOLDEN_READ_REPORT
+//         set against the golden files under dir, and fail if any was never
+//         read or was read more than once.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/golden"
+)
+
+type command struct {
+	args string
+	run  func(args []string) error
+}
+
+var commands = map[string]command{
+	"apply": {
+		args: "<patchdir>",
+		run: func(args []string) error {
+			files, err := golden.ApplyPatches(args[0])
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Println("updated", f)
+			}
+			return nil
+		},
+	},
+	"manifest": {
+		args: "<dir>",
+		run: func(args []string) error {
+			return golden.WriteManifest(args[0])
+		},
+	},
+	"verify": {
+		args: "<dir>",
+		run: func(args []string) error {
+			return golden.VerifyManifest(args[0])
+		},
+	},
+	"promote": {
+		args: "<dir>",
+		run: func(args []string) error {
+			files, err := golden.PromoteGoldens(args[0])
+			for _, f := range files {
+				fmt.Println("promoted", f)
+			}
+			return err
+		},
+	},
+	"quarantine": {
+		args: "<file> <YYYY-MM-DD> <owner>",
+		run: func(args []string) error {
+			until, err := time.Parse("2006-01-02", args[1])
+			if err != nil {
+				return err
+			}
+			return golden.Quarantine(args[0], until, args[2])
+		},
+	},
+	"reads": {
+		args: "<report> <dir>",
+		run: func(args []string) error {
+			findings, err := golden.AuditReads(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			if len(findings) > 0 {
+				return fmt.Errorf("%d problems found:\n  %v", len(findings), strings.Join(findings, "\n  "))
+			}
+			return nil
+		},
+	},
+	"unquarantine": {
+		args: "<file>",
+		run: func(args []string) error {
+			return golden.LiftQuarantine(args[0])
+		},
+	},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  golden %v %v\n", name, commands[name].args)
+	}
+}
+
+// run runs the command described by args and returns the exit code.
+func run(args []string, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok || len(args)-1 != len(strings.Fields(cmd.args)) {
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintf(stderr, "golden %v: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden_cmd_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(path.Join(dir, "a.golden"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		args   []string
+		code   int
+		stderr string
+	}{
+		{args: nil, code: 2, stderr: "Usage:"},
+		{args: []string{"nosuchcommand", dir}, code: 2, stderr: "Usage:"},
+		{args: []string{"verify", dir}, code: 1, stderr: "golden verify: "},
+		{args: []string{"manifest", dir}, code: 0},
+		{args: []string{"verify", dir}, code: 0},
+		{args: []string{"quarantine", path.Join(dir, "a.golden"), "2017-03-01"}, code: 2, stderr: "Usage:"},
+		{args: []string{"quarantine", path.Join(dir, "a.golden"), "March", "alice"}, code: 1, stderr: "golden quarantine: "},
+		{args: []string{"quarantine", path.Join(dir, "a.golden"), "2017-03-01", "alice"}, code: 0},
+		{args: []string{"verify", dir}, code: 1, stderr: "golden verify: "},
+		{args: []string{"unquarantine", path.Join(dir, "a.golden")}, code: 0},
+		{args: []string{"verify", dir}, code: 0},
+		{args: []string{"apply", path.Join(dir, "nosuchdir")}, code: 1, stderr: "golden apply: "},
+		{args: []string{"apply", dir}, code: 0},
+		{args: []string{"promote", path.Join(dir, "nosuchdir")}, code: 1, stderr: "golden promote: "},
+	}
+	for _, test := range tests {
+		stderr := &bytes.Buffer{}
+		if code := run(test.args, stderr); code != test.code {
+			t.Errorf("run(%q): got exit code %v want %v; stderr: %v", test.args, code, test.code, stderr)
+		}
+		if !strings.HasPrefix(stderr.String(), test.stderr) {
+			t.Errorf("run(%q): got stderr %q want prefix %q", test.args, stderr, test.stderr)
+		}
+	}
+}
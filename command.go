@@ -0,0 +1,115 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompareCommand runs cmd, such as a CLI binary built for the test, and
+// compares a transcript of the run to goldenFile with CompareSections. The
+// transcript holds the standard output, the standard error and the exit code
+// of cmd:
+//
+//     -- stdout --
+//     Wrote 3 files to /out
+//     -- stderr --
+//     -- exit code --
+//     0
+//
+// Paths that vary between machines and runs are replaced with variables in
+// stdout and stderr: cmd.Dir with $DIR, the current directory with $PWD, the
+// temporary directory with $TMPDIR and the home directory with $HOME. So is
+// the value of any variable set to an absolute path in cmd.Env, with $ and
+// the variable's name. Pass Normalizers such as ScrubLogs to mask other
+// variable output.
+//
+// cmd must not have its Stdout or Stderr set. If it cannot be run at all, the
+// error is returned as the failure message; exiting with a non-zero code is
+// not an error.
+func CompareCommand(cmd *exec.Cmd, goldenFile string, opts ...Option) string {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return fmt.Sprintf("Error running %v for %v: Stdout or Stderr already set\n", cmd.Path, goldenFile)
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return fmt.Sprintf("Error running %v for %v: %v\n", cmd.Path, goldenFile, err)
+	}
+	scrub := commandScrubber(cmd)
+	return CompareSections([]Section{
+		{"stdout", scrub.Replace(stdout.String())},
+		{"stderr", scrub.Replace(stderr.String())},
+		{"exit code", fmt.Sprintf("%d\n", exitCode)},
+	}, goldenFile, opts...)
+}
+
+// commandScrubber returns a Replacer replacing the paths described for
+// CompareCommand with variables.
+func commandScrubber(cmd *exec.Cmd) *strings.Replacer {
+	paths := map[string]string{}
+	add := func(p, variable string) {
+		if !filepath.IsAbs(p) {
+			return
+		}
+		if p = filepath.Clean(p); p == string(filepath.Separator) {
+			return
+		}
+		if _, ok := paths[p]; !ok {
+			paths[p] = variable
+		}
+	}
+	// Earlier paths take precedence over later ones with the same value.
+	add(cmd.Dir, "$DIR")
+	if wd, err := os.Getwd(); err == nil {
+		add(wd, "$PWD")
+	}
+	for _, kv := range cmd.Env {
+		if i := strings.Index(kv, "="); i > 0 {
+			add(kv[i+1:], "$"+kv[:i])
+		}
+	}
+	add(os.TempDir(), "$TMPDIR")
+	if home, err := os.UserHomeDir(); err == nil {
+		add(home, "$HOME")
+	}
+	// Longer paths are replaced first, so that the temporary directory does
+	// not mask a directory within it.
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	var oldnew []string
+	for _, p := range sorted {
+		oldnew = append(oldnew, p, paths[p])
+	}
+	return strings.NewReplacer(oldnew...)
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	env := TestEnv(t)
+	dir := env.Path("work")
+	cache := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(cache, 0700); err != nil {
+		t.Fatal(err)
+	}
+	command := func(script string) *exec.Cmd {
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "CACHE="+cache)
+		return cmd
+	}
+
+	env.SetUpdating(true)
+	CompareCommand(command("echo wrote $PWD/out; echo cached in $CACHE >&2; exit 3"), "cmd.golden")
+	data, err := ioutil.ReadFile(env.Path("cmd.golden"))
+	want := "-- stdout --\nwrote $DIR/out\n-- stderr --\ncached in $CACHE\n-- exit code --\n3\n"
+	if string(data) != want || err != nil {
+		t.Errorf("golden file: got %q, %v want %q", data, err, want)
+	}
+
+	env.SetUpdating(false)
+	var tests = []struct {
+		script string
+		want   string
+	}{
+		{"echo wrote $PWD/out; echo cached in $CACHE >&2; exit 3", ""},
+		{"echo wrote $PWD/out; echo cached in $CACHE >&2", `section "exit code" differs`},
+		{"echo wrote $PWD/other", `section "stdout" differs`},
+	}
+	for _, test := range tests {
+		got := CompareCommand(command(test.script), "cmd.golden")
+		if (test.want == "") != (got == "") || !strings.Contains(got, test.want) {
+			t.Errorf("CompareCommand(%q): got %q, want it to contain %q", test.script, got, test.want)
+		}
+	}
+
+	cmd := command("true")
+	cmd.Stdout = &bytes.Buffer{}
+	if got := CompareCommand(cmd, "cmd.golden"); !strings.Contains(got, "Stdout or Stderr already set") {
+		t.Errorf("CompareCommand with Stdout set: got %q", got)
+	}
+	if got := CompareCommand(exec.Command(filepath.Join(dir, "missing")), "cmd.golden"); !strings.HasPrefix(got, "Error running") {
+		t.Errorf("CompareCommand of a missing binary: got %q", got)
+	}
+}
+
+func TestCommandScrubber(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Dir = filepath.Join(os.TempDir(), "work")
+	cmd.Env = []string{"A=" + filepath.Join(cmd.Dir, "a"), "B=relative", "ROOT=/"}
+	got := commandScrubber(cmd).Replace(filepath.Join(cmd.Dir, "a", "x") + " " + filepath.Join(cmd.Dir, "y") + " " + filepath.Join(os.TempDir(), "z") + " relative /")
+	if want := "$A/x $DIR/y $TMPDIR/z relative /"; got != want {
+		t.Errorf("commandScrubber: got %q want %q", got, want)
+	}
+}
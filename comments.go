@@ -0,0 +1,73 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "strings"
+
+// DefaultCommentPrefix starts comment lines in golden files compared with
+// WithComments("").
+const DefaultCommentPrefix = "#!golden:"
+
+// WithComments lets golden files contain comment lines, starting with prefix
+// or DefaultCommentPrefix if prefix is empty, which are dropped before
+// comparing. They can explain what a golden file checks or why its data looks
+// the way it does:
+//
+//     #!golden: The second line must stay sorted; see issue 42.
+//     first
+//     second
+//
+// Updating a golden file keeps the comment lines at its top, but drops those
+// further down, since they cannot be placed reliably in the new data.
+func WithComments(prefix string) Option {
+	if prefix == "" {
+		prefix = DefaultCommentPrefix
+	}
+	return func(o *options) {
+		o.commentPrefix = prefix
+	}
+}
+
+// stripComments removes the comment lines from golden data.
+func (o *options) stripComments(s string) string {
+	if o.commentPrefix == "" {
+		return s
+	}
+	lines := strings.SplitAfter(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, o.commentPrefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// leadingComments splits golden data into the comment lines at its top and
+// the rest.
+func (o *options) leadingComments(s string) (comments string, rest string) {
+	if o.commentPrefix == "" {
+		return "", s
+	}
+	rest = s
+	for strings.HasPrefix(rest, o.commentPrefix) {
+		i := strings.Index(rest, "\n")
+		if i < 0 {
+			i = len(rest) - 1
+		}
+		rest = rest[i+1:]
+	}
+	return s[:len(s)-len(rest)], rest
+}
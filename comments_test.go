@@ -0,0 +1,84 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	o := newOptions([]Option{WithComments("")})
+	var tests = []struct {
+		in, comments, rest, stripped string
+	}{
+		{"", "", "", ""},
+		{"data\n", "", "data\n", "data\n"},
+		{"#!golden: why\ndata\n", "#!golden: why\n", "data\n", "data\n"},
+		{"#!golden: a\n#!golden: b\ndata\n#!golden: c\nmore", "#!golden: a\n#!golden: b\n", "data\n#!golden: c\nmore", "data\nmore"},
+		{"#!golden: only", "#!golden: only", "", ""},
+		{" #!golden: indented\n", "", " #!golden: indented\n", " #!golden: indented\n"},
+	}
+	for _, test := range tests {
+		comments, rest := o.leadingComments(test.in)
+		if comments != test.comments || rest != test.rest {
+			t.Errorf("leadingComments(%q): got (%q, %q) want (%q, %q)", test.in, comments, rest, test.comments, test.rest)
+		}
+		if got := o.stripComments(test.in); got != test.stripped {
+			t.Errorf("stripComments(%q): got %q want %q", test.in, got, test.stripped)
+		}
+	}
+	if got := newOptions(nil).stripComments("#!golden: x\n"); got != "#!golden: x\n" {
+		t.Errorf("stripComments without WithComments: got %q", got)
+	}
+}
+
+func TestCompareWithComments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/commented.golden")
+	original := "// Sorted by name.\nalice\n// Bob was added later.\nbob\n"
+	if err := ioutil.WriteFile(goldenPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer setGoPathForTest(dir)()
+
+	if diff := Compare("alice\nbob\n", "fake/testdata/commented.golden", WithComments("// ")); diff != "" {
+		t.Errorf("Compare with comments: %v", diff)
+	}
+	if diff := Compare("alice\nbob\n", "fake/testdata/commented.golden"); diff == "" {
+		t.Errorf("Compare without WithComments: got no diff")
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	Compare("alice\nbob\n", "fake/testdata/commented.golden", WithComments("// "))
+	if written, _ := ioutil.ReadFile(goldenPath); string(written) != original {
+		t.Errorf("golden file with unchanged data: got %q want %q", written, original)
+	}
+	Compare("alice\ncarol\n", "fake/testdata/commented.golden", WithComments("// "))
+	want := "// Sorted by name.\nalice\ncarol\n"
+	if written, _ := ioutil.ReadFile(goldenPath); string(written) != want {
+		t.Errorf("updated golden file: got %q want %q", written, want)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"strings"
+)
+
+// WithDecompress makes the comparison decompress the golden and the actual
+// data first if they are gzip or zlib streams, such as gzipped exports, so
+// that differences in compression level, timestamps or other settings of
+// the compressor do not cause mismatches. Mismatches are then reported as
+// diffs of the decompressed data. Other data is compared as is, and updates
+// still write the raw actual data. Digest golden files are not affected.
+func WithDecompress() Option {
+	return func(o *options) {
+		o.decompress = true
+	}
+}
+
+// decompress returns data decompressed if it is a gzip stream, possibly of
+// several members, or a zlib stream, and data itself otherwise. A corrupt
+// gzip stream is an error; data that only starts like a zlib stream, which
+// has a two-byte header that text can start with by chance, is returned as
+// is if it cannot be decompressed.
+func decompress(data string) (string, error) {
+	switch {
+	case strings.HasPrefix(data, "\x1f\x8b\x08"):
+		r, err := gzip.NewReader(strings.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	case isZlibHeader(data):
+		r, err := zlib.NewReader(strings.NewReader(data))
+		if err != nil {
+			return data, nil
+		}
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			return data, nil
+		}
+		return string(out), nil
+	}
+	return data, nil
+}
+
+// isZlibHeader reports whether data starts with a zlib header using the
+// deflate method, as described in RFC 1950.
+func isZlibHeader(data string) bool {
+	return len(data) >= 2 && data[0]&0x0f == 8 && data[0]>>4 <= 7 && (int(data[0])<<8|int(data[1]))%31 == 0
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gzipForTest(t *testing.T, data string, level int, modTime time.Time) string {
+	buf := &bytes.Buffer{}
+	w, err := gzip.NewWriterLevel(buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.ModTime = modTime
+	w.Write([]byte(data))
+	w.Close()
+	return buf.String()
+}
+
+func zlibForTest(t *testing.T, data string, level int) string {
+	buf := &bytes.Buffer{}
+	w, err := zlib.NewWriterLevel(buf, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(data))
+	w.Close()
+	return buf.String()
+}
+
+func TestDecompress(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{desc: "text", data: "plain\n", want: "plain\n"},
+		{desc: "empty", data: "", want: ""},
+		{desc: "gzip", data: gzipForTest(t, "hello\n", gzip.BestSpeed, time.Unix(1, 0)), want: "hello\n"},
+		{desc: "zlib", data: zlibForTest(t, "hello\n", zlib.BestCompression), want: "hello\n"},
+		{desc: "text starting like zlib", data: "x^2\n", want: "x^2\n"},
+		{desc: "corrupt gzip", data: gzipForTest(t, "hello\n", gzip.BestSpeed, time.Time{})[:12], wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := decompress(test.data)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("%v: decompress: got %q, %v, want %q, error %v", test.desc, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestCompareWithDecompress(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	Compare(gzipForTest(t, "a\nb\n", gzip.BestCompression, time.Unix(1, 0)), "export.gz.golden")
+	env.SetUpdating(false)
+
+	other := gzipForTest(t, "a\nb\n", gzip.BestSpeed, time.Unix(2, 0))
+	if got := Compare(other, "export.gz.golden", WithDecompress()); got != "" {
+		t.Errorf("Compare of differently compressed data WithDecompress: got %q, want no diff", got)
+	}
+	if got := Compare(other, "export.gz.golden"); got == "" {
+		t.Errorf("Compare of differently compressed data: got no diff")
+	}
+	changed := gzipForTest(t, "a\nc\n", gzip.BestSpeed, time.Unix(2, 0))
+	if got := Compare(changed, "export.gz.golden", WithDecompress()); !strings.Contains(got, "-b\n+c\n") {
+		t.Errorf("Compare of changed compressed data WithDecompress: got %q, want a diff of the decompressed data", got)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Contains checks that the contents of goldenFragmentFile appear as a
+// contiguous block of whole lines within actual, and returns an empty string
+// if they do. Otherwise it returns a diff between the fragment and the block
+// of actual that resembles it most.
+//
+// This is useful when only a stable excerpt of a large, partly
+// nondeterministic output matters. Since it is not known which part of actual
+// the fragment should be replaced with, -update_golden has no effect on
+// Contains. With WithMissingAsEmpty, a fragment file that does not exist is
+// reported with all of actual as added lines.
+func Contains(actual string, goldenFragmentFile string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFragmentFile = o.prefixed(goldenFragmentFile)
+	fullPath, _, fragment, missing, err := readGoldenOrMissing(goldenFragmentFile, o)
+	if err != nil {
+		return Fatalf("Error while reading golden file: %v", err)
+	}
+	fragment, actual = o.normalize(o.expandVariables(goldenFragmentFile, o.stripComments(fragment))), o.normalize(actual)
+	if missing {
+		// Show all of actual, as Compare does with WithMissingAsEmpty.
+		return fmt.Sprintf("Golden fragment file %v does not exist\n%v", displayPath(goldenFragmentFile, fullPath, o), o.containsDiffer(goldenFragmentFile, fullPath, "actual").Diff("", actual))
+	}
+	if fragment == "" {
+		// Any data contains the empty fragment.
+		return ""
+	}
+	want := strings.Split(strings.TrimSuffix(fragment, "\n"), "\n")
+	got := strings.Split(strings.TrimSuffix(actual, "\n"), "\n")
+
+	// Find the window of actual lines agreeing with the most fragment lines.
+	best, bestEqual := 0, -1
+	for start := 0; start+len(want) <= len(got) || start == 0; start++ {
+		equal := 0
+		for i := range want {
+			if start+i < len(got) && got[start+i] == want[i] {
+				equal++
+			}
+		}
+		if equal == len(want) {
+			return ""
+		}
+		if equal > bestEqual {
+			best, bestEqual = start, equal
+		}
+	}
+	end := best + len(want)
+	if end > len(got) {
+		end = len(got)
+	}
+	differ := o.containsDiffer(goldenFragmentFile, fullPath, fmt.Sprintf("actual lines %d-%d", best+1, end))
+	diffstr := differ.Diff(strings.Join(want, "\n")+"\n", strings.Join(got[best:end], "\n")+"\n")
+	return fmt.Sprintf("Actual data does not contain the golden fragment\n%v", diffstr)
+}
+
+// containsDiffer returns the Differ showing how actual, named toFile, differs
+// from the golden fragment file for Contains.
+func (o *options) containsDiffer(goldenFragmentFile string, fullPath string, toFile string) Differ {
+	if o.differ != nil {
+		return o.differ
+	}
+	return unifiedDiffer{
+		fromFile: displayPath(goldenFragmentFile, fullPath, o),
+		toFile:   toFile,
+		patience: o.patience,
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestContains(t *testing.T) {
+	var tests = []struct {
+		actual string
+		want   string
+	}{
+		{
+			actual: "It reads many bits\nIt exchanges many bits\nIt writes many bits\n",
+			want:   "",
+		},
+		{
+			actual: "header\nIt exchanges many bits\nIt writes many bits\nfooter",
+			want:   "",
+		},
+		{
+			actual: "It exchanges many bits\nIt writes many bits",
+			want:   "",
+		},
+		{
+			actual: "header\nIt exchanges many bits\nfooter\nIt writes many bits\n",
+			want: `Actual data does not contain the golden fragment
+--- testdata/fragment.txt.golden
++++ actual lines 2-3
+@@ -1,3 +1,3 @@
+ It exchanges many bits
+-It writes many bits
++footer
+ 
+`,
+		},
+		{
+			actual: "short",
+			want: `Actual data does not contain the golden fragment
+--- testdata/fragment.txt.golden
++++ actual lines 1-1
+@@ -1,3 +1,2 @@
+-It exchanges many bits
+-It writes many bits
++short
+ 
+`,
+		},
+	}
+	for _, test := range tests {
+		got := Contains(test.actual, "github.com/google/golden/testdata/fragment.txt.golden")
+		if got != test.want {
+			t.Errorf("Contains(%q): got %q, want %q", test.actual, got, test.want)
+		}
+	}
+}
+
+func TestContainsEmptyFragment(t *testing.T) {
+	env := TestEnv(t)
+	if err := ioutil.WriteFile(env.Path("empty.golden"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, actual := range []string{"", "some\ndata\n"} {
+		if got := Contains(actual, "empty.golden"); got != "" {
+			t.Errorf("Contains(%q) of an empty fragment: got %q, want no diff", actual, got)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"crypto/sha256"
+)
+
+// hashChunkSize is how much data hashContext hashes between checks for
+// cancellation.
+const hashChunkSize = 1 << 20
+
+// CompareContext is like Compare, but gives up as soon as ctx is done while
+// reading large golden files, hashing data for digest and pointer golden
+// files, or waiting for a Storage such as a remote golden store, so that a
+// hung server fails the test with the context's error instead of blocking it
+// until the test binary times out:
+//
+//     ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//     defer cancel()
+//     if diff := golden.CompareContext(ctx, got, "testdata/big.golden.ptr", golden.WithBlobStorage(store)); diff != "" {
+//       t.Error(diff)
+//     }
+//
+// Storage operations are only interrupted for real if the Storage implements
+// ContextStorage; otherwise CompareContext stops waiting for them.
+func CompareContext(ctx context.Context, actual string, goldenFile string, opts ...Option) string {
+	return Compare(actual, goldenFile, withContext(ctx, opts)...)
+}
+
+// CheckContext is like Check, but gives up as soon as ctx is done, as
+// CompareContext does. The context's error is then returned by Result.Err.
+func CheckContext(ctx context.Context, actual string, goldenFile string, opts ...Option) Result {
+	return Check(actual, goldenFile, withContext(ctx, opts)...)
+}
+
+// withContext returns opts followed by an option setting ctx, without
+// modifying the caller's slice.
+func withContext(ctx context.Context, opts []Option) []Option {
+	return append(opts[:len(opts):len(opts)], func(o *options) { o.ctx = ctx })
+}
+
+// context returns the context set by CompareContext or CheckContext, or
+// context.Background.
+func (o *options) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// A ContextStorage is a Storage whose operations can be canceled, such as
+// one sending requests over the network. HTTPStorage and GCSStorage return
+// ContextStorages.
+type ContextStorage interface {
+	Storage
+	// ReadContext is like Read, but gives up when ctx is done.
+	ReadContext(ctx context.Context, key string) ([]byte, error)
+	// WriteContext is like Write, but gives up when ctx is done.
+	WriteContext(ctx context.Context, key string, data []byte) error
+}
+
+// storageRead reads key from s, giving up when ctx is done. Reads from
+// Storages that are not ContextStorages keep running in the background.
+func storageRead(ctx context.Context, s Storage, key string) ([]byte, error) {
+	if cs, ok := s.(ContextStorage); ok {
+		return cs.ReadContext(ctx, key)
+	}
+	var data []byte
+	err := runContext(ctx, func() (err error) {
+		data, err = s.Read(key)
+		return err
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// storageWrite writes data under key in s, giving up when ctx is done, as
+// storageRead does.
+func storageWrite(ctx context.Context, s Storage, key string, data []byte) error {
+	if cs, ok := s.(ContextStorage); ok {
+		return cs.WriteContext(ctx, key, data)
+	}
+	return runContext(ctx, func() error { return s.Write(key, data) }, nil)
+}
+
+// readMappedContext is like readMapped, but gives up when ctx is done, for
+// example because the file lives on a hung network file system.
+func readMappedContext(ctx context.Context, path string) ([]byte, func(), error) {
+	if ctx.Done() == nil {
+		// Keep the common case free of the allocations of readMappedAsync.
+		return readMapped(path)
+	}
+	return readMappedAsync(ctx, path)
+}
+
+// readMappedAsync is readMappedContext for contexts that can be done.
+func readMappedAsync(ctx context.Context, path string) (data []byte, release func(), err error) {
+	err = runContext(ctx, func() (err error) {
+		data, release, err = readMapped(path)
+		return err
+	}, func() {
+		if release != nil {
+			release()
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, release, nil
+}
+
+// runContext runs f and returns its error, or the error of ctx if ctx is
+// done first. f then keeps running in the background, and cleanup, if not
+// nil, is called once it returns to release what it produced.
+func runContext(ctx context.Context, f func() error, cleanup func()) error {
+	if ctx.Done() == nil {
+		return f()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- f() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		go func() {
+			if err := <-done; err == nil && cleanup != nil {
+				cleanup()
+			}
+		}()
+		return ctx.Err()
+	}
+}
+
+// hashContext returns the SHA-256 digest of data, checking whether ctx is
+// done every hashChunkSize bytes.
+func hashContext(ctx context.Context, data string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	h := sha256.New()
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			return sum, err
+		}
+		n := hashChunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		h.Write([]byte(data[:n]))
+		data = data[n:]
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
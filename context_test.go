@@ -0,0 +1,98 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingStorage is a Storage that never answers until it is released.
+type blockingStorage struct {
+	release chan struct{}
+}
+
+func (s blockingStorage) Read(key string) ([]byte, error) {
+	<-s.release
+	return []byte("data\n"), nil
+}
+
+func (s blockingStorage) Write(key string, data []byte) error {
+	<-s.release
+	return nil
+}
+
+func TestCheckContext(t *testing.T) {
+	env := TestEnv(t)
+	s := blockingStorage{make(chan struct{})}
+	defer close(s.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r := CheckContext(ctx, "data\n", "hung.golden", WithStorage(s))
+	if !errors.Is(r.Err(), context.DeadlineExceeded) {
+		t.Errorf("CheckContext with a hung Storage: got error %v, want %v", r.Err(), context.DeadlineExceeded)
+	}
+	if r := CheckContext(context.Background(), "data\n", "hung.golden", WithStorage(DirStorage(env.Path("store")))); !errors.Is(r.Err(), ErrGoldenNotFound) {
+		t.Errorf("CheckContext without a deadline: got error %v, want %v", r.Err(), ErrGoldenNotFound)
+	}
+}
+
+func TestCompareContext(t *testing.T) {
+	env := TestEnv(t)
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	storage := WithStorage(HTTPStorage(server.URL, nil))
+	if got := CompareContext(ctx, "data\n", "remote.golden", storage); len(l.errors) != 1 || !strings.Contains(l.errors[0], context.Canceled.Error()) {
+		t.Errorf("CompareContext with a canceled context: got %q, errors %q", got, l.errors)
+	}
+
+	env.SetUpdating(true)
+	if got := CompareContext(ctx, "data\n", "local.golden"); len(l.errors) != 2 {
+		t.Errorf("CompareContext updating with a canceled context: got %q, errors %q", got, l.errors)
+	}
+	env.SetUpdating(false)
+	if got := CompareContext(context.Background(), "data\n", "local.golden", WithMissingAsEmpty()); !strings.Contains(got, "+data") {
+		t.Errorf("CompareContext after a canceled update: got %q, want a diff", got)
+	}
+}
+
+func TestHashContext(t *testing.T) {
+	data := strings.Repeat("x", hashChunkSize+1)
+	if got, err := hashContext(context.Background(), data); got != sha256.Sum256([]byte(data)) || err != nil {
+		t.Errorf("hashContext: got %x, %v, want %x", got, err, sha256.Sum256([]byte(data)))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := hashContext(ctx, data); err != context.Canceled {
+		t.Errorf("hashContext with a canceled context: got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A G compares the outputs of a test to golden files laid out by convention,
+// so that tests need not name them:
+//
+//     func TestRender(t *testing.T) {
+//       g := golden.New(t)
+//       g.Check(render(input))
+//     }
+//
+// The golden files of a test are kept in testdata/golden next to the file of
+// the test, in testdata/golden/<TestName>.golden for a test and in
+// testdata/golden/<TestName>/<SubtestName>.golden for its subtests.
+//
+// Test names are made safe to use as file names on Windows and macOS as
+// well: in each part of a name,
+//
+//   - characters other than letters, digits and "-", "_", "." and "+", such
+//     as ":", "\" and "*", are replaced with "_"
+//   - a leading or trailing dot is replaced with "_"
+//   - names reserved on Windows, such as CON or LPT1, get "_" appended
+//   - names longer than 100 bytes are cut short, and the first 8 hex digits
+//     of the SHA-256 of the full name are appended after a "-"
+//
+// Since this can map different test names to the same file, and file names
+// differing only in case collide on common file systems, Check reports an
+// error when two tests would share a golden file.
+type G struct {
+	t    T
+	opts []Option
+	// dir is the directory of the test file.
+	dir string
+	// checks counts the calls to Check.
+	checks int
+}
+
+// New returns a G for the running test t, which compares with opts.
+func New(t T, opts ...Option) *G {
+	t.Helper()
+	return &G{t: t, opts: opts, dir: callerTestDir()}
+}
+
+// Check compares actual to the next golden file of the test, and reports a
+// mismatch, or a golden file that does not exist yet, as an error of the
+// test. The first call of a test uses its golden file; further calls use
+// <TestName>.2.golden, <TestName>.3.golden and so on. If the -update_golden
+// flag is set, Check instead writes the golden file, creating its directory
+// as needed.
+func (g *G) Check(actual string) {
+	g.t.Helper()
+	g.checks++
+	goldenFile := g.path(g.checks)
+	if other := claimConventionPath(goldenFile, g.t.Name()); other != "" {
+		g.t.Error(fmt.Sprintf("Golden file %v of %v is also used by %v; rename one of the tests", goldenFile, g.t.Name(), other))
+		return
+	}
+	o := newOptions(g.opts)
+	if shouldUpdateGolden() {
+		var err error
+		if updateGoldenDir.Load() == "" {
+			err = os.MkdirAll(filepath.Dir(goldenFile), 0770)
+		}
+		if err == nil {
+			err = writeGolden(goldenFile, actual, o)
+		}
+		if err != nil {
+			g.t.Error(fmt.Sprintf("Error while updating golden file: %v", err))
+		}
+		return
+	}
+	r := check(actual, goldenFile, o)
+	switch {
+	case errors.Is(r.err, ErrGoldenNotFound):
+		g.t.Error(fmt.Sprintf("Golden file %v does not exist; run %q to create it\n", r.displayPath, o.updateCommandOrDefault()))
+	case r.String() != "":
+		g.t.Error(r.String())
+	}
+}
+
+// path returns the golden file of the nth call to Check.
+func (g *G) path(n int) string {
+	name := g.t.Name()
+	if n > 1 {
+		name = fmt.Sprintf("%v.%d", name, n)
+	}
+	p := filepath.Join(g.dir, "testdata", "golden", filepath.FromSlash(sanitizeTestName(name))+".golden")
+	if !filepath.IsAbs(p) {
+		// Keep the path relative to the package directory.
+		p = "./" + filepath.ToSlash(p)
+	}
+	return p
+}
+
+var conventionPaths = struct {
+	sync.Mutex
+	// byKey maps the lowercase golden files of G to the tests using them.
+	byKey map[string]string
+}{byKey: map[string]string{}}
+
+// claimConventionPath records that test uses goldenFile, and returns the
+// other test already using it, or a file whose name differs only in case, if
+// there is one.
+func claimConventionPath(goldenFile string, test string) string {
+	key := strings.ToLower(goldenFile)
+	conventionPaths.Lock()
+	defer conventionPaths.Unlock()
+	if other, ok := conventionPaths.byKey[key]; ok && other != test {
+		return other
+	}
+	conventionPaths.byKey[key] = test
+	return ""
+}
+
+// maxNameLength is the length in bytes beyond which sanitizeTestName
+// shortens the parts of test names.
+const maxNameLength = 100
+
+// windowsReservedNames lists the file names reserved on Windows, which
+// cannot be used even with an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeTestName makes each slash-separated part of the test name safe to
+// use as a file name, as described for G.
+func sanitizeTestName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		safe := []rune(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.+", r) {
+				return r
+			}
+			return '_'
+		}, part))
+		if len(safe) == 0 {
+			safe = []rune{'_'}
+		}
+		if safe[0] == '.' {
+			safe[0] = '_'
+		}
+		if safe[len(safe)-1] == '.' {
+			safe[len(safe)-1] = '_'
+		}
+		s := string(safe)
+		if base := strings.SplitN(s, ".", 2)[0]; windowsReservedNames[strings.ToUpper(base)] {
+			s = base + "_" + s[len(base):]
+		}
+		if len(s) > maxNameLength {
+			sum := sha256.Sum256([]byte(part))
+			suffix := "-" + hex.EncodeToString(sum[:4])
+			cut := maxNameLength - len(suffix)
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			s = s[:cut] + suffix
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, "/")
+}
+
+// callerTestDir returns the directory of the closest _test.go file on the
+// call stack, or "." if there is none or its path is not absolute, as with
+// -trimpath. Tests run in their package directory, so "." is usually the
+// same directory.
+func callerTestDir() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if strings.HasSuffix(frame.File, "_test.go") && filepath.IsAbs(frame.File) {
+			return filepath.Dir(frame.File)
+		}
+		if !more {
+			return "."
+		}
+	}
+}
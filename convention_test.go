@@ -0,0 +1,96 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCallerTestDir(t *testing.T) {
+	want, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := callerTestDir(); got != want {
+		t.Errorf("callerTestDir: got %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeTestName(t *testing.T) {
+	long := strings.Repeat("x", 120)
+	var tests = []struct {
+		in, want string
+	}{
+		{in: "TestRender/dark_mode", want: "TestRender/dark_mode"},
+		{in: "TestParse/a:b*c?<d>|e\\f\"g", want: "TestParse/a_b_c__d__e_f_g"},
+		{in: "TestX/.hidden/trailing.", want: "TestX/_hidden/trailing_"},
+		{in: "TestX/con/Lpt1.2/console", want: "TestX/con_/Lpt1_.2/console"},
+		{in: "TestX/#00/", want: "TestX/_00/_"},
+		{in: "TestX/长名字", want: "TestX/长名字"},
+		{in: "TestX/" + long, want: "TestX/" + long[:91] + "-13f05a0b"},
+	}
+	for _, test := range tests {
+		if got := sanitizeTestName(test.in); got != test.want {
+			t.Errorf("sanitizeTestName(%q): got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestConventionCheck(t *testing.T) {
+	env := TestEnv(t)
+	newG := func(name string) (*G, *fakeT) {
+		ft := &fakeT{name: name}
+		g := New(ft)
+		g.dir = env.Root()
+		return g, ft
+	}
+
+	g, ft := newG("TestRender/dark_mode")
+	g.Check("a\n")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "testdata/golden/TestRender/dark_mode.golden does not exist") {
+		t.Errorf("Check without a golden file: got errors %q", ft.errors)
+	}
+
+	env.SetUpdating(true)
+	g, ft = newG("TestRender/dark_mode")
+	g.Check("a\n")
+	g.Check("b\n")
+	env.SetUpdating(false)
+	for name, want := range map[string]string{"TestRender/dark_mode.golden": "a\n", "TestRender/dark_mode.2.golden": "b\n"} {
+		if got, err := ioutil.ReadFile(env.Path("testdata/golden/" + name)); string(got) != want || err != nil {
+			t.Errorf("golden file %v: got %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if len(ft.errors) != 0 {
+		t.Errorf("Check with -update_golden: got errors %q", ft.errors)
+	}
+
+	g, ft = newG("TestRender/dark_mode")
+	g.Check("a\n")
+	g.Check("c\n")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "-b\n+c\n") {
+		t.Errorf("Check with a mismatch on the second call: got errors %q", ft.errors)
+	}
+
+	// Both names map to the golden file TestRender/Dark_mode.golden.
+	g, ft = newG("TestRender/Dark:mode")
+	g.Check("a\n")
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "is also used by TestRender/dark_mode") {
+		t.Errorf("Check of a colliding test: got errors %q", ft.errors)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cupaloy is a drop-in replacement for the most used parts of the
+// API of github.com/bradleyjkemp/cupaloy/v2, backed by package golden.
+// Switching a test suite over only takes changing its imports:
+//
+//     cupaloy.SnapshotT(t, result)
+//
+// Snapshots are kept in .snapshots/<TestName> as with cupaloy, and are
+// updated with -update_golden, or -update when calling golden.Run from
+// TestMain, rather than UPDATE_SNAPSHOTS. Strings and byte slices are
+// snapshotted as is; other values are formatted with %#v rather than with
+// spew, so their snapshots need to be updated once after switching.
+package cupaloy
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/golden"
+)
+
+// Config configures where snapshots are kept.
+type Config struct {
+	subDirName string
+	opts       []golden.Option
+}
+
+// A Configurator changes a Config.
+type Configurator func(*Config)
+
+// SnapshotSubdirectory sets the directory snapshots are kept in. The default
+// is ".snapshots".
+func SnapshotSubdirectory(name string) Configurator {
+	return func(c *Config) {
+		c.subDirName = name
+	}
+}
+
+// WithGoldenOptions passes opts on to package golden. It has no cupaloy
+// counterpart.
+func WithGoldenOptions(opts ...golden.Option) Configurator {
+	return func(c *Config) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// New returns a Config changed by configurators.
+func New(configurators ...Configurator) *Config {
+	c := &Config{subDirName: ".snapshots"}
+	for _, configurator := range configurators {
+		configurator(c)
+	}
+	return c
+}
+
+var global = New()
+
+// SnapshotT compares i to the snapshot of the running test with the default
+// Config.
+func SnapshotT(t golden.T, i ...interface{}) {
+	t.Helper()
+	global.SnapshotT(t, i...)
+}
+
+// SnapshotMulti compares i to the snapshot snapshotID with the default
+// Config.
+func SnapshotMulti(snapshotID string, i ...interface{}) error {
+	return global.SnapshotMulti(snapshotID, i...)
+}
+
+// SnapshotT compares i to the snapshot named after the running test, and
+// reports a mismatch as a test error.
+func (c *Config) SnapshotT(t golden.T, i ...interface{}) {
+	t.Helper()
+	if err := c.SnapshotMulti(strings.Replace(t.Name(), "/", "-", -1), i...); err != nil {
+		t.Error(err)
+	}
+}
+
+// SnapshotMulti compares i to the snapshot snapshotID. It returns an error if
+// they differ. As with cupaloy, a missing snapshot is created, but an error
+// is still returned so that the new snapshot gets reviewed.
+func (c *Config) SnapshotMulti(snapshotID string, i ...interface{}) error {
+	goldenFile := filepath.Join(c.subDirName, snapshotID)
+	if !filepath.IsAbs(goldenFile) {
+		// Make the path relative to the package directory rather than to
+		// the GOPATH.
+		goldenFile = "./" + filepath.ToSlash(goldenFile)
+	}
+	r := golden.Check(takeSnapshot(i...), goldenFile, c.opts...)
+	switch {
+	case golden.Updating():
+		return r.Update()
+	case os.IsNotExist(r.Err()):
+		if err := os.MkdirAll(c.subDirName, 0770); err != nil {
+			return err
+		}
+		if err := r.Update(); err != nil {
+			return err
+		}
+		return fmt.Errorf("snapshot created for test %v", snapshotID)
+	case !r.Equal():
+		return fmt.Errorf("%v", r)
+	}
+	return nil
+}
+
+// takeSnapshot renders values one after the other.
+func takeSnapshot(i ...interface{}) string {
+	buf := &bytes.Buffer{}
+	for _, v := range i {
+		switch v := v.(type) {
+		case string:
+			buf.WriteString(v)
+		case []byte:
+			buf.Write(v)
+		default:
+			fmt.Fprintf(buf, "%#v", v)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cupaloy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	got := takeSnapshot("text", []byte("bytes"), struct{ A int }{1})
+	want := "text\nbytes\nstruct { A int }{A:1}\n"
+	if got != want {
+		t.Errorf("takeSnapshot: got %q want %q", got, want)
+	}
+}
+
+func TestSnapshotMulti(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(SnapshotSubdirectory(filepath.Join(dir, ".snapshots")))
+
+	if err := c.SnapshotMulti("TestX-case", "one"); err == nil || !strings.Contains(err.Error(), "snapshot created") {
+		t.Errorf("SnapshotMulti without a snapshot: got %v, want a snapshot created error", err)
+	}
+	if got, _ := ioutil.ReadFile(filepath.Join(dir, ".snapshots/TestX-case")); string(got) != "one\n" {
+		t.Errorf("created snapshot: got %q, want %q", got, "one\n")
+	}
+	if err := c.SnapshotMulti("TestX-case", "one"); err != nil {
+		t.Errorf("SnapshotMulti with the same data: got %v", err)
+	}
+	if err := c.SnapshotMulti("TestX-case", "two"); err == nil || !strings.Contains(err.Error(), "-one\n+two\n") {
+		t.Errorf("SnapshotMulti with different data: got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// debugEnv is the environment variable enabling WithDebug for all
+// comparisons.
+const debugEnv = "GOLDEN_DEBUG"
+
+// WithDebug makes the comparison log what it does through the Logger set
+// with SetLogger: where the golden file was looked for and found, which
+// GOPATH entry or search root it was found under, the normalizers applied,
+// and how many bytes were read and written. This helps tell why a golden file
+// is not found in unusual directory layouts. Setting the GOLDEN_DEBUG
+// environment variable enables it for all comparisons.
+func WithDebug() Option {
+	return func(o *options) {
+		o.debug = true
+	}
+}
+
+// debugging tells whether debugging is enabled. Callers check it before
+// computing costly debugf arguments.
+func (o *options) debugging() bool {
+	return o.debug || os.Getenv(debugEnv) != ""
+}
+
+// debugf logs a trace line about goldenFile if debugging is enabled.
+func (o *options) debugf(goldenFile string, format string, args ...interface{}) {
+	if o.debugging() {
+		logf("golden: %v: %v", goldenFile, fmt.Sprintf(format, args...))
+	}
+}
+
+// describeResolution tells how goldenFile resolves to fullPath, the empty
+// string if it could not be found, for debugging.
+func describeResolution(goldenFile string, fullPath string, o *options) string {
+	switch {
+	case o.storage != nil:
+		return "key in storage"
+	case isLiteralPath(goldenFile):
+		return "literal path"
+	}
+	b, err := currentBackend()
+	if err != nil {
+		return err.Error()
+	}
+	if _, ok := b.(gopathBackend); !ok {
+		return fmt.Sprintf("resolved by the %T backend", b)
+	}
+	roots, where, err := searchRoots()
+	if err != nil {
+		return err.Error()
+	}
+	for _, root := range roots {
+		if fullPath != "" && strings.HasPrefix(fullPath, root+"/") {
+			return fmt.Sprintf("found under %v entry %v of %v", where, root, roots)
+		}
+	}
+	return fmt.Sprintf("searched %v %v", where, roots)
+}
+
+// normalizerNames names the normalizers that o applies, for debugging.
+func (o *options) normalizerNames() []string {
+	var names []string
+	if len(o.ignoreLines) > 0 {
+		names = append(names, fmt.Sprintf("WithIgnoreLines(%d patterns)", len(o.ignoreLines)))
+	}
+	for _, n := range o.normalizers {
+		name := "unknown"
+		if f := runtime.FuncForPC(reflect.ValueOf(n).Pointer()); f != nil {
+			name = f.Name()
+		}
+		names = append(names, name)
+	}
+	return names
+}
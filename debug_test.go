@@ -0,0 +1,50 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebug(t *testing.T) {
+	env := TestEnv(t)
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	env.SetUpdating(true)
+	Compare("a\n", "a.golden", WithDebug())
+	env.SetUpdating(false)
+	Compare("a\n", "a.golden", WithDebug())
+	Compare(" a\n", "a.golden", WithDebug(), WithNormalizer(strings.TrimSpace))
+	Compare("a\n", "a.golden")
+	func() {
+		defer setenvForTest(map[string]string{debugEnv: "1"})()
+		Compare("a\n", "missing.golden", WithMissingAsEmpty())
+	}()
+
+	root := env.Root()
+	want := []string{
+		"golden: a.golden: created " + root + "/a.golden (found under search roots entry " + root + " of [" + root + "]) with 2 bytes of actual data",
+		"golden: a.golden: read " + root + "/a.golden (found under search roots entry " + root + " of [" + root + "]) and found it equal to the actual data",
+		"golden: a.golden: read 2 bytes from " + root + "/a.golden (found under search roots entry " + root + " of [" + root + "])",
+		"golden: a.golden: normalizing with strings.TrimSpace",
+		"golden: missing.golden: not found: missing.golden: file not found in search roots; searched search roots [" + root + "]",
+	}
+	if strings.Join(l.notices, "\n") != strings.Join(want, "\n") {
+		t.Errorf("debug log:\ngot  %q\nwant %q", l.notices, want)
+	}
+}
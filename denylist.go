@@ -0,0 +1,45 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultDenyList matches common kinds of secrets that should never be
+// committed in golden files: cloud provider keys, bearer tokens, private keys
+// and email addresses. See WithDenyList.
+var DefaultDenyList = []*regexp.Regexp{
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`),
+	regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]{16,}=*`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+}
+
+// checkDenyList returns an error if any of patterns matches data. The error
+// identifies the pattern and line but, to keep secrets out of logs, not the
+// matching text.
+func checkDenyList(data string, patterns []*regexp.Regexp) error {
+	for _, p := range patterns {
+		if loc := p.FindStringIndex(data); loc != nil {
+			line := strings.Count(data[:loc[0]], "\n") + 1
+			return fmt.Errorf("actual data matches deny-list pattern %q on line %d", p, line)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,486 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Line-based diffing and unified diff formatting.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A match records that a[i:i+size] == b[j:j+size].
+type match struct {
+	i, j, size int
+}
+
+// An opCode describes how to turn a[i1:i2] into b[j1:j2]. The tag is one of
+// 'e' (equal), 'r' (replace), 'd' (delete) or 'i' (insert), with the same
+// meaning as in Python's difflib.
+type opCode struct {
+	tag            byte
+	i1, i2, j1, j2 int
+}
+
+// splitLines splits s after each newline. Like difflib, it always terminates
+// the last line with a newline, so that a missing final newline shows up as a
+// changed line instead of corrupting the diff.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// opCodesFromMatches converts an increasing list of matching blocks between
+// sequences of length la and lb into the opcodes that turn one into the
+// other. Adjacent blocks are merged.
+func opCodesFromMatches(matches []match, la, lb int) []opCode {
+	var codes []opCode
+	i, j := 0, 0
+	for _, m := range append(matches, match{la, lb, 0}) {
+		tag := byte(0)
+		switch {
+		case i < m.i && j < m.j:
+			tag = 'r'
+		case i < m.i:
+			tag = 'd'
+		case j < m.j:
+			tag = 'i'
+		}
+		if tag != 0 {
+			codes = append(codes, opCode{tag, i, m.i, j, m.j})
+		}
+		if m.size > 0 {
+			if n := len(codes); n > 0 && codes[n-1].tag == 'e' {
+				codes[n-1].i2 += m.size
+				codes[n-1].j2 += m.size
+			} else {
+				codes = append(codes, opCode{'e', m.i, m.i + m.size, m.j, m.j + m.size})
+			}
+		}
+		i, j = m.i+m.size, m.j+m.size
+	}
+	return codes
+}
+
+// groupOpCodes splits codes into hunks with up to n lines of context,
+// dropping long runs of equal lines.
+func groupOpCodes(codes []opCode, n int) [][]opCode {
+	codes = append([]opCode{}, codes...)
+	if len(codes) == 0 {
+		codes = []opCode{{'e', 0, 1, 0, 1}}
+	}
+	if c := &codes[0]; c.tag == 'e' {
+		c.i1, c.j1 = max(c.i1, c.i2-n), max(c.j1, c.j2-n)
+	}
+	if c := &codes[len(codes)-1]; c.tag == 'e' {
+		c.i2, c.j2 = min(c.i2, c.i1+n), min(c.j2, c.j1+n)
+	}
+	var groups [][]opCode
+	var group []opCode
+	for _, c := range codes {
+		// End the current group and start a new one whenever there is a
+		// large range with no changes.
+		if c.tag == 'e' && c.i2-c.i1 > 2*n {
+			group = append(group, opCode{'e', c.i1, min(c.i2, c.i1+n), c.j1, min(c.j2, c.j1+n)})
+			groups = append(groups, group)
+			group = nil
+			c.i1, c.j1 = max(c.i1, c.i2-n), max(c.j1, c.j2-n)
+		}
+		group = append(group, c)
+	}
+	if len(group) > 0 && !(len(group) == 1 && group[0].tag == 'e') {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// formatRangeUnified formats the [start, stop) line range for a hunk header.
+func formatRangeUnified(start, stop int) string {
+	beginning := start + 1 // Lines are numbered from one.
+	length := stop - start
+	if length == 1 {
+		return fmt.Sprintf("%d", beginning)
+	}
+	if length == 0 {
+		beginning-- // Empty ranges begin at the line just before the range.
+	}
+	return fmt.Sprintf("%d,%d", beginning, length)
+}
+
+// unifiedDiff renders the hunks turning lines a into lines b as a unified
+// diff. It returns the empty string if there are no hunks.
+func unifiedDiff(a, b []string, fromFile, toFile string, groups [][]opCode) string {
+	if len(groups) == 0 {
+		return ""
+	}
+	buf := &bytes.Buffer{}
+	if fromFile != "" || toFile != "" {
+		fmt.Fprintf(buf, "--- %s\n+++ %s\n", fromFile, toFile)
+	}
+	for _, g := range groups {
+		first, last := g[0], g[len(g)-1]
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", formatRangeUnified(first.i1, last.i2), formatRangeUnified(first.j1, last.j2))
+		for _, c := range g {
+			if c.tag == 'e' {
+				for _, line := range a[c.i1:c.i2] {
+					buf.WriteByte(' ')
+					buf.WriteString(line)
+				}
+				continue
+			}
+			// Lines that only differ in whitespace or non-printing
+			// characters would look identical.
+			show := func(line string) string { return line }
+			if c.tag == 'r' && invisibleOnly(strings.Join(a[c.i1:c.i2], ""), strings.Join(b[c.j1:c.j2], "")) {
+				show = showInvisible
+			}
+			if c.tag == 'r' || c.tag == 'd' {
+				for _, line := range a[c.i1:c.i2] {
+					buf.WriteByte('-')
+					buf.WriteString(show(line))
+				}
+			}
+			if c.tag == 'r' || c.tag == 'i' {
+				for _, line := range b[c.j1:c.j2] {
+					buf.WriteByte('+')
+					buf.WriteString(show(line))
+				}
+			}
+		}
+	}
+	return buf.String()
+}
+
+// isInvisible reports whether r cannot be told apart from its absence or
+// from other such characters when printed.
+func isInvisible(r rune) bool {
+	return unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
+
+// invisibleOnly reports whether a and b differ, but only in whitespace or
+// non-printing characters.
+func invisibleOnly(a, b string) bool {
+	strip := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if isInvisible(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	return a != b && strip(a) == strip(b)
+}
+
+// showInvisible renders the whitespace and non-printing characters of line
+// visibly: trailing spaces as "·", tabs as "⇥", carriage returns as "\r" and
+// other invisible characters as Go escapes.
+func showInvisible(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	trailing := len(strings.TrimRight(line, " "))
+	buf := &bytes.Buffer{}
+	for i, r := range line {
+		switch {
+		case r == ' ' && i >= trailing:
+			buf.WriteString("·")
+		case r == ' ':
+			buf.WriteRune(r)
+		case r == '\t':
+			buf.WriteString("⇥")
+		case r == utf8.RuneError && !strings.HasPrefix(line[i:], string(utf8.RuneError)):
+			fmt.Fprintf(buf, "\\x%02x", line[i])
+		case isInvisible(r):
+			buf.WriteString(strings.Trim(strconv.QuoteRuneToASCII(r), "'"))
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String() + "\n"
+}
+
+// patienceMatches returns the matching blocks between a and b found by the
+// patience diff algorithm: lines that occur exactly once on each side are
+// used as anchors, and the regions between anchors are diffed recursively.
+// This keeps reordered blocks together where a longest-common-subsequence
+// diff would interleave them.
+func patienceMatches(a, b []string) []match {
+	var matches []match
+	var recurse func(alo, ahi, blo, bhi int)
+	recurse = func(alo, ahi, blo, bhi int) {
+		// Common prefix and suffix lines always match.
+		for alo < ahi && blo < bhi && a[alo] == b[blo] {
+			matches = append(matches, match{alo, blo, 1})
+			alo, blo = alo+1, blo+1
+		}
+		var suffix []match
+		for alo < ahi && blo < bhi && a[ahi-1] == b[bhi-1] {
+			ahi, bhi = ahi-1, bhi-1
+			suffix = append(suffix, match{ahi, bhi, 1})
+		}
+		defer func() {
+			for k := len(suffix) - 1; k >= 0; k-- {
+				matches = append(matches, suffix[k])
+			}
+		}()
+		if alo == ahi || blo == bhi {
+			return
+		}
+
+		anchors := uniqueCommonLines(a[alo:ahi], b[blo:bhi])
+		if len(anchors) == 0 {
+			matches = append(matches, lcsMatches(a[alo:ahi], b[blo:bhi], alo, blo)...)
+			return
+		}
+		i, j := alo, blo
+		for _, anchor := range anchors {
+			ai, bj := alo+anchor.i, blo+anchor.j
+			recurse(i, ai, j, bj)
+			matches = append(matches, match{ai, bj, 1})
+			i, j = ai+1, bj+1
+		}
+		recurse(i, ahi, j, bhi)
+	}
+	recurse(0, len(a), 0, len(b))
+	return matches
+}
+
+// uniqueCommonLines returns the longest increasing sequence of pairs (i, j)
+// such that a[i] == b[j] and the line occurs exactly once in each of a and b.
+func uniqueCommonLines(a, b []string) []match {
+	type occurrence struct {
+		countA, countB int
+		i, j           int
+	}
+	lines := map[string]*occurrence{}
+	for i, line := range a {
+		o := lines[line]
+		if o == nil {
+			o = &occurrence{}
+			lines[line] = o
+		}
+		o.countA++
+		o.i = i
+	}
+	for j, line := range b {
+		if o := lines[line]; o != nil {
+			o.countB++
+			o.j = j
+		}
+	}
+	var pairs []match
+	for _, o := range lines {
+		if o.countA == 1 && o.countB == 1 {
+			pairs = append(pairs, match{o.i, o.j, 1})
+		}
+	}
+	sort.Slice(pairs, func(x, y int) bool { return pairs[x].i < pairs[y].i })
+
+	// Patience sorting: tops[k] is the index in pairs of the smallest j that
+	// ends an increasing run of length k+1.
+	var tops []int
+	prev := make([]int, len(pairs))
+	for p := range pairs {
+		k := sort.Search(len(tops), func(k int) bool { return pairs[tops[k]].j > pairs[p].j })
+		prev[p] = -1
+		if k > 0 {
+			prev[p] = tops[k-1]
+		}
+		if k == len(tops) {
+			tops = append(tops, p)
+		} else {
+			tops[k] = p
+		}
+	}
+	if len(tops) == 0 {
+		return nil
+	}
+	result := make([]match, len(tops))
+	for k, p := len(tops)-1, tops[len(tops)-1]; k >= 0; k, p = k-1, prev[p] {
+		result[k] = pairs[p]
+	}
+	return result
+}
+
+// maxLCSCells bounds the size of the table used by lcsMatches.
+const maxLCSCells = 1 << 22
+
+// lcsMatches returns matching lines between a and b, offset by (alo, blo),
+// using a longest common subsequence. Regions too large for the quadratic
+// table are treated as entirely replaced.
+func lcsMatches(a, b []string, alo, blo int) []match {
+	if len(a)*len(b) > maxLCSCells {
+		return nil
+	}
+	// lengths[i][j] is the length of the LCS of a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	var matches []match
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches = append(matches, match{alo + i, blo + j, 1})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// sequenceMatches returns the matching blocks between a and b found by the
+// algorithm of Python's difflib.SequenceMatcher (with its automatic junk
+// heuristic): the longest contiguous matching block is found, and the same
+// is applied recursively to the pieces on either side of it. This does not
+// yield minimal diffs, but tends to yield diffs that look right to people.
+func sequenceMatches(a, b []string) []match {
+	// byLine holds the indices of the lines of b, sorted by line and then by
+	// index. Looking lines up in it rather than in a map of the indices of
+	// each distinct line saves an allocation per line of a typical golden
+	// file.
+	byLine := make([]int, len(b))
+	for j := range byLine {
+		byLine[j] = j
+	}
+	sort.SliceStable(byLine, func(x, y int) bool { return b[byLine[x]] < b[byLine[y]] })
+	// Lines that make up more than 1% of a long b are considered junk: they
+	// never start a match, but may extend one.
+	popular := len(b) + 1
+	if n := len(b); n >= 200 {
+		popular = n/100 + 1
+	}
+	// b2j returns the increasing indices of the occurrences of line in b,
+	// or nothing if it is junk.
+	b2j := func(line string) []int {
+		lo := sort.Search(len(byLine), func(k int) bool { return b[byLine[k]] >= line })
+		hi := lo + sort.Search(len(byLine)-lo, func(k int) bool { return b[byLine[lo+k]] > line })
+		if hi-lo > popular {
+			return nil
+		}
+		return byLine[lo:hi]
+	}
+
+	// findLongestMatch returns the longest block in a[alo:ahi] and
+	// b[blo:bhi], preferring blocks that start earliest in a, then in b.
+	findLongestMatch := func(alo, ahi, blo, bhi int) match {
+		besti, bestj, bestsize := alo, blo, 0
+		// j2len[j] is the length of the longest match ending with a[i-1]
+		// and b[j].
+		j2len, newj2len := map[int]int{}, map[int]int{}
+		for i := alo; i < ahi; i++ {
+			for _, j := range b2j(a[i]) {
+				if j < blo {
+					continue
+				}
+				if j >= bhi {
+					break
+				}
+				k := j2len[j-1] + 1
+				newj2len[j] = k
+				if k > bestsize {
+					besti, bestj, bestsize = i-k+1, j-k+1, k
+				}
+			}
+			j2len, newj2len = newj2len, j2len
+			clear(newj2len)
+		}
+		// Extend the match with junk lines on both ends.
+		for besti > alo && bestj > blo && a[besti-1] == b[bestj-1] {
+			besti, bestj, bestsize = besti-1, bestj-1, bestsize+1
+		}
+		for besti+bestsize < ahi && bestj+bestsize < bhi && a[besti+bestsize] == b[bestj+bestsize] {
+			bestsize++
+		}
+		return match{besti, bestj, bestsize}
+	}
+
+	var matches []match
+	var recurse func(alo, ahi, blo, bhi int)
+	recurse = func(alo, ahi, blo, bhi int) {
+		m := findLongestMatch(alo, ahi, blo, bhi)
+		if m.size == 0 {
+			return
+		}
+		if alo < m.i && blo < m.j {
+			recurse(alo, m.i, blo, m.j)
+		}
+		matches = append(matches, m)
+		if m.i+m.size < ahi && m.j+m.size < bhi {
+			recurse(m.i+m.size, ahi, m.j+m.size, bhi)
+		}
+	}
+	recurse(0, len(a), 0, len(b))
+	return matches
+}
+
+// A Position locates a byte within golden or actual data.
+type Position struct {
+	// Offset is the byte offset, starting at 0.
+	Offset int
+	// Line is the line number, starting at 1.
+	Line int
+	// Column is the byte offset within the line, starting at 1.
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d (byte offset %d)", p.Line, p.Column, p.Offset)
+}
+
+// firstDifference returns the position of the first byte at which a and b
+// differ. If one is a prefix of the other, that is the position just past
+// the end of the shorter one.
+func firstDifference(a, b string) Position {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	prefix := a[:n]
+	return Position{
+		Offset: n,
+		Line:   strings.Count(prefix, "\n") + 1,
+		Column: n - strings.LastIndex(prefix, "\n"),
+	}
+}
+
+// countChangedLines returns the number of lines that differ between a and b,
+// counting a replaced block by the larger of its two sides.
+func countChangedLines(a, b string) int {
+	al, bl := splitLines(a), splitLines(b)
+	n := 0
+	for _, c := range opCodesFromMatches(sequenceMatches(al, bl), len(al), len(bl)) {
+		if c.tag != 'e' {
+			n += max(c.i2-c.i1, c.j2-c.j1)
+		}
+	}
+	return n
+}
@@ -0,0 +1,246 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatRangeUnified(t *testing.T) {
+	var tests = []struct {
+		start, stop int
+		out         string
+	}{
+		{start: 0, stop: 0, out: "0,0"},
+		{start: 3, stop: 4, out: "4"},
+		{start: 3, stop: 7, out: "4,4"},
+		{start: 5, stop: 5, out: "5,0"},
+	}
+	for _, test := range tests {
+		if got := formatRangeUnified(test.start, test.stop); got != test.out {
+			t.Errorf("formatRangeUnified(%v, %v): got %q want %q", test.start, test.stop, got, test.out)
+		}
+	}
+}
+
+func TestUniqueCommonLines(t *testing.T) {
+	a := []string{"x", "a", "b", "x", "c", "d"}
+	b := []string{"c", "a", "x", "b", "d"}
+	// "x" is not unique, and only one of "c" and the "a", "b", "d" run can
+	// be kept in increasing order.
+	want := []match{{1, 1, 1}, {2, 3, 1}, {5, 4, 1}}
+	if got := uniqueCommonLines(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueCommonLines(%q, %q): got %v want %v", a, b, got, want)
+	}
+}
+
+func TestPatienceDiff(t *testing.T) {
+	expected := `func a() {
+	return 1
+}
+
+func b() {
+	return 2
+}
+`
+	actual := `func b() {
+	return 2
+}
+
+func a() {
+	return 1
+}
+`
+	want := `--- want
++++ got
+@@ -1,8 +1,8 @@
+-func a() {
+-	return 1
+-}
+-
+ func b() {
+ 	return 2
++}
++
++func a() {
++	return 1
+ }
+ 
+`
+	got := unifiedDiffer{fromFile: "want", toFile: "got", patience: true}.Diff(expected, actual)
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompareWithPatienceDiff(t *testing.T) {
+	got := Compare("It writes many bits\nIt reads many bits\nIt exchanges many bits\n",
+		"github.com/google/golden/testdata/haiku.txt.golden", WithPatienceDiff())
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+testdata/haiku.txt.golden:1:4: first difference (byte offset 3)
+--- testdata/haiku.txt.golden
++++ testdata/haiku.txt.actual
+@@ -1,4 +1,4 @@
++It writes many bits
+ It reads many bits
+ It exchanges many bits
+-It writes many bits
+ 
+`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// generatedSource returns deterministic Go-like source with many repeated
+// lines, long enough to trigger the automatic junk heuristic.
+func generatedSource(funcs int, skip, rename int) string {
+	buf := &bytes.Buffer{}
+	for i := 0; i < funcs; i++ {
+		if i == skip {
+			continue
+		}
+		name := fmt.Sprintf("f%d", i)
+		if i == rename {
+			name = "renamed"
+		}
+		fmt.Fprintf(buf, "func %v() {\n\tif x {\n\t\treturn %d\n\t}\n\treturn 0\n}\n\n", name, i)
+	}
+	return buf.String()
+}
+
+func TestUnifiedDiffCompatibility(t *testing.T) {
+	var tests = []struct {
+		expected, actual string
+		goldenFile       string
+	}{
+		{
+			expected:   generatedSource(40, -1, -1),
+			actual:     generatedSource(40, 7, 23),
+			goldenFile: "github.com/google/golden/testdata/diff/autojunk.diff.golden",
+		},
+		{
+			expected:   "a\nb\nc",
+			actual:     "a\nb\nc\n",
+			goldenFile: "github.com/google/golden/testdata/diff/final_newline.diff.golden",
+		},
+		{
+			expected:   "",
+			actual:     "a\nb\n",
+			goldenFile: "github.com/google/golden/testdata/diff/empty.diff.golden",
+		},
+	}
+	for _, test := range tests {
+		got := unifiedDiffer{fromFile: "want", toFile: "got"}.Diff(test.expected, test.actual)
+		if diff := Compare(got, test.goldenFile); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+// TestSequenceMatches checks sequenceMatches against the matching blocks
+// recorded from Python's difflib.SequenceMatcher(None, a, b), on inputs where
+// shortcuts such as matching common leading and trailing lines up front
+// change the result.
+func TestSequenceMatches(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want []match
+	}{
+		{"\nb\na\n}\n}\n", "}\n", []match{{3, 0, 1}}},
+		{"a\nxa\n{\n}\nb\n}\n", "}\n", []match{{3, 0, 1}}},
+		{"{\na\nd\nd\n", "{\na\n\nd\n", []match{{0, 0, 2}, {2, 3, 1}}},
+		{"a\n}\n{\n}\n", "{\na\n{\nxa\n\n}\n", []match{{0, 1, 1}, {1, 5, 1}}},
+		{"a\na\n{\n{\n", "a\nd\nd\na\na\na\nd\nd\n", []match{{0, 3, 2}}},
+	}
+	for _, test := range tests {
+		a, b := strings.SplitAfter(test.a, "\n"), strings.SplitAfter(test.b, "\n")
+		a, b = a[:len(a)-1], b[:len(b)-1]
+		if got := sequenceMatches(a, b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sequenceMatches(%q, %q): got %v, want %v", a, b, got, test.want)
+		}
+	}
+}
+
+func TestFirstDifference(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want Position
+	}{
+		{a: "abc", b: "abd", want: Position{Offset: 2, Line: 1, Column: 3}},
+		{a: "a\nbc\nd", b: "a\nbc\ne", want: Position{Offset: 5, Line: 3, Column: 1}},
+		{a: "a\nbc", b: "a\nbcd", want: Position{Offset: 4, Line: 2, Column: 3}},
+		{a: "", b: "x", want: Position{Offset: 0, Line: 1, Column: 1}},
+	}
+	for _, test := range tests {
+		if got := firstDifference(test.a, test.b); got != test.want {
+			t.Errorf("firstDifference(%q, %q): got %v want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestCountChangedLines(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		want int
+	}{
+		{a: "a\nb\n", b: "a\nb\n", want: 0},
+		{a: "a\nb\nc\n", b: "a\nx\nc\n", want: 1},
+		{a: "a\nb\nc\n", b: "a\nx\ny\nz\nc\n", want: 3},
+		{a: "a\n", b: "a\nb\nc\n", want: 2},
+	}
+	for _, test := range tests {
+		if got := countChangedLines(test.a, test.b); got != test.want {
+			t.Errorf("countChangedLines(%q, %q): got %v want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestShowInvisible(t *testing.T) {
+	var tests = []struct {
+		expected, actual string
+		want             string
+	}{
+		{
+			"a b\nsame\n", "a b  \nsame\n",
+			"@@ -1,3 +1,3 @@\n-a b\n+a b··\n same\n \n",
+		},
+		{
+			"\tindented\n", "    indented\n",
+			"@@ -1,2 +1,2 @@\n-⇥indented\n+    indented\n \n",
+		},
+		{
+			"windows\n", "windows\r\n",
+			"@@ -1,2 +1,2 @@\n-windows\n+windows\\r\n \n",
+		},
+		{
+			"zero width\n", "zero​ width\n",
+			"@@ -1,2 +1,2 @@\n-zero width\n+zero\\u200b width\n \n",
+		},
+		{
+			"visible \n", "change \n",
+			"@@ -1,2 +1,2 @@\n-visible \n+change \n \n",
+		},
+	}
+	for _, test := range tests {
+		if got := (unifiedDiffer{}).Diff(test.expected, test.actual); got != test.want {
+			t.Errorf("Diff(%q, %q): got %q want %q", test.expected, test.actual, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// A Differ describes the differences between golden data and actual data.
+//
+// Any diffing library can be plugged in with DifferFunc. For example, to use
+// go-cmp:
+//
+//     golden.Compare(got, goldenFile, golden.WithDiffer(golden.DifferFunc(
+//       func(expected, actual string) string { return cmp.Diff(expected, actual) })))
+type Differ interface {
+	// Diff returns a human readable description of how actual differs from
+	// expected. It is only called when the two differ.
+	Diff(expected, actual string) string
+}
+
+// DifferFunc adapts an ordinary function to the Differ interface.
+type DifferFunc func(expected, actual string) string
+
+// Diff returns f(expected, actual).
+func (f DifferFunc) Diff(expected, actual string) string {
+	return f(expected, actual)
+}
+
+// unifiedDiffer is the default Differ. It produces a unified diff with three
+// lines of context.
+type unifiedDiffer struct {
+	fromFile, toFile string
+	// patience selects the patience diff algorithm instead of the default
+	// difflib-compatible one.
+	patience bool
+}
+
+func (d unifiedDiffer) Diff(expected, actual string) string {
+	a, b := splitLines(expected), splitLines(actual)
+	matches := sequenceMatches
+	if d.patience {
+		matches = patienceMatches
+	}
+	codes := opCodesFromMatches(matches(a, b), len(a), len(b))
+	return unifiedDiff(a, b, d.fromFile, d.toFile, groupOpCodes(codes, 3))
+}
+
+// CommandDiffer returns a Differ that runs an external program such as diff.
+// The expected and actual data are written to temporary files whose names are
+// appended to args. A command exiting with status 1 is taken to mean that the
+// inputs differ, as is conventional for diff tools; any other failure is
+// included in the returned description.
+//
+//     golden.WithDiffer(golden.CommandDiffer("diff", "-u"))
+func CommandDiffer(name string, args ...string) Differ {
+	return DifferFunc(func(expected, actual string) string {
+		dir, err := ioutil.TempDir("", "golden_diff")
+		if err != nil {
+			return fmt.Sprintf("Error creating temporary directory for %v: %v\n", name, err)
+		}
+		defer os.RemoveAll(dir)
+		expectedFile := dir + "/expected"
+		actualFile := dir + "/actual"
+		if err := ioutil.WriteFile(expectedFile, []byte(expected), 0600); err != nil {
+			return fmt.Sprintf("Error writing input for %v: %v\n", name, err)
+		}
+		if err := ioutil.WriteFile(actualFile, []byte(actual), 0600); err != nil {
+			return fmt.Sprintf("Error writing input for %v: %v\n", name, err)
+		}
+		cmd := exec.Command(name, append(append([]string{}, args...), expectedFile, actualFile)...)
+		out, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Sprintf("%sError running %v: %v\n", out, name, err)
+		}
+		return string(out)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestCompareWithDiffer(t *testing.T) {
+	differ := DifferFunc(func(expected, actual string) string {
+		return "expected " + expected[:7] + ", actual " + actual[:7] + "\n"
+	})
+	got := Compare("It eats many bits\n", "github.com/google/golden/testdata/haiku.txt.golden", WithDiffer(differ))
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+testdata/haiku.txt.golden:1:4: first difference (byte offset 3)
+expected It read, actual It eats
+`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCommandDiffer(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skipf("diff is not installed: %v", err)
+	}
+	got := CommandDiffer("diff").Diff("a\nb\n", "a\nc\n")
+	want := "2c2\n< b\n---\n> c\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := CommandDiffer("sh", "-c", "exit 2").Diff("a", "b"); got == "" {
+		t.Errorf("CommandDiffer exiting with status 2: got empty description, want the exit status")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// digestGoldenSuffix marks golden files that only hold the SHA-256 digest and
+// size of the expected data, for artifacts too large to check in.
+const digestGoldenSuffix = ".golden.sha256"
+
+var artifactsDir = newStringFlag("golden_artifacts_dir", "", "Directory that actual data is saved to when it does not match a .golden.sha256 file. Defaults to $TEST_UNDECLARED_OUTPUTS_DIR, or a directory under the system temporary directory.")
+
+func isDigestGolden(goldenFile string) bool {
+	return strings.HasSuffix(goldenFile, digestGoldenSuffix)
+}
+
+// formatDigest returns the contents of a digest golden file for data.
+func formatDigest(data string) string {
+	return fmt.Sprintf("%x %d\n", sha256.Sum256([]byte(data)), len(data))
+}
+
+// formatDigestContext is like formatDigest, but gives up when ctx is done.
+func formatDigestContext(ctx context.Context, data string) (string, error) {
+	sum, err := hashContext(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x %d\n", sum, len(data)), nil
+}
+
+// artifactsDirOrDefault returns the directory that mismatching actual data is
+// saved to.
+func artifactsDirOrDefault() string {
+	if dir := artifactsDir.Load(); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "golden-artifacts")
+}
+
+// saveArtifact writes the actual data for goldenFile to the artifacts
+// directory so that it can be inspected, and returns where it went.
+func saveArtifact(goldenFile string, actual string) (string, error) {
+	dir := artifactsDirOrDefault()
+	if err := os.MkdirAll(dir, 0770); err != nil {
+		return "", err
+	}
+	fullPath := filepath.Join(dir, filepath.Base(actualFileName(goldenFile)))
+	return fullPath, ioutil.WriteFile(fullPath, []byte(actual), 0660)
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFormatDigest(t *testing.T) {
+	got := formatDigest("hello\n")
+	want := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03 6\n"
+	if got != want {
+		t.Errorf("formatDigest: got %q want %q", got, want)
+	}
+}
+
+func TestCompareDigestGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/big.bin.golden.sha256")
+	if err := ioutil.WriteFile(goldenPath, []byte(formatDigest("hello\n")), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+	originalArtifactsDir := artifactsDir.Load()
+	defer func() { artifactsDir.Store(originalArtifactsDir) }()
+	artifactsDir.Store(path.Join(dir, "artifacts"))
+
+	if got := Compare("hello\n", "fake/testdata/big.bin.golden.sha256"); got != "" {
+		t.Errorf("Compare with matching data: got %q, want no diff", got)
+	}
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+fake/testdata/big.bin.golden.sha256:1:1: first difference (byte offset 0)
+--- fake/testdata/big.bin.golden.sha256
++++ fake/testdata/big.bin.actual
+@@ -1,2 +1,2 @@
+-5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03 6
++71573b922a87abc3fd1a957f2cfa09d9e16998567dd878a85e12166112751806 8
+ 
+Actual data saved to ` + path.Join(dir, "artifacts/big.bin.actual") + "\n"
+	if got := Compare("goodbye\n", "fake/testdata/big.bin.golden.sha256"); got != want {
+		t.Errorf("Compare with different data: got %q, want %q", got, want)
+	}
+	if got, _ := ioutil.ReadFile(path.Join(dir, "artifacts/big.bin.actual")); string(got) != "goodbye\n" {
+		t.Errorf("saved artifact: got %q, want %q", got, "goodbye\n")
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	Compare("goodbye\n", "fake/testdata/big.bin.golden.sha256")
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != formatDigest("goodbye\n") {
+		t.Errorf("updated digest golden: got %q, want %q", got, formatDigest("goodbye\n"))
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var roots = struct {
+	sync.Mutex
+	byDir map[string]string
+}{byDir: map[string]string{}}
+
+// findRoot returns the root of the repository containing dir, that is the
+// closest ancestor holding a .git entry, or failing that the closest one
+// holding a go.mod file. It returns the empty string if there is neither.
+// The result is cached for the lifetime of the process.
+func findRoot(dir string) string {
+	roots.Lock()
+	defer roots.Unlock()
+	if root, ok := roots.byDir[dir]; ok {
+		return root
+	}
+	var root string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil && root == "" {
+			root = d
+		}
+		if parent := filepath.Dir(d); parent == d {
+			break
+		}
+	}
+	roots.byDir[dir] = root
+	return root
+}
+
+// displayPath returns how messages refer to goldenFile, found at fullPath:
+// relative to the root of its repository or module, which editors and
+// terminals opened there can follow, or goldenFile itself if fullPath is not
+// inside one.
+func displayPath(goldenFile string, fullPath string, o *options) string {
+	if o.storage != nil || fullPath == "" {
+		return goldenFile
+	}
+	abs, err := filepath.Abs(fullPath)
+	if err != nil {
+		return goldenFile
+	}
+	root := findRoot(filepath.Dir(abs))
+	if root == "" {
+		return goldenFile
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return goldenFile
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDisplayPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"repo/.git/HEAD", "repo/mod/go.mod", "module/go.mod", "none/x.golden"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		fullPath string
+		storage  bool
+		want     string
+	}{
+		// The repository root wins over the root of a nested module.
+		{filepath.Join(dir, "repo/mod/testdata/a.golden"), false, "mod/testdata/a.golden"},
+		{filepath.Join(dir, "repo/b.golden"), false, "b.golden"},
+		{filepath.Join(dir, "module/testdata/c.golden"), false, "testdata/c.golden"},
+		{filepath.Join(dir, "none/x.golden"), false, "key.golden"},
+		{"", false, "key.golden"},
+		{filepath.Join(dir, "repo/b.golden"), true, "key.golden"},
+	}
+	for _, tt := range tests {
+		o := newOptions(nil)
+		if tt.storage {
+			o.storage = DirStorage(dir)
+		}
+		if got := displayPath("key.golden", tt.fullPath, o); got != tt.want {
+			t.Errorf("displayPath(%q, %q) = %q, want %q", "key.golden", tt.fullPath, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// utf8BOM is the byte order mark that some editors, notably on Windows, add
+// at the start of UTF-8 files.
+const utf8BOM = "\ufeff"
+
+// stripBOM removes a UTF-8 byte order mark from the start of s.
+func stripBOM(s string) string {
+	return strings.TrimPrefix(s, utf8BOM)
+}
+
+// decodeGolden returns the contents of a golden file as text, without a byte
+// order mark. With WithUTF16, contents starting with a UTF-16 byte order mark
+// are transcoded to UTF-8.
+func decodeGolden(data []byte, o *options) string {
+	if o.utf16 && len(data) >= 2 && len(data)%2 == 0 {
+		var order binary.ByteOrder
+		switch {
+		case data[0] == 0xfe && data[1] == 0xff:
+			order = binary.BigEndian
+		case data[0] == 0xff && data[1] == 0xfe:
+			order = binary.LittleEndian
+		}
+		if order != nil {
+			units := make([]uint16, 0, len(data)/2-1)
+			for i := 2; i < len(data); i += 2 {
+				units = append(units, order.Uint16(data[i:]))
+			}
+			return string(utf16.Decode(units))
+		}
+	}
+	return stripBOM(string(data))
+}
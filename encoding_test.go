@@ -0,0 +1,75 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDecodeGolden(t *testing.T) {
+	var tests = []struct {
+		in    string
+		utf16 bool
+		want  string
+	}{
+		{"plain\n", false, "plain\n"},
+		{"\xef\xbb\xbfwith bom\n", false, "with bom\n"},
+		{"\xef\xbb\xbfwith bom\n", true, "with bom\n"},
+		{"\xff\xfeh\x00\xe9\x00\n\x00", true, "hé\n"},
+		{"\xfe\xff\x00h\x00\xe9\x00\n", true, "hé\n"},
+		{"\xff\xfeh\x00\xe9\x00\n\x00", false, "\xff\xfeh\x00\xe9\x00\n\x00"},
+		{"\xff\xfe\x00", true, "\xff\xfe\x00"},
+	}
+	for _, test := range tests {
+		o := newOptions(nil)
+		o.utf16 = test.utf16
+		if got := decodeGolden([]byte(test.in), o); got != test.want {
+			t.Errorf("decodeGolden(%q, utf16=%v): got %q want %q", test.in, test.utf16, got, test.want)
+		}
+	}
+}
+
+func TestCompareIgnoresBOM(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/bom.golden")
+	if err := ioutil.WriteFile(goldenPath, []byte("\xef\xbb\xbf#!golden-meta test: TestFoo\ncontents\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer setGoPathForTest(dir)()
+
+	if diff := Compare("contents\n", "fake/testdata/bom.golden"); diff != "" {
+		t.Errorf("Compare with a golden file starting with a BOM: %v", diff)
+	}
+	if diff := Compare("\xef\xbb\xbfcontents\n", "fake/testdata/bom.golden"); diff != "" {
+		t.Errorf("Compare with actual data starting with a BOM: %v", diff)
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	Compare("\xef\xbb\xbfcontents\n", "fake/testdata/bom.golden")
+	if written, _ := ioutil.ReadFile(goldenPath); string(written) != "#!golden-meta test: TestFoo\ncontents\n" {
+		t.Errorf("updated golden file: got %q, want it without BOM", written)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// TB is the part of testing.TB used by TestEnv.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(format string, args ...interface{})
+}
+
+// An Env is a sandbox for golden files created by TestEnv.
+type Env struct {
+	root string
+}
+
+// TestEnv sets up a sandbox for the rest of the running test, so that
+// libraries built on golden can test how they use it. Relative golden file
+// paths resolve against a new temporary directory with the gopath backend,
+// and golden files are only read, as if -update_golden were not set, until
+// SetUpdating is called. Updates are written right away even under Run, which
+// buffers them until the end of the tests otherwise. The update summary
+// starts out empty. Everything, including the temporary directory, is
+// restored or removed when the test ends.
+//
+// Since TestEnv changes the package's global state, it must not be used by
+// tests running in parallel with other tests that use golden files.
+func TestEnv(t TB) *Env {
+	t.Helper()
+	root, err := ioutil.TempDir("", "golden_env")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	explicitRoots.Lock()
+	originalRoots := explicitRoots.dirs
+	explicitRoots.dirs = []string{root}
+	explicitRoots.Unlock()
+	backends.Lock()
+	originalBackend := backends.current
+	backends.current = "gopath"
+	backends.Unlock()
+	originalUpdate, originalUpdateDir, originalBackup := updateGolden.Load(), updateGoldenDir.Load(), backupGolden.Load()
+	updateGolden.Store(false)
+	updateGoldenDir.Store("")
+	backupGolden.Store(false)
+	restoreUpdates := resetUpdatesForTest()
+	restoreReads := resetReadsForTest()
+	restoreWrites := resetWritesForTest()
+
+	t.Cleanup(func() {
+		restoreWrites()
+		restoreReads()
+		restoreUpdates()
+		updateGolden.Store(originalUpdate)
+		updateGoldenDir.Store(originalUpdateDir)
+		backupGolden.Store(originalBackup)
+		backends.Lock()
+		backends.current = originalBackend
+		backends.Unlock()
+		explicitRoots.Lock()
+		explicitRoots.dirs = originalRoots
+		explicitRoots.Unlock()
+	})
+	return &Env{root: root}
+}
+
+// Root returns the temporary directory that relative golden file paths
+// resolve against.
+func (e *Env) Root() string {
+	return e.root
+}
+
+// Path returns where the golden file goldenFile, a path relative to Root,
+// lives on disk.
+func (e *Env) Path(goldenFile string) string {
+	return filepath.Join(e.root, filepath.FromSlash(goldenFile))
+}
+
+// SetUpdating makes Compare and the other functions update golden files, as
+// if -update_golden were set, or only read them.
+func (e *Env) SetUpdating(update bool) {
+	updateGolden.Store(update)
+}
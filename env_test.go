@@ -0,0 +1,63 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTestEnv(t *testing.T) {
+	// As under Run.
+	defer resetWritesForTest()()
+	bufferWrites()
+	var root string
+	t.Run("sandbox", func(t *testing.T) {
+		env := TestEnv(t)
+		root = env.Root()
+		if Updating() {
+			t.Errorf("Updating() in a new environment: got true")
+		}
+		if err := os.MkdirAll(filepath.Dir(env.Path("pkg/testdata/a.golden")), 0700); err != nil {
+			t.Fatal(err)
+		}
+		env.SetUpdating(true)
+		Compare("contents\n", "pkg/testdata/a.golden")
+		if data, err := ioutil.ReadFile(env.Path("pkg/testdata/a.golden")); err != nil || string(data) != "contents\n" {
+			t.Errorf("golden file written in the environment: got %q, %v", data, err)
+		}
+		if got, want := UpdateSummary(), "Golden update summary: 1 created, 0 modified, 0 unchanged\n  created:   pkg/testdata/a.golden\n"; got != want {
+			t.Errorf("UpdateSummary(): got %q want %q", got, want)
+		}
+		env.SetUpdating(false)
+		if diff := Compare("other\n", "pkg/testdata/a.golden"); diff == "" {
+			t.Errorf("Compare with different data after SetUpdating(false): got no diff")
+		}
+	})
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("environment root %v not removed: %v", root, err)
+	}
+	if Updating() {
+		t.Errorf("Updating() after the environment was torn down: got true")
+	}
+	if !pendingWrites.enabled || hasPendingWrites() {
+		t.Errorf("buffered updates after the environment was torn down: got %v, want buffering on and none buffered", pendingWrites.byPath)
+	}
+	if diff := Compare("It reads many bits\nIt exchanges many bits\nIt writes many bits\n", "github.com/google/golden/testdata/haiku.txt.golden"); diff != "" {
+		t.Errorf("Compare after the environment was torn down: %v", diff)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"os"
+)
+
+// Errors returned by Result.Err, Result.Update and the other functions
+// returning errors can be tested against these values with errors.Is, to tell
+// the category of the failure apart without matching error messages.
+var (
+	// ErrGoldenNotFound means the golden file does not exist. It is
+	// os.ErrNotExist, so that such errors keep satisfying os.IsNotExist when
+	// they come straight from the file system.
+	ErrGoldenNotFound = os.ErrNotExist
+	// ErrAmbiguousPath means a relative golden file path matches files or
+	// directories under several roots, so it is unclear which one to write.
+	ErrAmbiguousPath = errors.New("ambiguous golden file path")
+	// ErrGOPATHEmpty means golden files are resolved against the GOPATH, but
+	// it is empty and there are no search roots either.
+	ErrGOPATHEmpty = errors.New("GOPATH is empty")
+	// ErrUpdateRefused means a golden file was not updated because the
+	// update was not allowed: the actual data matches the deny list, another
+	// test updated the file with different contents, or the backend cannot
+	// write golden files in this run.
+	ErrUpdateRefused = errors.New("golden file update refused")
+	// ErrTooLarge means the actual data is larger than the limit set with
+	// WithMaxSize, and WithAllowLarge was not passed.
+	ErrTooLarge = errors.New("actual data too large")
+)
+
+// A categorizedError is an error that also matches category, one of the
+// exported Err values, in errors.Is.
+type categorizedError struct {
+	err      error
+	category error
+}
+
+// categorize returns err with the given category.
+func categorize(err error, category error) error {
+	return &categorizedError{err, category}
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.err
+}
+
+func (e *categorizedError) Is(target error) bool {
+	return target == e.category
+}
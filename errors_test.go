@@ -0,0 +1,101 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestErrorCategories(t *testing.T) {
+	env := TestEnv(t)
+	a, b := env.Path("a"), env.Path("b")
+	for _, dir := range []string{filepath.Join(a, "pkg"), filepath.Join(b, "pkg")} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var tests = []struct {
+		desc string
+		// err is called with the environment's search roots in place.
+		err  func() error
+		want error
+	}{
+		{
+			desc: "missing golden file",
+			err:  func() error { return Check("", "missing.golden").Err() },
+			want: ErrGoldenNotFound,
+		},
+		{
+			desc: "missing stored golden file",
+			err:  func() error { return Check("", "missing.golden", WithStorage(DirStorage(env.Path("store")))).Err() },
+			want: ErrGoldenNotFound,
+		},
+		{
+			desc: "ambiguous path",
+			err: func() error {
+				SetSearchRoots(a, b)
+				defer SetSearchRoots(env.Root())
+				return Check("x", "pkg/new.golden").Update()
+			},
+			want: ErrAmbiguousPath,
+		},
+		{
+			desc: "empty GOPATH",
+			err: func() error {
+				SetSearchRoots()
+				defer SetSearchRoots(env.Root())
+				defer setenvForTest(map[string]string{"GOLDEN_ROOTS": ""})()
+				defer setGoPathForTest("")()
+				return Check("x", "pkg/new.golden").Err()
+			},
+			want: ErrGOPATHEmpty,
+		},
+		{
+			desc: "deny list",
+			err: func() error {
+				return Check("secret", "denied.golden", WithDenyList(regexp.MustCompile("secret"))).Update()
+			},
+			want: ErrUpdateRefused,
+		},
+		{
+			desc: "conflicting update",
+			err: func() error {
+				writers.Lock()
+				writers.byTarget[env.Path("conflict.golden")] = updateWriter{test: "TestOther", actual: "other"}
+				writers.Unlock()
+				return Check("x", "conflict.golden").Update()
+			},
+			want: ErrUpdateRefused,
+		},
+		{
+			desc: "too large",
+			err:  func() error { return Check("large", "large.golden", WithMaxSize(1)).Err() },
+			want: ErrTooLarge,
+		},
+	}
+	categories := []error{ErrGoldenNotFound, ErrAmbiguousPath, ErrGOPATHEmpty, ErrUpdateRefused, ErrTooLarge}
+	for _, test := range tests {
+		err := test.err()
+		for _, category := range categories {
+			if got, want := errors.Is(err, category), category == test.want; got != want {
+				t.Errorf("%v: errors.Is(%v, %v): got %v, want %v", test.desc, err, category, got, want)
+			}
+		}
+	}
+}
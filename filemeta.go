@@ -0,0 +1,127 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileMetadata selects the metadata of files compared by CompareDir with
+// WithFileMetadata.
+type FileMetadata int
+
+const (
+	// ExecutableBit compares whether files are executable by their owner,
+	// as scripts emitted by code generators need to be.
+	ExecutableBit FileMetadata = 1 << iota
+	// FileMode compares the permission bits of files.
+	FileMode
+	// SymlinkTarget compares the targets of symbolic links, which are
+	// otherwise skipped.
+	SymlinkTarget
+)
+
+// dirMetadataFile is the golden file, relative to the golden directory of
+// CompareDir, that holds the metadata of the actual files. No actual file can
+// be compared to it.
+const dirMetadataFile = ".metadata.golden"
+
+// WithFileMetadata makes CompareDir compare the given metadata of files
+// besides their contents. The metadata of the files under the actual
+// directory is listed in the golden file .metadata.golden at the root of the
+// golden directory, one file per line:
+//
+//     bin/generate.sh executable
+//     config.yaml mode 0644
+//     latest -> v2
+//
+// Files without any of the selected metadata, such as files that are not
+// executable when only ExecutableBit is selected, are left out.
+func WithFileMetadata(m FileMetadata) Option {
+	return func(o *options) {
+		o.fileMetadata = m
+	}
+}
+
+// compareDirMetadata compares the metadata of the files under actualDir to
+// the metadata golden file under goldenDir, or updates it, for CompareDir.
+func compareDirMetadata(actualDir string, goldenDir string, o *options) string {
+	goldenFile := goldenDir + "/" + dirMetadataFile
+	actual, err := renderFileMetadata(actualDir, o)
+	if err != nil {
+		return Fatalf("Error while reading actual data: %v", err)
+	}
+	if shouldUpdateGolden() {
+		if err := writeGolden(goldenFile, actual, o); err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	r := check(actual, goldenFile, o)
+	if errors.Is(r.err, ErrGoldenNotFound) {
+		return fmt.Sprintf("Golden file %v does not exist; run %q to create it\n", goldenFile, o.updateCommandOrDefault())
+	}
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	return r.String()
+}
+
+// renderFileMetadata lists the metadata of the files under dir selected by
+// o, as described for WithFileMetadata.
+func renderFileMetadata(dir string, o *options) (string, error) {
+	var lines []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() && rel != "." && o.excludesFile(rel) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !o.includesFile(rel) || o.excludesFile(rel) {
+			return nil
+		}
+		mode := info.Mode()
+		switch {
+		case mode&os.ModeSymlink != 0 && o.fileMetadata&SymlinkTarget != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, fmt.Sprintf("%v -> %v", rel, filepath.ToSlash(target)))
+		case !mode.IsRegular():
+		case o.fileMetadata&FileMode != 0:
+			lines = append(lines, fmt.Sprintf("%v mode %04o", rel, mode.Perm()))
+		case o.fileMetadata&ExecutableBit != 0 && mode&0100 != 0:
+			lines = append(lines, rel+" executable")
+		}
+		return nil
+	})
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return "", err
+	}
+	return strings.Join(lines, "\n") + "\n", err
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompareDirWithFileMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and symbolic links are not portable to Windows")
+	}
+	env := TestEnv(t)
+	actualDir := env.Path("actual")
+	if err := os.MkdirAll(actualDir+"/bin", 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range map[string]os.FileMode{"bin/run.sh": 0755, "config.yaml": 0644, "skip.tmp": 0755} {
+		if err := ioutil.WriteFile(actualDir+"/"+name, []byte(name+"\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(actualDir+"/"+name, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("config.yaml", actualDir+"/latest"); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		metadata FileMetadata
+		want     string
+	}{
+		{ExecutableBit, "bin/run.sh executable\n"},
+		{FileMode, "bin/run.sh mode 0755\nconfig.yaml mode 0644\n"},
+		{ExecutableBit | SymlinkTarget, "bin/run.sh executable\nlatest -> config.yaml\n"},
+	}
+	for _, test := range tests {
+		got, err := renderFileMetadata(actualDir, newOptions([]Option{WithFileMetadata(test.metadata), WithExcludeFiles("*.tmp")}))
+		if got != test.want || err != nil {
+			t.Errorf("renderFileMetadata(%v): got %q, %v, want %q", test.metadata, got, err, test.want)
+		}
+	}
+
+	opts := []Option{WithFileMetadata(ExecutableBit), WithExcludeFiles("*.tmp")}
+	env.SetUpdating(true)
+	if got := CompareDir(actualDir, "golden", opts...); len(got) != 0 {
+		t.Errorf("CompareDir with -update_golden: got %q", got)
+	}
+	env.SetUpdating(false)
+	if got := CompareDir(actualDir, "golden", opts...); len(got) != 0 {
+		t.Errorf("CompareDir after updating: got %q, want no diffs", got)
+	}
+	if err := os.Chmod(actualDir+"/bin/run.sh", 0644); err != nil {
+		t.Fatal(err)
+	}
+	got := CompareDir(actualDir, "golden", opts...)
+	if diff := got["golden/.metadata.golden"]; len(got) != 1 || !strings.Contains(diff, "-bin/run.sh executable\n") {
+		t.Errorf("CompareDir after losing the executable bit: got %q", got)
+	}
+
+	if err := ioutil.WriteFile(actualDir+"/.metadata", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+	got = CompareDir(actualDir, "golden", opts...)
+	if msg := got["golden"]; len(got) != 1 || len(l.errors) != 1 || !strings.Contains(msg, "reserved for the golden file .metadata.golden") {
+		t.Errorf("CompareDir with an actual .metadata file: got %q", got)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// fingerprintEnv names the environment variable that enables environment
+// fingerprints for all comparisons, as WithEnvironmentFingerprint does for
+// one. It is typically set in CI.
+const fingerprintEnv = "GOLDEN_FINGERPRINT"
+
+// wantFingerprint reports whether mismatches should be followed by an
+// environment fingerprint.
+func (o *options) wantFingerprint() bool {
+	return o.fingerprint || os.Getenv(fingerprintEnv) != ""
+}
+
+// environmentFingerprint describes the parts of the environment that most
+// often make output differ between machines, such as
+//
+//     Environment: linux/amd64, go1.22.1, TZ=CET (+01:00), locale=en_US.UTF-8
+func environmentFingerprint() string {
+	name, offset := time.Now().Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("Environment: %v/%v, %v, TZ=%v (%c%02d:%02d), locale=%v\n",
+		runtime.GOOS, runtime.GOARCH, runtime.Version(), name, sign, offset/3600, offset%3600/60, locale())
+}
+
+// locale returns the locale that programs use for character handling and
+// messages, following the precedence of POSIX locale variables.
+func locale() string {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "C"
+}
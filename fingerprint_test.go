@@ -0,0 +1,53 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		opts []Option
+		want string
+	}{
+		{"default", map[string]string{fingerprintEnv: ""}, nil, ""},
+		{"option", map[string]string{fingerprintEnv: "", "LC_ALL": "", "LC_CTYPE": "", "LANG": "de_DE.UTF-8"}, []Option{WithEnvironmentFingerprint()}, "locale=de_DE.UTF-8"},
+		{"environment", map[string]string{fingerprintEnv: "1", "LC_ALL": "fr_FR.UTF-8", "LANG": "de_DE.UTF-8"}, nil, "locale=fr_FR.UTF-8"},
+		{"no locale", map[string]string{fingerprintEnv: "1", "LC_ALL": "", "LC_CTYPE": "", "LANG": ""}, nil, "locale=C"},
+	}
+	for _, tt := range tests {
+		restore := setenvForTest(tt.env)
+		msg := Check("It reads many bits\nIt exchanges twenty bits\nIt writes many bits\n", "github.com/google/golden/testdata/haiku.txt.golden", tt.opts...).String()
+		restore()
+		lines := strings.Split(strings.TrimSuffix(msg, "\n"), "\n")
+		last := lines[len(lines)-1]
+		if got := strings.HasPrefix(last, "Environment: "); got != (tt.want != "") {
+			t.Errorf("%v: fingerprint present = %v in %q", tt.name, got, msg)
+			continue
+		}
+		if tt.want == "" {
+			continue
+		}
+		prefix := "Environment: " + runtime.GOOS + "/" + runtime.GOARCH + ", " + runtime.Version() + ", TZ="
+		if !strings.HasPrefix(last, prefix) || !strings.HasSuffix(last, ", "+tt.want) {
+			t.Errorf("%v: got fingerprint %q, want prefix %q and suffix %q", tt.name, last, prefix, tt.want)
+		}
+	}
+}
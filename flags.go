@@ -0,0 +1,108 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"flag"
+	"strconv"
+	"sync/atomic"
+)
+
+// boolFlag is a boolean flag whose value can be read and changed
+// concurrently, such as by parallel tests and the helpers that temporarily
+// enable updates, without a data race.
+type boolFlag struct {
+	value int32
+}
+
+// newBoolFlag defines a boolFlag on flag.CommandLine, as flag.Bool does.
+func newBoolFlag(name string, value bool, usage string) *boolFlag {
+	f := &boolFlag{}
+	f.Store(value)
+	flag.CommandLine.Var(f, name, usage)
+	return f
+}
+
+// Load returns the value of the flag.
+func (f *boolFlag) Load() bool {
+	return atomic.LoadInt32(&f.value) != 0
+}
+
+// Store sets the value of the flag.
+func (f *boolFlag) Store(value bool) {
+	var v int32
+	if value {
+		v = 1
+	}
+	atomic.StoreInt32(&f.value, v)
+}
+
+func (f *boolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	f.Store(v)
+	return nil
+}
+
+func (f *boolFlag) String() string {
+	if f == nil {
+		return "false"
+	}
+	return strconv.FormatBool(f.Load())
+}
+
+// IsBoolFlag lets the flag be given without a value, as in -update_golden.
+func (f *boolFlag) IsBoolFlag() bool {
+	return true
+}
+
+// stringFlag is the string counterpart of boolFlag.
+type stringFlag struct {
+	value atomic.Value
+}
+
+// newStringFlag defines a stringFlag on flag.CommandLine, as flag.String
+// does.
+func newStringFlag(name string, value string, usage string) *stringFlag {
+	f := &stringFlag{}
+	f.Store(value)
+	flag.CommandLine.Var(f, name, usage)
+	return f
+}
+
+// Load returns the value of the flag.
+func (f *stringFlag) Load() string {
+	v, _ := f.value.Load().(string)
+	return v
+}
+
+// Store sets the value of the flag.
+func (f *stringFlag) Store(value string) {
+	f.value.Store(value)
+}
+
+func (f *stringFlag) Set(s string) error {
+	f.Store(s)
+	return nil
+}
+
+func (f *stringFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.Load()
+}
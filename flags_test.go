@@ -0,0 +1,64 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"flag"
+	"sync"
+	"testing"
+)
+
+func TestFlagValues(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	b := &boolFlag{}
+	s := &stringFlag{}
+	fs.Var(b, "b", "")
+	fs.Var(s, "s", "")
+	if b.String() != "false" || s.String() != "" {
+		t.Errorf("zero values: got %q, %q", b.String(), s.String())
+	}
+	if err := fs.Parse([]string{"-b", "-s=dir"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !b.Load() || s.Load() != "dir" {
+		t.Errorf("after parsing: got %v, %q, want true, %q", b.Load(), s.Load(), "dir")
+	}
+	if err := b.Set("maybe"); err == nil {
+		t.Errorf("Set(%q): got nil error", "maybe")
+	}
+}
+
+// TestConcurrentUpdating is meant to be run with -race: updates are enabled
+// and disabled while other goroutines compare golden files.
+func TestConcurrentUpdating(t *testing.T) {
+	env := TestEnv(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			env.SetUpdating(true)
+			restore := enableUpdateGoldenForTest(env.Root())
+			restore()
+		}()
+		go func() {
+			defer wg.Done()
+			Check("data\n", "concurrent.golden")
+			Updating()
+			artifactsDirOrDefault()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// CompareFunc is like Compare, but takes a function generating the actual
+// data instead of the data itself. The path of the golden file is resolved
+// first, and if that fails, for example because the file does not exist and
+// WithMissingAsEmpty is not set, the error is reported without calling gen.
+// Otherwise gen is always called, since only its data tells whether it
+// matches. If gen fails, its error is returned as the failure message.
+func CompareFunc(gen func() (string, error), goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	var err error
+	switch {
+	case o.storage != nil:
+	case shouldUpdateGolden():
+		_, err = getFullPathForWrite(o.prefixed(goldenFile))
+	default:
+		_, err = getFullPathForRead(o.prefixed(goldenFile))
+	}
+	if err != nil && !o.isMissingGolden(err) {
+		return Fatalf("Error while locating golden file: %v", err)
+	}
+	actual, err := gen()
+	if err != nil {
+		return fmt.Sprintf("Error generating actual data for %v: %v\n", goldenFile, err)
+	}
+	return Compare(actual, goldenFile, opts...)
+}
+
+// CompareReader is like Compare, but reads the actual data from r. This saves
+// tests producing output through an io.Writer, such as template.Execute or
+// an encoder, from buffering it into a string first:
+//
+//     pr, pw := io.Pipe()
+//     go func() { pw.CloseWithError(tmpl.Execute(pw, data)) }()
+//     if diff := golden.CompareReader(pr, goldenFile); diff != "" {
+//       t.Error(diff)
+//     }
+//
+// If reading from r fails, the error is returned as the failure message.
+func CompareReader(r io.Reader, goldenFile string, opts ...Option) string {
+	return CompareFunc(func() (string, error) {
+		data, err := ioutil.ReadAll(r)
+		return string(data), err
+	}, goldenFile, opts...)
+}
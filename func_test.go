@@ -0,0 +1,62 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCompareFunc(t *testing.T) {
+	gen := func() (string, error) {
+		return "It reads many bits\nIt exchanges many bits\nIt writes many bits\n", nil
+	}
+	if got := CompareFunc(gen, "github.com/google/golden/testdata/haiku.txt.golden"); got != "" {
+		t.Errorf("CompareFunc of matching data: got %q, want no diff", got)
+	}
+
+	failing := func() (string, error) {
+		return "", errors.New("out of bits")
+	}
+	want := "Error generating actual data for github.com/google/golden/testdata/haiku.txt.golden: out of bits\n"
+	if got := CompareFunc(failing, "github.com/google/golden/testdata/haiku.txt.golden"); got != want {
+		t.Errorf("CompareFunc of failing generator: got %q want %q", got, want)
+	}
+}
+
+func TestCompareReader(t *testing.T) {
+	haiku := "It reads many bits\nIt exchanges many bits\nIt writes many bits\n"
+	if got := CompareReader(strings.NewReader(haiku), "github.com/google/golden/testdata/haiku.txt.golden"); got != "" {
+		t.Errorf("CompareReader of matching data: got %q, want no diff", got)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, haiku)
+		pw.Close()
+	}()
+	if got := CompareReader(pr, "github.com/google/golden/testdata/haiku.txt.golden"); got != "" {
+		t.Errorf("CompareReader of a pipe: got %q, want no diff", got)
+	}
+
+	r := iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(haiku)))
+	want := "Error generating actual data for github.com/google/golden/testdata/haiku.txt.golden: timeout\n"
+	if got := CompareReader(r, "github.com/google/golden/testdata/haiku.txt.golden"); got != want {
+		t.Errorf("CompareReader of failing reader: got %q want %q", got, want)
+	}
+}
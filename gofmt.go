@@ -0,0 +1,39 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "go/format"
+
+// GoFormat is a Normalizer formatting Go source code the way gofmt does. Data
+// that does not parse as Go source is returned unchanged, so that the
+// comparison shows it as is.
+func GoFormat(src string) string {
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return src
+	}
+	return string(formatted)
+}
+
+// WithGoFormat makes the comparison ignore gofmt-equivalent formatting
+// differences, for golden files holding generated Go source. Unlike other
+// normalizers, it is also applied when updating, so golden files are kept
+// gofmt-clean.
+func WithGoFormat() Option {
+	return func(o *options) {
+		o.normalizers = append(o.normalizers, GoFormat)
+		o.writeFormatters = append(o.writeFormatters, GoFormat)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestGoFormat(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{in: "package p\nfunc  f( ) {return}", out: "package p\n\nfunc f() { return }\n"},
+		{in: "package p\n\nfunc f() { return }\n", out: "package p\n\nfunc f() { return }\n"},
+		{in: "not Go {", out: "not Go {"},
+	}
+	for _, test := range tests {
+		if got := GoFormat(test.in); got != test.out {
+			t.Errorf("GoFormat(%q): got %q want %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestCompareWithGoFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/gen.go.golden")
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	Compare("package p\nvar  x=1", "fake/testdata/gen.go.golden", WithGoFormat())
+	restoreFunc()
+	want := "package p\n\nvar x = 1\n"
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != want {
+		t.Errorf("updated golden file: got %q, want %q", got, want)
+	}
+
+	defer setGoPathForTest(dir)()
+	if got := Compare("package p\n\nvar x =    1\n", "fake/testdata/gen.go.golden", WithGoFormat()); got != "" {
+		t.Errorf("Compare with gofmt-equivalent source: got %q, want no diff", got)
+	}
+	if got := Compare("package p\n\nvar x = 2\n", "fake/testdata/gen.go.golden", WithGoFormat()); got == "" {
+		t.Errorf("Compare with different source: got no diff")
+	}
+}
@@ -35,6 +35,7 @@
 // file, they will see the following error message:
 //
 //     Actual data differs from golden data; run "go test -update_golden" to update
+//     .../testdata/data.txt.golden:2:18: first difference (byte offset 26)
 //     --- .../testdata/data.txt.golden
 //     +++ .../testdata/data.txt.actual
 //       blah: ""
@@ -54,60 +55,289 @@
 // with the actual data. Code reviewers will notice in diffs that the golden
 // data has been modified, and can easily compare the output of the code before
 // and after the change.
+//
+// To keep a local copy of the previous golden data, also pass the
+// -backup_golden flag. Each golden file that changes is first copied to
+// <file>.bak, so a mistaken bulk update can be reverted without git.
+//
+// When tests are too expensive to re-run locally, pass -golden_patch_dir in
+// CI instead: each mismatch then leaves a patch there that updates its golden
+// file, and downloading the directory and running "golden apply" on it from
+// the root of the repository accepts the new data.
+//
+// Large regenerations are safer to review before they touch the repository.
+// Passing -update_golden_dir=<dir> instead of -update_golden writes the
+// updated golden files to a tree under dir that mirrors the repository, and
+// running "golden promote <dir>" from the root of the repository copies them
+// into place once reviewed.
+//
+// A bulk update is easier to sanity-check with a list of the files it
+// touched. Calling Run from TestMain prints one once all tests have finished:
+//
+//     func TestMain(m *testing.M) {
+//       os.Exit(golden.Run(m))
+//     }
 package golden
 
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
+	"os"
 	"strings"
-
-	"github.com/pmezard/go-difflib/difflib"
+	"time"
 )
 
 // Compare compares the actual parameter to the contents of goldenFile and
 // returns an empty string if they match. If they don't match, it returns a
-// unified diff string documenting the differences.
+// unified diff string documenting the differences. The diff refers to
+// goldenFile by its path relative to the root of the repository, or failing
+// that of the Go module, containing it, so that it can be opened from there.
 //
 // If the -update_golden flag is set, this function will overwrite the
 // contents of goldenFile with the actual value. This is useful for updating
 // the golden data automatically.
 //
-// goldenFile is a path relative to os.Getenv("GOROOT").
-func Compare(actual string, goldenFile string) string {
+// goldenFile is a path relative to the src directory of a GOPATH entry, or
+// to one of the directories passed to SetSearchRoots. Absolute paths, and
+// paths starting with "./" or "../", are used as is; when running tests, the
+// latter are relative to the package directory. If goldenFile ends in
+// ".golden.re", each of its lines is an anchored regular expression that the
+// corresponding line of actual must match. This is useful when whole lines,
+// such as durations or counts, are inherently variable but their shape still
+// needs to be checked. Updating such a file keeps the expressions that still
+// match and replaces the others with the quoted actual line. If goldenFile
+// ends in ".golden.sha256", it only holds the SHA-256 digest and size of the
+// expected data, which keeps very large outputs out of the repository; on a
+// mismatch the actual data is saved to the directory given by the
+// -golden_artifacts_dir flag for inspection. If goldenFile ends in
+// ".golden.ptr", it only holds a pointer to the expected data, which is kept
+// in the Storage set with WithBlobStorage. If goldenFile ends in
+// ".golden.gz", it holds the expected data compressed with gzip; updates
+// compress it the same way every time, so that unchanged data does not
+// rewrite the file.
+//
+// With WithStorage, goldenFile is instead a key in a Storage, such as one
+// shared between repositories or kept on a remote server.
+//
+// Data is taken to be binary if its first 8000 bytes contain a NUL byte or
+// more than a tenth of them are invalid UTF-8: mismatches then report the
+// sizes and SHA-256 digests of the golden and actual data and the offset of
+// their first difference instead of a diff.
+//
+// The comparison can be customized by passing Options such as WithDiffer.
+// Use Check instead for more control over reporting and updating.
+func Compare(actual string, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFile = o.prefixed(goldenFile)
 	if shouldUpdateGolden() {
-		fullPath, err := getFullPathForWrite(goldenFile)
-		if err != nil {
-			log.Fatalf("Error while getting path for writes: %v", err)
-		}
-		if err := ioutil.WriteFile(fullPath, []byte(actual), 0660); err != nil {
-			log.Fatal(err)
+		if err := writeGolden(goldenFile, actual, o); err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
 		}
 		return ""
 	}
+	r := check(actual, goldenFile, o)
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	return r.String()
+}
 
-	fullPath, err := getFullPathForRead(goldenFile)
-	if err != nil {
-		log.Fatalf("Error while getting path for reads: %v", err)
+// actualFileName returns the name under which actual data is shown next to
+// goldenFile.
+func actualFileName(goldenFile string) string {
+	if strings.Contains(goldenFile, ".approved.") {
+		return receivedFileName(goldenFile)
 	}
+	for _, suffix := range []string{regexpGoldenSuffix, digestGoldenSuffix, pointerGoldenSuffix, gzipGoldenSuffix} {
+		if strings.HasSuffix(goldenFile, suffix) {
+			goldenFile = strings.TrimSuffix(goldenFile, suffix) + ".golden"
+		}
+	}
+	return strings.TrimSuffix(goldenFile, ".golden") + ".actual"
+}
+
+// readGolden resolves goldenFile and returns its full path, its metadata
+// header and the rest of its contents. Golden files kept in a Storage set
+// with WithStorage are their own full path.
+func readGolden(goldenFile string, o *options) (fullPath string, header string, body string, err error) {
+	fullPath, header, body, _, err = readGoldenOrMissing(goldenFile, o)
+	return fullPath, header, body, err
+}
 
-	expected, err := ioutil.ReadFile(fullPath)
+// readGoldenOrMissing is readGolden, also reporting whether goldenFile does
+// not exist and was read as empty because of WithMissingAsEmpty.
+func readGoldenOrMissing(goldenFile string, o *options) (fullPath string, header string, body string, missing bool, err error) {
+	var expected []byte
+	if o.storage != nil {
+		fullPath = goldenFile
+		expected, err = storageRead(o.context(), o.storage, goldenFile)
+	} else {
+		fullPath, err = getFullPathForRead(goldenFile)
+		if err != nil {
+			if o.debugging() {
+				o.debugf(goldenFile, "not found: %v; %v", err, describeResolution(goldenFile, "", o))
+			}
+			if o.isMissingGolden(err) {
+				return "", "", "", true, nil
+			}
+			return "", "", "", false, fmt.Errorf("getting path for reads: %w", err)
+		}
+		var release func()
+		if pending, ok := pendingContents(fullPath); ok {
+			expected, release = []byte(pending), func() {}
+		} else {
+			expected, release, err = readMappedContext(o.context(), fullPath)
+		}
+		if err == nil {
+			// decodeGolden copies the data before it is released.
+			defer release()
+			recordRead(fullPath)
+		}
+	}
+	if err == nil && isGzipGolden(goldenFile) {
+		if expected, err = gunzipGolden(expected); err != nil {
+			err = fmt.Errorf("decompressing %v: %v", fullPath, err)
+		}
+	}
 	if err != nil {
-		log.Fatalf("Error while reading golden file: %v", err)
+		o.debugf(goldenFile, "cannot read %v: %v", fullPath, err)
 	}
-	if string(expected) == actual {
-		return ""
+	if o.isMissingGolden(err) {
+		return fullPath, "", "", true, nil
+	}
+	if err != nil {
+		return fullPath, "", "", false, err
 	}
-	udiff := difflib.UnifiedDiff{
-		A:        difflib.SplitLines(string(expected)),
-		FromFile: goldenFile,
-		B:        difflib.SplitLines(actual),
-		ToFile:   strings.TrimSuffix(goldenFile, ".golden") + ".actual",
-		Context:  3,
+	if o.debugging() {
+		o.debugf(goldenFile, "read %d bytes from %v (%v)", len(expected), fullPath, describeResolution(goldenFile, fullPath, o))
+	}
+	header, body = splitMetadata(decodeGolden(expected, o))
+	return fullPath, header, body, false, nil
+}
+
+// writeGolden overwrites goldenFile with actual and records what it did in
+// the update summary. It fails if another test already updated goldenFile
+// with different contents during this run.
+func writeGolden(goldenFile string, actual string, o *options) error {
+	if err := o.context().Err(); err != nil {
+		return err
+	}
+	if err := o.checkSize(goldenFile, actual); err != nil {
+		return err
+	}
+	if err := checkDenyList(actual, o.denyList); err != nil {
+		return categorize(fmt.Errorf("refusing to update %v: %v", goldenFile, err), ErrUpdateRefused)
+	}
+	contents := func(previous string) string {
+		return goldenContents(goldenFile, previous, actual, o)
+	}
+	if isGzipGolden(goldenFile) {
+		contents = gzipContents(contents)
+	}
+	var fullPath string
+	if o.storage == nil {
+		var err error
+		if fullPath, err = getFullPathForWrite(goldenFile); err != nil {
+			return fmt.Errorf("getting path for writes: %w", err)
+		}
+	}
+	target := fullPath
+	if o.storage != nil {
+		target = goldenFile
+	}
+	if err := claimUpdate(target, actual); err != nil {
+		return err
+	}
+	if isPointerGolden(goldenFile) {
+		if err := writePointerBlob(actual, o); err != nil {
+			return fmt.Errorf("storing data for %v: %v", goldenFile, err)
+		}
+	}
+	var status updateStatus
+	var err error
+	switch {
+	case o.storage != nil:
+		status, err = writeStoredGolden(o.context(), o.storage, goldenFile, contents)
+	case updateGoldenDir.Load() != "":
+		status, err = writeShadowGolden(fullPath, shadowPath(goldenFile, fullPath, o), contents)
+	default:
+		status, err = writeGoldenFile(fullPath, contents)
 	}
-	diffstr, err := difflib.GetUnifiedDiffString(udiff)
 	if err != nil {
-		log.Fatalf("Error computing unified diff with golden file: %v", err)
+		return err
+	}
+	recordUpdate(goldenFile, status)
+	if o.debugging() {
+		o.debugf(goldenFile, "%v %v (%v) with %d bytes of actual data", status, target, describeResolution(goldenFile, fullPath, o), len(actual))
+	}
+	return nil
+}
+
+// goldenContents returns what goldenFile should contain once updated with
+// actual, given its previous contents. An existing metadata header is kept
+// as long as the rest of the file does not change, except for a quarantine,
+// which updating lifts.
+func goldenContents(goldenFile string, previous string, actual string, o *options) string {
+	// Byte order marks are never written, even if the golden file had one.
+	previous = stripBOM(previous)
+	header, previousBody := splitMetadata(previous)
+	if kept := withoutQuarantine(header); kept != header {
+		header, previous = kept, kept+previousBody
+	}
+	comments, previousData := o.leadingComments(previousBody)
+	previousData = o.stripComments(previousData)
+	actual = o.formatForWrite(stripBOM(actual))
+	body := o.contractVariables(goldenFile, actual)
+	switch {
+	case isRegexpGolden(goldenFile):
+		body = updateRegexpGolden(previousData, actual)
+	case isDigestGolden(goldenFile):
+		body = formatDigest(o.normalize(actual))
+	case isPointerGolden(goldenFile):
+		body = formatPointer(actual)
+	}
+	// Comments further down are only kept if the data does not change.
+	if o.commentPrefix != "" && body == previousData && (header != "" || !o.metadataHeader) {
+		return previous
+	}
+	body = comments + body
+	if header != "" && body == previousBody {
+		return previous
+	}
+	if o.metadataHeader {
+		entries := o.metadata
+		if test := callingTest(); o.testMetadata && test != "" {
+			entries = append([]metadataEntry{{"test", test}}, entries...)
+		}
+		return formatMetadata(entries, time.Now()) + body
+	}
+	return body
+}
+
+// writeGoldenFile overwrites fullPath with the result of calling contents on
+// its previous contents, and reports whether the file was created, modified
+// or left unchanged. If -backup_golden is set, the previous contents of a
+// modified file are first copied to fullPath+".bak".
+func writeGoldenFile(fullPath string, contents func(previous string) string) (updateStatus, error) {
+	if status, buffered, err := bufferWrite(fullPath, contents); buffered {
+		return status, err
+	}
+	previous, err := ioutil.ReadFile(fullPath)
+	status := statusModified
+	switch {
+	case os.IsNotExist(err):
+		status = statusCreated
+	case err != nil:
+		return status, err
+	}
+	actual := contents(string(previous))
+	if status == statusModified && string(previous) == actual {
+		return statusUnchanged, nil
+	}
+	if status == statusModified && shouldBackupGolden() {
+		if err := ioutil.WriteFile(fullPath+".bak", previous, 0660); err != nil {
+			return status, fmt.Errorf("backing up golden file: %v", err)
+		}
 	}
-	return fmt.Sprintf("Actual data differs from golden data; run %q to update\n%v", formatUpdateCommand(), diffstr)
+	return status, ioutil.WriteFile(fullPath, []byte(actual), 0660)
 }
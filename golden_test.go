@@ -15,9 +15,12 @@
 package golden
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -34,8 +37,9 @@ func TestCompareNotEquals(t *testing.T) {
 	got := Compare("It reads many bits\nIt exchanges twenty bits\nIt writes many bits\n",
 		"github.com/google/golden/testdata/haiku.txt.golden")
 	want := `Actual data differs from golden data; run "go test -update_golden" to update
---- github.com/google/golden/testdata/haiku.txt.golden
-+++ github.com/google/golden/testdata/haiku.txt.actual
+testdata/haiku.txt.golden:2:14: first difference (byte offset 32)
+--- testdata/haiku.txt.golden
++++ testdata/haiku.txt.actual
 @@ -1,4 +1,4 @@
  It reads many bits
 -It exchanges many bits
@@ -77,3 +81,126 @@ func TestUpdateGolden(t *testing.T) {
 		}
 	}
 }
+
+func TestUpdateGoldenBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	originalBackupGolden := backupGolden.Load()
+	backupGolden.Store(true)
+	defer func() {
+		backupGolden.Store(originalBackupGolden)
+	}()
+
+	goldenPath := path.Join(dir, "src/fake/testdata/haiku.txt.golden")
+	// The first update creates the file, so there is nothing to back up.
+	Compare("Old contents", "fake/testdata/haiku.txt.golden")
+	if _, err := os.Stat(goldenPath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("backup after creating golden file: got err %v, want not exist", err)
+	}
+	Compare("New contents", "fake/testdata/haiku.txt.golden")
+	got, err := ioutil.ReadFile(goldenPath + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Old contents"; string(got) != want {
+		t.Errorf("backup contents: got %q, want %q", string(got), want)
+	}
+}
+
+func TestCompareRelativeToPackage(t *testing.T) {
+	got := Compare("It reads many bits\nIt exchanges many bits\nIt writes many bits\n", "./testdata/haiku.txt.golden")
+	if got != "" {
+		t.Errorf("got %q, want no diff", got)
+	}
+}
+
+// compareBenchmarks lists the inputs that Compare is benchmarked with, and
+// how many allocations a call may make at most. The budgets leave some
+// headroom, but must not grow with the size of the data: equal data is
+// confirmed by streaming the golden file, and a small change to a large file
+// is only diffed around the change. Their golden files are written by
+// writeBenchmarkGoldens.
+var compareBenchmarks = []struct {
+	name      string
+	golden    string
+	actual    string
+	maxAllocs float64
+}{
+	{"small/equal", benchmarkData(10), benchmarkData(10), 10},
+	{"small/different", benchmarkData(10), changeLine(benchmarkData(10), 5), 80},
+	{"large/equal", benchmarkData(100000), benchmarkData(100000), 10},
+	{"large/different", benchmarkData(100000), changeLine(benchmarkData(100000), 50000), 120},
+}
+
+// benchmarkData returns n lines of text.
+func benchmarkData(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "line %d of the benchmark data\n", i)
+	}
+	return b.String()
+}
+
+// changeLine changes line i of data.
+func changeLine(data string, i int) string {
+	lines := strings.SplitAfter(data, "\n")
+	lines[i] = "changed\n"
+	return strings.Join(lines, "")
+}
+
+// writeBenchmarkGoldens writes the golden files of compareBenchmarks to dir
+// and returns their paths.
+func writeBenchmarkGoldens(tb testing.TB, dir string) []string {
+	var paths []string
+	for i, bm := range compareBenchmarks {
+		p := filepath.Join(dir, fmt.Sprintf("bench%d.golden", i))
+		if err := ioutil.WriteFile(p, []byte(bm.golden), 0600); err != nil {
+			tb.Fatalf("Cannot write golden file: %v", err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func BenchmarkCompare(b *testing.B) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		b.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	paths := writeBenchmarkGoldens(b, dir)
+	for i, bm := range compareBenchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.actual)))
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				Compare(bm.actual, paths[i])
+			}
+		})
+	}
+}
+
+func TestCompareAllocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	paths := writeBenchmarkGoldens(t, dir)
+	for i, bm := range compareBenchmarks {
+		allocs := testing.AllocsPerRun(10, func() {
+			Compare(bm.actual, paths[i])
+		})
+		if allocs > bm.maxAllocs {
+			t.Errorf("%v: Compare made %v allocations, want at most %v", bm.name, allocs, bm.maxAllocs)
+		}
+	}
+}
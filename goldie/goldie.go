@@ -0,0 +1,125 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package goldie is a drop-in replacement for the most used parts of the API
+// of github.com/sebdah/goldie/v2, backed by package golden. Switching a test
+// suite over only takes changing its imports:
+//
+//     g := goldie.New(t)
+//     g.Assert(t, "example", []byte("actual"))
+//
+// Golden files are kept in testdata/<name>.golden as with goldie, but are
+// updated with -update_golden. Calling golden.Run from TestMain also makes
+// -update work, as it does with goldie.
+package goldie
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/golden"
+)
+
+// Goldie compares data to golden files in a fixture directory.
+type Goldie struct {
+	fixtureDir string
+	nameSuffix string
+	opts       []golden.Option
+}
+
+// An Option configures a Goldie.
+type Option func(*Goldie) error
+
+// WithFixtureDir sets the directory golden files are kept in. The default is
+// "testdata".
+func WithFixtureDir(dir string) Option {
+	return func(g *Goldie) error {
+		g.fixtureDir = dir
+		return nil
+	}
+}
+
+// WithNameSuffix sets the suffix appended to names to get golden file names.
+// The default is ".golden".
+func WithNameSuffix(suffix string) Option {
+	return func(g *Goldie) error {
+		g.nameSuffix = suffix
+		return nil
+	}
+}
+
+// WithGoldenOptions passes opts on to package golden. It has no goldie
+// counterpart.
+func WithGoldenOptions(opts ...golden.Option) Option {
+	return func(g *Goldie) error {
+		g.opts = append(g.opts, opts...)
+		return nil
+	}
+}
+
+// New returns a Goldie configured with options.
+func New(t *testing.T, options ...Option) *Goldie {
+	g := &Goldie{fixtureDir: "testdata", nameSuffix: ".golden"}
+	for _, option := range options {
+		if err := option(g); err != nil {
+			t.Fatalf("Could not apply option: %v", err)
+		}
+	}
+	return g
+}
+
+// GoldenFileName returns the golden file name for name.
+func (g *Goldie) GoldenFileName(t *testing.T, name string) string {
+	goldenFile := filepath.Join(g.fixtureDir, name+g.nameSuffix)
+	if filepath.IsAbs(goldenFile) {
+		return goldenFile
+	}
+	// Make the path relative to the package directory rather than to the
+	// GOPATH.
+	return "./" + filepath.ToSlash(goldenFile)
+}
+
+// Assert compares actualData to the golden file for name, and reports any
+// difference as a test error. With -update_golden, it updates the golden file
+// instead.
+func (g *Goldie) Assert(t *testing.T, name string, actualData []byte) {
+	t.Helper()
+	r := golden.Check(string(actualData), g.GoldenFileName(t, name), g.opts...)
+	if golden.Updating() {
+		if err := r.Update(); err != nil {
+			t.Error(err)
+		}
+		return
+	}
+	if !r.Equal() {
+		t.Error(r.String())
+	}
+}
+
+// AssertJson is like Assert for the indented JSON encoding of
+// actualJSONData.
+func (g *Goldie) AssertJson(t *testing.T, name string, actualJSONData interface{}) {
+	t.Helper()
+	data, err := json.MarshalIndent(actualJSONData, "", "  ")
+	if err != nil {
+		t.Fatalf("Could not marshal data to JSON: %v", err)
+	}
+	g.Assert(t, name, data)
+}
+
+// Update overwrites the golden file for name with actualData.
+func (g *Goldie) Update(t *testing.T, name string, actualData []byte) error {
+	return golden.Check(string(actualData), g.GoldenFileName(t, name), g.opts...).Update()
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goldie
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	g := New(t, WithFixtureDir(dir), WithNameSuffix(".gold"))
+	if got, want := g.GoldenFileName(t, "example"), filepath.Join(dir, "example.gold"); got != want {
+		t.Errorf("GoldenFileName: got %q want %q", got, want)
+	}
+	if got, want := New(t).GoldenFileName(t, "example"), "./testdata/example.golden"; got != want {
+		t.Errorf("GoldenFileName: got %q want %q", got, want)
+	}
+	if err := g.Update(t, "example", []byte("{\n  \"a\": 1\n}")); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	g.Assert(t, "example", []byte("{\n  \"a\": 1\n}"))
+	g.AssertJson(t, "example", map[string]int{"a": 1})
+}
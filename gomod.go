@@ -0,0 +1,103 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// gomodBackend resolves golden files against the directory of the main
+// module. Paths may be written either relative to that directory or as
+// import paths starting with the module path.
+type gomodBackend struct{}
+
+var mainModule struct {
+	sync.Mutex
+	dir, path string
+	err       error
+	resolved  bool
+}
+
+// findMainModule returns the directory and module path of the main module,
+// as reported by "go env GOMOD". The result is cached for the lifetime of
+// the process.
+func findMainModule() (dir string, modulePath string, err error) {
+	mainModule.Lock()
+	defer mainModule.Unlock()
+	if !mainModule.resolved {
+		mainModule.dir, mainModule.path, mainModule.err = readMainModule()
+		mainModule.resolved = true
+	}
+	return mainModule.dir, mainModule.path, mainModule.err
+}
+
+func readMainModule() (string, string, error) {
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("running go env GOMOD: %v", err)
+	}
+	gomod := strings.TrimSpace(string(out))
+	if gomod == "" || gomod == "/dev/null" || gomod == "NUL" {
+		return "", "", fmt.Errorf("not inside a Go module")
+	}
+	data, err := ioutil.ReadFile(gomod)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Dir(gomod), parseModulePath(data), nil
+}
+
+// parseModulePath returns the module path declared in the go.mod file data,
+// or the empty string if there is none.
+func parseModulePath(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+func (gomodBackend) resolve(relPath string) (string, error) {
+	dir, modulePath, err := findMainModule()
+	if err != nil {
+		return "", err
+	}
+	if modulePath != "" && strings.HasPrefix(relPath, modulePath+"/") {
+		relPath = strings.TrimPrefix(relPath, modulePath+"/")
+	}
+	return filepath.Join(dir, filepath.FromSlash(relPath)), nil
+}
+
+func (b gomodBackend) PathForRead(relPath string) (string, error) {
+	return b.resolve(relPath)
+}
+
+func (b gomodBackend) PathForWrite(relPath string) (string, error) {
+	return b.resolve(relPath)
+}
+
+func (gomodBackend) UpdateCommand() string {
+	return "go test -update_golden"
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseModulePath(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{in: "module github.com/google/golden\n\ngo 1.21\n", out: "github.com/google/golden"},
+		{in: "// comment\nmodule \"example.com/quoted\"\n", out: "example.com/quoted"},
+		{in: "go 1.21\n", out: ""},
+	}
+	for _, test := range tests {
+		if got := parseModulePath([]byte(test.in)); got != test.out {
+			t.Errorf("parseModulePath(%q): got %q want %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestGomodBackend(t *testing.T) {
+	mainModule.Lock()
+	dir, path, err, resolved := mainModule.dir, mainModule.path, mainModule.err, mainModule.resolved
+	mainModule.dir, mainModule.path, mainModule.err, mainModule.resolved = "/src/golden", "github.com/google/golden", nil, true
+	mainModule.Unlock()
+	defer func() {
+		mainModule.Lock()
+		mainModule.dir, mainModule.path, mainModule.err, mainModule.resolved = dir, path, err, resolved
+		mainModule.Unlock()
+	}()
+
+	var tests = []struct {
+		in, out string
+	}{
+		{in: "github.com/google/golden/testdata/a.golden", out: "/src/golden/testdata/a.golden"},
+		{in: "testdata/a.golden", out: "/src/golden/testdata/a.golden"},
+		{in: "github.com/google/goldenrod/a.golden", out: "/src/golden/github.com/google/goldenrod/a.golden"},
+	}
+	for _, test := range tests {
+		want := filepath.FromSlash(test.out)
+		if got, err := (gomodBackend{}).PathForRead(test.in); got != want || err != nil {
+			t.Errorf("PathForRead(%q): got (%q, %v) want %q", test.in, got, err, want)
+		}
+		if got, err := (gomodBackend{}).PathForWrite(test.in); got != want || err != nil {
+			t.Errorf("PathForWrite(%q): got (%q, %v) want %q", test.in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// CompareGoSource compares generated Go source code to the Go source in
+// goldenFile. Unlike Compare, it parses both and compares their syntax trees,
+// so differences in spacing, indentation or line breaks are tolerated, while
+// any change to the code itself is reported along with where it first occurs.
+// Comments are compared too, unless WithIgnoreGoComments is passed.
+//
+// Updating the golden file writes the actual source unchanged; combine with
+// WithGoFormat to keep it gofmt-clean.
+func CompareGoSource(actual string, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFile = o.prefixed(goldenFile)
+	if shouldUpdateGolden() {
+		if err := writeGolden(goldenFile, actual, o); err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	fullPath, _, expected, err := readGolden(goldenFile, o)
+	if err != nil {
+		return Fatalf("Error while checking golden file: %v", err)
+	}
+	expected = o.expandVariables(goldenFile, o.stripComments(expected))
+	display := displayPath(goldenFile, fullPath, o)
+	goldenFset, actualFset := token.NewFileSet(), token.NewFileSet()
+	goldenAST, err := parser.ParseFile(goldenFset, display, expected, parser.ParseComments)
+	if err != nil {
+		return Fatalf("Error while checking golden file: %v", err)
+	}
+	actualAST, err := parser.ParseFile(actualFset, actualFileName(display), actual, parser.ParseComments)
+	if err != nil {
+		return fmt.Sprintf("Actual data is not valid Go source: %v\n", err)
+	}
+	goldenNode, actualNode, equal := firstASTDifference(reflect.ValueOf(goldenAST), reflect.ValueOf(actualAST), goldenAST, actualAST, o.ignoreGoComments)
+	if equal {
+		return ""
+	}
+	o.normalizers = append(o.normalizers, GoFormat)
+	r := check(actual, goldenFile, o)
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	return fmt.Sprintf("Actual Go source differs structurally from golden data; run %q to update\nFirst difference in %v at %v of the golden file and %v of the actual data\n%v",
+		o.updateCommandOrDefault(), strings.TrimPrefix(fmt.Sprintf("%T", actualNode), "*ast."),
+		nodePosition(goldenFset, goldenNode), nodePosition(actualFset, actualNode), r.diff)
+}
+
+// nodePosition returns where n starts.
+func nodePosition(fset *token.FileSet, n ast.Node) Position {
+	p := fset.Position(n.Pos())
+	return Position{Offset: p.Offset, Line: p.Line, Column: p.Column}
+}
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	nodeType         = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// firstASTDifference walks the syntax trees a and b in parallel, ignoring
+// positions and resolved identifiers, and returns the innermost nodes
+// enclosing their first difference. na and nb are the innermost nodes
+// enclosing a and b. It reports whether the trees are equal.
+func firstASTDifference(a, b reflect.Value, na, nb ast.Node, ignoreComments bool) (ast.Node, ast.Node, bool) {
+	if a.Type() != b.Type() {
+		return na, nb, false
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return na, nb, a.IsNil() == b.IsNil()
+		}
+		if a.Type().Implements(nodeType) && a.Kind() == reflect.Ptr {
+			na, nb = a.Interface().(ast.Node), b.Interface().(ast.Node)
+		}
+		return firstASTDifference(a.Elem(), b.Elem(), na, nb, ignoreComments)
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			switch f := a.Type().Field(i); {
+			case f.Type == posType, f.Type == objectType, f.Type == scopeType, f.Name == "Unresolved":
+				continue
+			case ignoreComments && (f.Type == commentGroupType || f.Type == reflect.SliceOf(commentGroupType)):
+				continue
+			}
+			if na, nb, equal := firstASTDifference(a.Field(i), b.Field(i), na, nb, ignoreComments); !equal {
+				return na, nb, false
+			}
+		}
+		return na, nb, true
+	case reflect.Slice:
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			if na, nb, equal := firstASTDifference(a.Index(i), b.Index(i), na, nb, ignoreComments); !equal {
+				return na, nb, false
+			}
+		}
+		return na, nb, a.Len() == b.Len()
+	default:
+		return na, nb, a.Interface() == b.Interface()
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+const goldenGoSource = `package p
+
+// Answer is the answer.
+func Answer() int {
+	return 42
+}
+`
+
+func TestCompareGoSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "src/fake/testdata/gen.go.golden"), []byte(goldenGoSource), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+
+	var tests = []struct {
+		desc   string
+		actual string
+		opts   []Option
+		want   string
+	}{
+		{
+			desc:   "same source",
+			actual: goldenGoSource,
+			want:   "",
+		},
+		{
+			desc:   "cosmetic differences",
+			actual: "package p\n// Answer is the answer.\nfunc Answer( ) int { return 42 }",
+			want:   "",
+		},
+		{
+			desc:   "different comment",
+			actual: "package p\n// Answer is wrong.\nfunc Answer() int { return 42 }",
+			want:   "First difference in Comment at line 3, column 1 (byte offset 11) of the golden file and line 2, column 1 (byte offset 10) of the actual data\n",
+		},
+		{
+			desc:   "different comment ignored",
+			actual: "package p\n// Answer is wrong.\nfunc Answer() int { return 42 }",
+			opts:   []Option{WithIgnoreGoComments()},
+			want:   "",
+		},
+		{
+			desc:   "different literal",
+			actual: "package p\n// Answer is the answer.\nfunc Answer() int { return 41 }",
+			want: `First difference in BasicLit at line 5, column 9 (byte offset 64) of the golden file and line 3, column 28 (byte offset 62) of the actual data
+--- fake/testdata/gen.go.golden
++++ fake/testdata/gen.go.actual
+@@ -1,7 +1,5 @@
+ package p
+ 
+ // Answer is the answer.
+-func Answer() int {
+-	return 42
+-}
++func Answer() int { return 41 }
+ 
+`,
+		},
+		{
+			desc:   "different statements",
+			actual: "package p\n// Answer is the answer.\nfunc Answer() int { println(); return 42 }",
+			want:   "First difference in BlockStmt at line 4, column 19 (byte offset 54) of the golden file and line 3, column 19 (byte offset 53) of the actual data\n",
+		},
+		{
+			desc:   "invalid source",
+			actual: "package p\nfunc {",
+			want:   "Actual data is not valid Go source: ",
+		},
+	}
+	for _, test := range tests {
+		got := CompareGoSource(test.actual, "fake/testdata/gen.go.golden", test.opts...)
+		if !strings.Contains(got, test.want) || (got == "") != (test.want == "") {
+			t.Errorf("%v: got %q, want a message containing %q", test.desc, got, test.want)
+		}
+	}
+}
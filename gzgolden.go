@@ -0,0 +1,70 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+)
+
+// gzipGoldenSuffix marks golden files whose data is gzip-compressed, which
+// keeps large but compressible golden data small in the repository.
+const gzipGoldenSuffix = ".golden.gz"
+
+// gzipLevel is the compression level of gzip golden files. It is fixed so
+// that regenerating an unchanged golden file yields the same bytes.
+const gzipLevel = gzip.BestCompression
+
+func isGzipGolden(goldenFile string) bool {
+	return strings.HasSuffix(goldenFile, gzipGoldenSuffix)
+}
+
+// gzipGolden compresses data for a gzip golden file. The gzip header has no
+// name, a zero modification time and an unknown operating system, so that
+// the result only depends on data, and updating a golden file whose data did
+// not change leaves it byte for byte identical instead of churning it in
+// version control.
+func gzipGolden(data string) string {
+	buf := &bytes.Buffer{}
+	w, _ := gzip.NewWriterLevel(buf, gzipLevel)
+	w.Header = gzip.Header{OS: 255}
+	w.Write([]byte(data))
+	w.Close()
+	return buf.String()
+}
+
+// gunzipGolden returns the data of a gzip golden file.
+func gunzipGolden(contents []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// gzipContents adapts contents, which computes the data of a golden file from
+// its previous data, to gzip golden files. Previous contents that cannot be
+// decompressed are replaced as a whole.
+func gzipContents(contents func(previous string) string) func(previous string) string {
+	return func(previous string) string {
+		data, err := gunzipGolden([]byte(previous))
+		if err != nil {
+			data = nil
+		}
+		return gzipGolden(contents(string(data)))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGzipGolden(t *testing.T) {
+	data := strings.Repeat("line\n", 100)
+	got := gzipGolden(data)
+	if again := gzipGolden(data); again != got {
+		t.Errorf("gzipGolden is not deterministic: got %q, then %q", got, again)
+	}
+	if back, err := gunzipGolden([]byte(got)); string(back) != data || err != nil {
+		t.Errorf("gunzipGolden(gzipGolden(data)): got %q, %v, want %q", back, err, data)
+	}
+	if _, err := gunzipGolden([]byte(data)); err == nil {
+		t.Errorf("gunzipGolden of uncompressed data: got nil error")
+	}
+}
+
+func TestCompareGzipGolden(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	if got := Compare("a\nb\n", "data.golden.gz"); got != "" {
+		t.Errorf("Compare with -update_golden: got %q", got)
+	}
+	written, err := ioutil.ReadFile(env.Path("data.golden.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != gzipGolden("a\nb\n") {
+		t.Errorf("written golden file: got %q, want %q", written, gzipGolden("a\nb\n"))
+	}
+	// Updating with the same data leaves the file alone.
+	old := time.Unix(1, 0)
+	if err := os.Chtimes(env.Path("data.golden.gz"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	Compare("a\nb\n", "data.golden.gz")
+	if info, err := os.Stat(env.Path("data.golden.gz")); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("golden file rewritten although its data did not change: %v", err)
+	}
+	env.SetUpdating(false)
+
+	if got := Compare("a\nb\n", "data.golden.gz"); got != "" {
+		t.Errorf("Compare of equal data: got %q", got)
+	}
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+data.golden.gz:2:1: first difference (byte offset 2)
+--- data.golden.gz
++++ data.actual
+@@ -1,3 +1,3 @@
+ a
+-b
++c
+ 
+`
+	if got := Compare("a\nc\n", "data.golden.gz"); got != want {
+		t.Errorf("Compare of different data: got %q, want %q", got, want)
+	}
+
+	// No patch is saved, since it could not be applied to compressed data.
+	original := patchDir.Load()
+	defer func() { patchDir.Store(original) }()
+	patchDir.Store(env.Path("patches"))
+	if got := Compare("a\nc\n", "data.golden.gz"); got != want {
+		t.Errorf("Compare of different data with -golden_patch_dir: got %q, want %q", got, want)
+	}
+	if _, err := os.Stat(env.Path("patches")); !os.IsNotExist(err) {
+		t.Errorf("patch saved for a gzip golden file: %v", err)
+	}
+}
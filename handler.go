@@ -0,0 +1,95 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+)
+
+// CompareHandler serves req with h and compares a dump of the whole exchange
+// to goldenFile, as Compare does. The dump holds the request line, headers
+// and body, followed by the response status line, headers and body:
+//
+//     POST /users HTTP/1.1
+//     Host: example.com
+//     Content-Type: application/json
+//
+//     {"name":"alice"}
+//
+//     HTTP/1.1 201 Created
+//     Location: /users/1
+//
+//     created
+//
+// Headers are sorted by name. Create req with httptest.NewRequest. If its
+// body cannot be read, the error is returned as the failure message.
+func CompareHandler(h http.Handler, req *http.Request, goldenFile string, opts ...Option) string {
+	return CompareFunc(func() (string, error) {
+		return dumpExchange(h, req)
+	}, goldenFile, opts...)
+}
+
+// dumpExchange serves req with h and dumps both the request and the response.
+func dumpExchange(h http.Handler, req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return "", fmt.Errorf("reading request body: %v", err)
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%v %v %v\n", req.Method, req.URL.RequestURI(), req.Proto)
+	fmt.Fprintf(buf, "Host: %v\n", req.Host)
+	writeHeaderAndBody(buf, req.Header, body)
+	buf.WriteString("\n")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	resp := rec.Result()
+	fmt.Fprintf(buf, "HTTP/1.1 %03d %v\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+	writeHeaderAndBody(buf, resp.Header, rec.Body.Bytes())
+	return buf.String(), nil
+}
+
+// writeHeaderAndBody writes header with sorted names, a blank line, and body
+// if there is one.
+func writeHeaderAndBody(buf *bytes.Buffer, header http.Header, body []byte) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(buf, "%v: %v\n", name, value)
+		}
+	}
+	if len(body) == 0 {
+		return
+	}
+	buf.WriteString("\n")
+	buf.Write(body)
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCompareHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Location", "/users/1")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "created %s", body)
+	})
+
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	req := httptest.NewRequest("POST", "/users?dry_run=1", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set("X-Trace", "b")
+	req.Header.Set("Content-Type", "application/json")
+	CompareHandler(h, req, "fake/testdata/create.http.golden")
+	restoreFunc()
+	want := `POST /users?dry_run=1 HTTP/1.1
+Host: example.com
+Content-Type: application/json
+X-Trace: b
+
+{"name":"alice"}
+
+HTTP/1.1 201 Created
+Content-Type: text/plain
+Location: /users/1
+
+created {"name":"alice"}
+`
+	if got, _ := ioutil.ReadFile(path.Join(dir, "src/fake/testdata/create.http.golden")); string(got) != want {
+		t.Errorf("CompareHandler wrote %q, want %q", got, want)
+	}
+
+	defer setGoPathForTest(dir)()
+	req = httptest.NewRequest("POST", "/users?dry_run=1", strings.NewReader(`{"name":"bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace", "b")
+	if got := CompareHandler(h, req, "fake/testdata/create.http.golden"); !strings.Contains(got, "-created {\"name\":\"alice\"}\n+created {\"name\":\"bob\"}\n") {
+		t.Errorf("CompareHandler with a different request: got %q", got)
+	}
+	req = httptest.NewRequest("POST", "/users", iotest.TimeoutReader(strings.NewReader("xy")))
+	if got := CompareHandler(h, req, "fake/testdata/create.http.golden"); !strings.Contains(got, "reading request body") {
+		t.Errorf("CompareHandler with an unreadable body: got %q", got)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hclgolden compares HCL, such as Terraform configurations written by
+// infrastructure code generators, to golden files regardless of formatting:
+//
+//     if diff := golden.Compare(string(config), ".../testdata/main.tf.golden", hclgolden.WithFormat()); diff != "" {
+//       t.Error(diff)
+//     }
+package hclgolden
+
+import (
+	"github.com/google/golden"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Format is a golden.Normalizer laying out HCL in the canonical style of
+// hclwrite.Format, which terraform fmt uses: nested blocks are indented by two
+// spaces and the equals signs of consecutive attributes are aligned.
+// Expressions are not rewritten, and input that is not valid HCL is formatted
+// as far as it can be.
+func Format(src string) string {
+	return string(hclwrite.Format([]byte(src)))
+}
+
+// WithFormat makes the comparison ignore formatting differences that
+// terraform fmt would remove, for golden files holding generated HCL.
+func WithFormat() golden.Option {
+	return golden.WithNormalizer(Format)
+}
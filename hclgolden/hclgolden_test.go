@@ -0,0 +1,83 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hclgolden
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/golden"
+)
+
+func TestFormat(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{
+			in: `resource "aws_instance" "web" {
+ami="ami-123"
+    instance_type =   "t2.micro"   
+tags = {
+Name = "web"
+"kubernetes.io/role"= "node"
+}
+count = 2
+}
+`,
+			out: `resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+  tags = {
+    Name                 = "web"
+    "kubernetes.io/role" = "node"
+  }
+  count = 2
+}
+`,
+		},
+		{
+			// Brackets in strings and comments are not nesting, and escaped
+			// template sequences are kept.
+			in: `locals {
+a = "}${format("{%s}", var.x)}" # {
+b = "$${x}"
+}
+`,
+			out: `locals {
+  a = "}${format("{%s}", var.x)}" # {
+  b = "$${x}"
+}
+`,
+		},
+	}
+	for _, test := range tests {
+		if got := Format(test.in); got != test.out {
+			t.Errorf("Format(%q): got %q want %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestCompareWithFormat(t *testing.T) {
+	env := golden.TestEnv(t)
+	if err := ioutil.WriteFile(env.Path("main.tf.golden"), []byte("variable \"x\" {\n  default = 1\n}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := golden.Compare("variable \"x\" {\n    default   = 1\n}\n", "main.tf.golden", WithFormat()); got != "" {
+		t.Errorf("Compare with equivalent HCL: got %q, want no diff", got)
+	}
+	if got := golden.Compare("variable \"x\" {\n  default = 2\n}\n", "main.tf.golden", WithFormat()); got == "" {
+		t.Errorf("Compare with different HCL: got no diff")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// hexRowSize is the number of bytes on each row of a hexdump diff.
+	hexRowSize = 16
+	// hexContext is the number of unchanged bytes shown around changes.
+	hexContext = hexRowSize
+	// maxHexRows bounds the rows shown for each changed region.
+	maxHexRows = 32
+)
+
+// HexDiffer returns a Differ describing the differences between binary data
+// as a hexdump of the regions around them, such as changes to a wire format
+// or serialization. Each row holds up to 16 bytes, prefixed like in a
+// unified diff and by their offset, in the actual data for added bytes and
+// in the golden data otherwise, and followed by their printable characters:
+//
+//     @@ -0x0,18 +0x0,18 @@
+//       00000000  00 00 00 04 00 00 00 08  74 65 73 74 00           |........test.|
+//     - 0000000d  01                                                |.|
+//     + 0000000d  02                                                |.|
+//       0000000e  00 00 00 00                                       |....|
+//
+// Pass it to WithDiffer to use it for all data, or use WithHexDiff to only
+// add it to the report for data that looks binary.
+func HexDiffer() Differ {
+	return DifferFunc(hexDiff)
+}
+
+// WithHexDiff adds a hexdump of the regions around the differences, as
+// produced by HexDiffer, to the sizes and digests that mismatches of binary
+// data are otherwise reported with.
+func WithHexDiff() Option {
+	return func(o *options) {
+		o.hexDiff = true
+	}
+}
+
+// hexDiff returns a hexdump diff of expected and actual, as described for
+// HexDiffer.
+func hexDiff(expected, actual string) string {
+	buf := &strings.Builder{}
+	for _, group := range groupOpCodes(byteOpCodes(expected, actual), hexContext) {
+		first, last := group[0], group[len(group)-1]
+		fmt.Fprintf(buf, "@@ -0x%x,%d +0x%x,%d @@\n", first.i1, last.i2-first.i1, first.j1, last.j2-first.j1)
+		for _, c := range group {
+			if c.tag == 'e' {
+				writeHexRows(buf, ' ', c.i1, expected[c.i1:c.i2])
+				continue
+			}
+			if c.tag == 'r' || c.tag == 'd' {
+				writeHexRows(buf, '-', c.i1, expected[c.i1:c.i2])
+			}
+			if c.tag == 'r' || c.tag == 'i' {
+				writeHexRows(buf, '+', c.j1, actual[c.j1:c.j2])
+			}
+		}
+	}
+	return buf.String()
+}
+
+// byteOpCodes returns the opcodes turning a into b byte by byte. Bytes
+// common to their start and end are matched up front; in between, regions
+// too large for lcsMatches are taken to be replaced as a whole.
+func byteOpCodes(a, b string) []opCode {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var matches []match
+	if prefix > 0 {
+		matches = append(matches, match{0, 0, prefix})
+	}
+	matches = append(matches, lcsMatches(splitBytes(a[prefix:len(a)-suffix]), splitBytes(b[prefix:len(b)-suffix]), prefix, prefix)...)
+	if suffix > 0 {
+		matches = append(matches, match{len(a) - suffix, len(b) - suffix, suffix})
+	}
+	return opCodesFromMatches(matches, len(a), len(b))
+}
+
+// splitBytes returns each byte of s as a string, for lcsMatches.
+func splitBytes(s string) []string {
+	bytes := make([]string, len(s))
+	for i := range s {
+		bytes[i] = s[i : i+1]
+	}
+	return bytes
+}
+
+// writeHexRows writes data, found at offset, as hexdump rows starting with
+// prefix. Rows beyond maxHexRows are only counted.
+func writeHexRows(buf *strings.Builder, prefix byte, offset int, data string) {
+	for row := 0; len(data) > 0; row++ {
+		if row == maxHexRows {
+			fmt.Fprintf(buf, "%c %08x  ... %d more bytes\n", prefix, offset, len(data))
+			return
+		}
+		n := min(len(data), hexRowSize)
+		hex := &strings.Builder{}
+		ascii := &strings.Builder{}
+		for i := 0; i < n; i++ {
+			if i == hexRowSize/2 {
+				hex.WriteByte(' ')
+			}
+			fmt.Fprintf(hex, "%02x ", data[i])
+			if c := data[i]; c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(buf, "%c %08x  %-49s |%v|\n", prefix, offset, hex.String(), ascii.String())
+		data, offset = data[n:], offset+n
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHexDiff(t *testing.T) {
+	var tests = []struct {
+		desc             string
+		expected, actual string
+		want             string
+	}{
+		{
+			desc:     "changed byte",
+			expected: "\x00\x00\x00\x04\x00\x00\x00\x08test\x00\x01\x00\x00\x00\x00",
+			actual:   "\x00\x00\x00\x04\x00\x00\x00\x08test\x00\x02\x00\x00\x00\x00",
+			want: "@@ -0x0,18 +0x0,18 @@\n" +
+				"  00000000  00 00 00 04 00 00 00 08  74 65 73 74 00           |........test.|\n" +
+				"- 0000000d  01                                                |.|\n" +
+				"+ 0000000d  02                                                |.|\n" +
+				"  0000000e  00 00 00 00                                       |....|\n",
+		},
+		{
+			desc:     "inserted and deleted bytes",
+			expected: "abc\x00def",
+			actual:   "abdef!",
+			want: "@@ -0x0,7 +0x0,6 @@\n" +
+				"  00000000  61 62                                             |ab|\n" +
+				"- 00000002  63 00                                             |c.|\n" +
+				"  00000004  64 65 66                                          |def|\n" +
+				"+ 00000005  21                                                |!|\n",
+		},
+		{
+			desc:     "distant changes",
+			expected: "A" + strings.Repeat(".", 40) + "B",
+			actual:   "a" + strings.Repeat(".", 40) + "b",
+			want: "@@ -0x0,17 +0x0,17 @@\n" +
+				"- 00000000  41                                                |A|\n" +
+				"+ 00000000  61                                                |a|\n" +
+				"  00000001  2e 2e 2e 2e 2e 2e 2e 2e  2e 2e 2e 2e 2e 2e 2e 2e  |................|\n" +
+				"@@ -0x19,17 +0x19,17 @@\n" +
+				"  00000019  2e 2e 2e 2e 2e 2e 2e 2e  2e 2e 2e 2e 2e 2e 2e 2e  |................|\n" +
+				"- 00000029  42                                                |B|\n" +
+				"+ 00000029  62                                                |b|\n",
+		},
+	}
+	for _, test := range tests {
+		if got := HexDiffer().Diff(test.expected, test.actual); got != test.want {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestHexDiffLimitsRows(t *testing.T) {
+	got := hexDiff("", strings.Repeat("\x00", (maxHexRows+2)*hexRowSize))
+	if want := "+ 00000200  ... 32 more bytes\n"; !strings.HasSuffix(got, want) || strings.Count(got, "\n") != maxHexRows+2 {
+		t.Errorf("hexDiff of a large insertion: got %q, want %v rows ending with %q", got, maxHexRows, want)
+	}
+}
+
+func TestCompareWithHexDiff(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	Compare("\x00\x01\x02\x03", "data.bin.golden")
+	env.SetUpdating(false)
+
+	got := Compare("\x00\x01\x09\x03", "data.bin.golden", WithHexDiff())
+	if !strings.Contains(got, "Binary data differs:\n") || !strings.Contains(got, "- 00000002  02 ") || !strings.Contains(got, "+ 00000002  09 ") {
+		t.Errorf("Compare of binary data WithHexDiff: got %q", got)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package htmlgolden compares HTML documents to golden files by their
+// document tree, as parsed by golang.org/x/net/html, rather than by their
+// text. It suits tests of template rendering, where the markup's structure
+// matters but its serialization does not.
+//
+//     func TestPage(t *testing.T) {
+//       var buf bytes.Buffer
+//       tmpl.Execute(&buf, data)
+//       if diff := htmlgolden.Compare(buf.String(), ".../testdata/page.html.golden",
+//         htmlgolden.IgnoreAttributeOrder(), htmlgolden.IgnoreWhitespace()); diff != "" {
+//         t.Error(diff)
+//       }
+//     }
+package htmlgolden
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/golden"
+	"golang.org/x/net/html"
+)
+
+// An Option configures how Compare checks an HTML document against a golden
+// file.
+type Option func(*options)
+
+type options struct {
+	ignoreAttributeOrder bool
+	ignoreWhitespace     bool
+	golden               []golden.Option
+}
+
+// IgnoreAttributeOrder makes Compare ignore the order of each element's
+// attributes.
+func IgnoreAttributeOrder() Option {
+	return func(o *options) {
+		o.ignoreAttributeOrder = true
+	}
+}
+
+// IgnoreWhitespace makes Compare trim text and collapse runs of whitespace
+// within it, and drop text consisting only of whitespace, such as the
+// indentation between elements.
+func IgnoreWhitespace() Option {
+	return func(o *options) {
+		o.ignoreWhitespace = true
+	}
+}
+
+// WithGoldenOptions passes opts on to golden.Compare.
+func WithGoldenOptions(opts ...golden.Option) Option {
+	return func(o *options) {
+		o.golden = append(o.golden, opts...)
+	}
+}
+
+// Compare compares the HTML document actual to the one in goldenFile and
+// returns an empty string if their document trees match. Otherwise it returns
+// the path of the first node that differs, such as "html > body > ul > li[2]",
+// followed by a diff of both trees rendered with one node per line. Indices
+// count the preceding siblings with the same tag, starting from 1, and are
+// left out for the first one.
+//
+// Like golden.Compare, it overwrites goldenFile with actual if the
+// -update_golden flag is set.
+func Compare(actual string, goldenFile string, opts ...Option) string {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	gopts := append(o.golden, golden.WithNormalizer(o.render))
+	diff := golden.Compare(actual, goldenFile, gopts...)
+	if diff == "" {
+		return ""
+	}
+	r := golden.Check(actual, goldenFile, gopts...)
+	return fmt.Sprintf("First difference in %v\n%v", nodePath(o.render(actual), r.FirstDifference().Line), diff)
+}
+
+// render returns the document tree of the HTML document s, with one node per
+// line indented by its depth.
+func (o *options) render(s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		// The parser only fails on read errors, which strings.Reader
+		// does not return.
+		return s
+	}
+	var b strings.Builder
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		if line := o.renderNode(n); line != "" {
+			b.WriteString(strings.Repeat("  ", depth) + line + "\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, depth+1)
+		}
+	}
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, 0)
+	}
+	return b.String()
+}
+
+// renderNode returns the line representing n, or the empty string if n is
+// to be ignored.
+func (o *options) renderNode(n *html.Node) string {
+	switch n.Type {
+	case html.ElementNode:
+		attrs := make([]string, len(n.Attr))
+		for i, a := range n.Attr {
+			key := a.Key
+			if a.Namespace != "" {
+				key = a.Namespace + ":" + key
+			}
+			attrs[i] = fmt.Sprintf(" %v=%q", key, a.Val)
+		}
+		if o.ignoreAttributeOrder {
+			sort.Strings(attrs)
+		}
+		return "<" + n.Data + strings.Join(attrs, "") + ">"
+	case html.TextNode:
+		text := n.Data
+		if o.ignoreWhitespace {
+			if text = strings.Join(strings.Fields(text), " "); text == "" {
+				return ""
+			}
+		}
+		return strconv.Quote(text)
+	case html.CommentNode:
+		return "<!--" + n.Data + "-->"
+	case html.DoctypeNode:
+		return "<!DOCTYPE " + n.Data + ">"
+	}
+	return ""
+}
+
+// nodePath returns the path of elements leading to the node on the given
+// 1-based line of a rendered document tree.
+func nodePath(rendered string, line int) string {
+	lines := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	if line > len(lines) {
+		return "the end of the document"
+	}
+	// Walk back from the line, picking up each ancestor and counting the
+	// preceding siblings of the same tag at every level.
+	var path []string
+	depth := indentation(lines[line-1])
+	tag, index := nodeName(lines[line-1]), 1
+	for i := line - 2; i >= -1; i-- {
+		if i >= 0 && indentation(lines[i]) > depth {
+			continue
+		}
+		if i >= 0 && indentation(lines[i]) == depth {
+			if nodeName(lines[i]) == tag {
+				index++
+			}
+			continue
+		}
+		step := tag
+		if index > 1 {
+			step += "[" + strconv.Itoa(index) + "]"
+		}
+		path = append([]string{step}, path...)
+		if i < 0 {
+			break
+		}
+		depth, tag, index = indentation(lines[i]), nodeName(lines[i]), 1
+	}
+	return strings.Join(path, " > ")
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// nodeName returns the tag of the element rendered as line, or a
+// description of what other kind of node it is.
+func nodeName(line string) string {
+	line = strings.TrimLeft(line, " ")
+	switch {
+	case strings.HasPrefix(line, "<!--"):
+		return "comment()"
+	case strings.HasPrefix(line, "<!"):
+		return "doctype()"
+	case strings.HasPrefix(line, "<"):
+		return strings.FieldsFunc(line[1:], func(r rune) bool { return r == ' ' || r == '>' })[0]
+	}
+	return "text()"
+}
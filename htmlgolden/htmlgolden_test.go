@@ -0,0 +1,138 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlgolden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	in := "<!DOCTYPE html><ul class=a id=b>\n  <li>one  two</li><!-- c --></ul>"
+	var tests = []struct {
+		opts []Option
+		want string
+	}{
+		{
+			want: `<!DOCTYPE html>
+<html>
+  <head>
+  <body>
+    <ul class="a" id="b">
+      "\n  "
+      <li>
+        "one  two"
+      <!-- c -->
+`,
+		},
+		{
+			opts: []Option{IgnoreWhitespace()},
+			want: `<!DOCTYPE html>
+<html>
+  <head>
+  <body>
+    <ul class="a" id="b">
+      <li>
+        "one two"
+      <!-- c -->
+`,
+		},
+	}
+	for _, test := range tests {
+		o := &options{}
+		for _, opt := range test.opts {
+			opt(o)
+		}
+		if got := o.render(in); got != test.want {
+			t.Errorf("render(%q): got %q want %q", in, got, test.want)
+		}
+	}
+}
+
+func TestNodePath(t *testing.T) {
+	rendered := `<html>
+  <head>
+  <body>
+    <p>
+      "a"
+    <p>
+      "b"
+      <br>
+      "c"
+`
+	var tests = []struct {
+		line int
+		want string
+	}{
+		{line: 1, want: "html"},
+		{line: 5, want: "html > body > p > text()"},
+		{line: 6, want: "html > body > p[2]"},
+		{line: 9, want: "html > body > p[2] > text()[2]"},
+		{line: 10, want: "the end of the document"},
+	}
+	for _, test := range tests {
+		if got := nodePath(rendered, test.line); got != test.want {
+			t.Errorf("nodePath(%d): got %q want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := filepath.Join(dir, "page.html.golden")
+	golden := "<ul>\n  <li class=\"x\" id=\"1\">one</li>\n  <li>two</li>\n</ul>\n"
+	if err := ioutil.WriteFile(goldenFile, []byte(golden), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+
+	var tests = []struct {
+		actual string
+		opts   []Option
+		want   string
+	}{
+		{
+			actual: golden,
+			want:   "",
+		},
+		{
+			actual: "<ul><li id=1 class=x>one<li>two</ul>",
+			opts:   []Option{IgnoreAttributeOrder(), IgnoreWhitespace()},
+			want:   "",
+		},
+		{
+			actual: "<ul><li id=1 class=x>one<li>two</ul>",
+			opts:   []Option{IgnoreWhitespace()},
+			want:   "First difference in html > body > ul > li\n",
+		},
+		{
+			actual: "<ul><li class=x id=1>one<li>three</ul>",
+			opts:   []Option{IgnoreWhitespace()},
+			want:   "First difference in html > body > ul > li[2] > text()\n",
+		},
+	}
+	for _, test := range tests {
+		got := Compare(test.actual, goldenFile, test.opts...)
+		if !strings.HasPrefix(got, test.want) || (got == "") != (test.want == "") {
+			t.Errorf("Compare(%q): got %q, want a message starting with %q", test.actual, got, test.want)
+		}
+	}
+}
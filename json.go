@@ -0,0 +1,181 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonMask replaces values masked with WithJSONMask.
+const jsonMask = "<masked>"
+
+// CompareJSON compares JSON documents, ignoring differences in formatting and
+// in the order of object keys: both documents are indented and have their
+// keys sorted before they are diffed. Values at the paths given with
+// WithJSONExclude and WithJSONMask are removed or masked first.
+//
+// Updating the golden file writes the actual document in the same canonical
+// form, with two-space indentation and a final newline, so that repeated
+// updates never produce spurious diffs, for example due to map iteration
+// order. Actual data that is not valid JSON is written unchanged.
+func CompareJSON(actual string, goldenFile string, opts ...Option) string {
+	return Compare(actual, goldenFile, append(opts, withJSON())...)
+}
+
+// withJSON makes the comparison canonicalize JSON documents. It must come
+// after the options it depends on.
+func withJSON() Option {
+	return func(o *options) {
+		o.canonicalize = o.canonicalJSON
+		o.writeFormatters = append(o.writeFormatters, func(s string) string {
+			if canonical, err := o.canonicalJSON(s); err == nil {
+				return canonical
+			}
+			return s
+		})
+	}
+}
+
+// canonicalJSON returns the JSON document s indented, with sorted keys and
+// with the values at excluded and masked paths edited out, then transformed
+// as set by format-specific helpers such as CompareSchema.
+func (o *options) canonicalJSON(s string) (string, error) {
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return "", err
+	}
+	for _, edit := range []struct {
+		paths []string
+		mask  bool
+	}{{o.jsonExclude, false}, {o.jsonMask, true}} {
+		for _, p := range edit.paths {
+			path, err := parseJSONPath(p)
+			if err != nil {
+				return "", err
+			}
+			v, _ = editJSON(v, path, edit.mask)
+		}
+	}
+	if o.jsonTransform != nil {
+		v = o.jsonTransform(v)
+	}
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// A jsonSegment is a step of a JSON path: an object key, an array index, or
+// a wildcard matching every key or index.
+type jsonSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a path such as "$.metadata.name", "items[*].id" or
+// "spec.containers[0]['image']". The leading "$" is optional.
+func parseJSONPath(p string) ([]jsonSegment, error) {
+	rest := strings.TrimPrefix(p, "$")
+	var path []jsonSegment
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSON path %q: unclosed bracket", p)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				path = append(path, jsonSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, jsonSegment{key: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("JSON path %q: invalid index %q", p, inner)
+				}
+				path = append(path, jsonSegment{index: i, isIndex: true})
+			}
+		default:
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("JSON path %q: empty key", p)
+			}
+			rest = rest[end:]
+			path = append(path, jsonSegment{key: key, wildcard: key == "*"})
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("JSON path %q: empty path", p)
+	}
+	return path, nil
+}
+
+// editJSON returns v with the values at path removed, or masked if mask is
+// set. It reports whether v itself is to be kept.
+func editJSON(v interface{}, path []jsonSegment, mask bool) (interface{}, bool) {
+	if len(path) == 0 {
+		if mask {
+			return jsonMask, true
+		}
+		return nil, false
+	}
+	seg := path[0]
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if seg.wildcard || (!seg.isIndex && seg.key == k) {
+				if edited, keep := editJSON(child, path[1:], mask); keep {
+					v[k] = edited
+				} else {
+					delete(v, k)
+				}
+			}
+		}
+	case []interface{}:
+		kept := v[:0]
+		for i, child := range v {
+			if seg.wildcard || (seg.isIndex && seg.index == i) {
+				edited, keep := editJSON(child, path[1:], mask)
+				if !keep {
+					continue
+				}
+				child = edited
+			}
+			kept = append(kept, child)
+		}
+		return kept, true
+	}
+	return v, true
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	var tests = []struct {
+		in      string
+		want    []jsonSegment
+		wantErr bool
+	}{
+		{in: "$.metadata.name", want: []jsonSegment{{key: "metadata"}, {key: "name"}}},
+		{in: "items[*].id", want: []jsonSegment{{key: "items"}, {wildcard: true}, {key: "id"}}},
+		{in: "a[2]['b.c'].*", want: []jsonSegment{{key: "a"}, {index: 2, isIndex: true}, {key: "b.c"}, {key: "*", wildcard: true}}},
+		{in: "$", wantErr: true},
+		{in: "a[1", wantErr: true},
+		{in: "a[x]", wantErr: true},
+		{in: "a..b", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseJSONPath(test.in)
+		if (err != nil) != test.wantErr || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseJSONPath(%q): got %+v, %v, want %+v", test.in, got, err, test.want)
+		}
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	in := `{"b": 1.50, "a": "<x>", "items": [{"id": 1, "n": "x"}, {"id": 2}], "meta": {"ts": "now", "name": "n"}}`
+	var tests = []struct {
+		opts []Option
+		want string
+	}{
+		{
+			want: `{
+  "a": "<x>",
+  "b": 1.50,
+  "items": [
+    {
+      "id": 1,
+      "n": "x"
+    },
+    {
+      "id": 2
+    }
+  ],
+  "meta": {
+    "name": "n",
+    "ts": "now"
+  }
+}
+`,
+		},
+		{
+			opts: []Option{WithJSONExclude("items[*].id", "$.b", "items[1]"), WithJSONMask("meta.ts")},
+			want: `{
+  "a": "<x>",
+  "items": [
+    {
+      "n": "x"
+    }
+  ],
+  "meta": {
+    "name": "n",
+    "ts": "<masked>"
+  }
+}
+`,
+		},
+	}
+	for _, test := range tests {
+		got, err := newOptions(test.opts).canonicalJSON(in)
+		if err != nil || got != test.want {
+			t.Errorf("canonicalJSON: got %q, %v, want %q", got, err, test.want)
+		}
+	}
+	if _, err := newOptions(nil).canonicalJSON("{"); err == nil {
+		t.Errorf("canonicalJSON of invalid JSON: got nil error")
+	}
+}
+
+func TestCompareJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/obj.json.golden")
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	CompareJSON(`{"name": "x", "id": 123}`, "fake/testdata/obj.json.golden", WithJSONMask("id"))
+	restoreFunc()
+	want := "{\n  \"id\": \"<masked>\",\n  \"name\": \"x\"\n}\n"
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != want {
+		t.Errorf("updated golden file: got %q, want %q", got, want)
+	}
+
+	defer setGoPathForTest(dir)()
+	if got := CompareJSON(`{"id":456,"name":"x"}`, "fake/testdata/obj.json.golden", WithJSONMask("id")); got != "" {
+		t.Errorf("CompareJSON with a different masked value: got %q, want no diff", got)
+	}
+	if got := CompareJSON(`{"id":456,"name":"y"}`, "fake/testdata/obj.json.golden", WithJSONMask("id")); got == "" {
+		t.Errorf("CompareJSON with a different value: got no diff")
+	}
+}
+
+func TestCompareJSONUpdateIsStable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/map.json.golden")
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+
+	var tests = []struct {
+		actual string
+		want   string
+	}{
+		{actual: `{"b":{"y":2,"x":1},"a":[1, 2]}`, want: "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"x\": 1,\n    \"y\": 2\n  }\n}\n"},
+		{actual: `{"a":[1,2],"b":{"x":1,"y":2}}`, want: "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"x\": 1,\n    \"y\": 2\n  }\n}\n"},
+		{actual: "not JSON", want: "not JSON"},
+	}
+	for _, test := range tests {
+		CompareJSON(test.actual, "fake/testdata/map.json.golden")
+		if got, _ := ioutil.ReadFile(goldenPath); string(got) != test.want {
+			t.Errorf("updating with %q: got %q, want %q", test.actual, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompareManifests compares a stream of Kubernetes manifests, such as the
+// output of an operator or of helm template, to goldenFile. The stream is
+// split into its YAML documents, which are sorted by API version, kind and
+// name and compared as the sections of CompareSections, so each object that
+// differs is reported with its own diff:
+//
+//     -- apps/v1 Deployment default/web --
+//     apiVersion: apps/v1
+//     kind: Deployment
+//     ...
+//
+// Fields the API server populates are removed first: status and the
+// creationTimestamp, uid, resourceVersion, generation, managedFields and
+// selfLink of metadata. Manifests are handled as block-style YAML text, as
+// generators write them; documents that are not Kubernetes objects are kept
+// as they are, ordered before the objects.
+func CompareManifests(actual string, goldenFile string, opts ...Option) string {
+	return CompareSections(manifestSections(actual), goldenFile, opts...)
+}
+
+// serverFields lists the fields removed from manifests by CompareManifests,
+// keyed by the field that holds them, with "" for the top level.
+var serverFields = map[string]map[string]bool{
+	"": {"status": true},
+	"metadata": {
+		"creationTimestamp": true, "uid": true, "resourceVersion": true,
+		"generation": true, "managedFields": true, "selfLink": true,
+	},
+}
+
+// k8sObject is a YAML document of a manifest stream.
+type k8sObject struct {
+	apiVersion, kind, namespace, name string
+	data                              string
+}
+
+// sectionName returns the name of the section holding o.
+func (o k8sObject) sectionName(i int) string {
+	name := o.name
+	if o.namespace != "" {
+		name = o.namespace + "/" + name
+	}
+	s := strings.Join(strings.Fields(strings.Join([]string{o.apiVersion, o.kind, name}, " ")), " ")
+	if s == "" {
+		return fmt.Sprintf("document %d", i+1)
+	}
+	return s
+}
+
+// manifestSections splits a stream of manifests into sorted sections, one per
+// document.
+func manifestSections(stream string) []Section {
+	var objects []k8sObject
+	for _, doc := range splitYAMLDocuments(stream) {
+		objects = append(objects, parseK8sObject(doc))
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := objects[i], objects[j]
+		if a.apiVersion != b.apiVersion {
+			return a.apiVersion < b.apiVersion
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.namespace < b.namespace
+	})
+	var sections []Section
+	seen := map[string]int{}
+	for i, o := range objects {
+		name := o.sectionName(i)
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%v (%d)", name, seen[name])
+		}
+		sections = append(sections, Section{Name: name, Data: o.data})
+	}
+	return sections
+}
+
+// splitYAMLDocuments splits a YAML stream at its "---" and "..." markers,
+// dropping documents that hold nothing but comments and blank lines.
+func splitYAMLDocuments(stream string) []string {
+	var docs []string
+	var doc []string
+	flush := func() {
+		for _, line := range doc {
+			if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+				docs = append(docs, strings.Join(doc, "\n"))
+				break
+			}
+		}
+		doc = nil
+	}
+	for _, line := range strings.Split(stream, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if isYAMLMarker(line, "---") || isYAMLMarker(line, "...") {
+			flush()
+			continue
+		}
+		doc = append(doc, line)
+	}
+	flush()
+	return docs
+}
+
+// isYAMLMarker reports whether line is the document marker m, optionally
+// followed by a comment.
+func isYAMLMarker(line, m string) bool {
+	if !strings.HasPrefix(line, m) {
+		return false
+	}
+	rest := strings.TrimSpace(line[len(m):])
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+// parseK8sObject identifies the object in the YAML document doc and removes
+// its server-populated fields.
+func parseK8sObject(doc string) k8sObject {
+	var o k8sObject
+	var kept []string
+	parent := ""
+	// indent is the indentation of the keys of parent; skip is the
+	// indentation of the removed field whose lines are being dropped, or -1.
+	indent, skip := 0, -1
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			if skip < 0 {
+				kept = append(kept, line)
+			}
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if skip >= 0 {
+			if n > skip || n == skip && strings.HasPrefix(trimmed, "- ") {
+				continue
+			}
+			skip = -1
+		}
+		if n == 0 {
+			parent, indent = "", 0
+		} else if parent != "" && indent == 0 {
+			indent = n
+		}
+		key, value, ok := yamlKey(trimmed)
+		switch {
+		case !ok:
+		case n == 0:
+			switch key {
+			case "apiVersion":
+				o.apiVersion = value
+			case "kind":
+				o.kind = value
+			case "metadata":
+				parent = key
+			}
+		case parent == "metadata" && n == indent:
+			switch key {
+			case "name":
+				o.name = value
+			case "namespace":
+				o.namespace = value
+			}
+		}
+		if ok && (n == 0 && serverFields[""][key] || parent != "" && n == indent && serverFields[parent][key]) {
+			skip = n
+			continue
+		}
+		kept = append(kept, line)
+	}
+	o.data = strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n"
+	return o
+}
+
+// yamlKey splits the trimmed YAML line "key: value" into its key and its
+// scalar value, unquoted and without any comment.
+func yamlKey(line string) (key, value string, ok bool) {
+	i := strings.Index(line, ":")
+	if i <= 0 || strings.HasPrefix(line, "- ") || i+1 < len(line) && line[i+1] != ' ' {
+		return "", "", false
+	}
+	key, value = line[:i], strings.TrimSpace(line[i+1:])
+	if j := strings.Index(value, " #"); j >= 0 && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		value = strings.TrimSpace(value[:j])
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return key, value, true
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestSections(t *testing.T) {
+	stream := `# Source: chart/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: "prod"
+  uid: 1234
+  creationTimestamp: "2024-01-02T03:04:05Z"
+  managedFields:
+  - manager: kubectl
+    operation: Apply
+spec:
+  ports:
+  - port: 80
+status:
+  loadBalancer: {}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web # the frontend
+spec:
+  template:
+    metadata:
+      uid: kept
+...
+--- # empty
+# only a comment
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  k: v
+---
+plain: document
+`
+	want := []Section{
+		{"document 1", "plain: document\n"},
+		{"apps/v1 Deployment web", "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web # the frontend\nspec:\n  template:\n    metadata:\n      uid: kept\n"},
+		{"v1 ConfigMap a", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"},
+		{"v1 ConfigMap a (2)", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  k: v\n"},
+		{"v1 Service prod/web", "# Source: chart/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: \"prod\"\nspec:\n  ports:\n  - port: 80\n"},
+	}
+	if got := manifestSections(stream); !reflect.DeepEqual(got, want) {
+		t.Errorf("manifestSections: got %q, want %q", got, want)
+	}
+}
+
+func TestCompareManifests(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	CompareManifests("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n", "k8s.golden")
+	env.SetUpdating(false)
+
+	var tests = []struct {
+		actual   string
+		wantDiff bool
+	}{
+		{actual: "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n  uid: x\nstatus: {}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"},
+		{actual: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n", wantDiff: true},
+		{actual: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n", wantDiff: true},
+	}
+	for _, test := range tests {
+		if got := CompareManifests(test.actual, "k8s.golden"); (got != "") != test.wantDiff {
+			t.Errorf("CompareManifests(%q): got %q, want diff %v", test.actual, got, test.wantDiff)
+		}
+	}
+}
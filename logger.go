@@ -0,0 +1,78 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// A Logger receives the diagnostics of golden. A *log.Logger is one.
+type Logger interface {
+	// Printf logs a notice, such as a mismatch ignored because the golden
+	// file is quarantined.
+	Printf(format string, args ...interface{})
+	// Fatalf reports an error that prevents a comparison, such as a golden
+	// file that cannot be read or written.
+	Fatalf(format string, args ...interface{})
+}
+
+var logger struct {
+	sync.Mutex
+	l Logger
+}
+
+// SetLogger routes the diagnostics of golden through l, for frameworks that
+// embed golden and have logging of their own. By default they go to the
+// standard logger of package log, whose Fatalf exits the test binary. If the
+// Fatalf of l returns instead, the function that failed returns the error as
+// its failure message, as it would describe a mismatch, so that only the
+// calling test fails. Passing nil restores the default.
+func SetLogger(l Logger) {
+	logger.Lock()
+	defer logger.Unlock()
+	logger.l = l
+}
+
+func currentLogger() Logger {
+	logger.Lock()
+	defer logger.Unlock()
+	if logger.l == nil {
+		return log.Default()
+	}
+	return logger.l
+}
+
+// Fatalf reports an error that prevents a comparison through the Logger set
+// with SetLogger, and returns the error as a failure message in case the
+// Logger does not exit. It is meant for packages built on golden, such as
+// protogolden, to report errors the way golden does:
+//
+//     if err != nil {
+//       return golden.Fatalf("Error while rendering data: %v", err)
+//     }
+func Fatalf(format string, args ...interface{}) string {
+	if err := savePendingWrites(); err != nil {
+		currentLogger().Printf("Error while updating golden files: %v", err)
+	}
+	currentLogger().Fatalf(format, args...)
+	return fmt.Sprintf(format, args...) + "\n"
+}
+
+// logf logs a notice through the Logger set with SetLogger.
+func logf(format string, args ...interface{}) {
+	currentLogger().Printf(format, args...)
+}
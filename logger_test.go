@@ -0,0 +1,63 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingLogger records what it is given instead of logging it.
+type recordingLogger struct {
+	notices, errors []string
+}
+
+func (l *recordingLogger) Printf(format string, args ...interface{}) {
+	l.notices = append(l.notices, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Fatalf(format string, args ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	TestEnv(t)
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	var tests = []struct {
+		desc string
+		got  string
+	}{
+		{"Compare", Compare("x", "missing.golden")},
+		{"CompareSections", CompareSections([]Section{{"a", "x"}}, "missing.golden")},
+		{"CompareDir", CompareDir("missing", "testdata")["testdata"]},
+	}
+	if len(l.errors) != len(tests) {
+		t.Fatalf("errors reported: got %q, want %d", l.errors, len(tests))
+	}
+	for i, test := range tests {
+		if want := l.errors[i] + "\n"; test.got != want || !strings.HasPrefix(test.got, "Error while ") {
+			t.Errorf("%v with a logger that does not exit: got %q, want %q", test.desc, test.got, want)
+		}
+	}
+
+	logf("notice %d", 1)
+	if want := []string{"notice 1"}; len(l.notices) != 1 || l.notices[0] != want[0] {
+		t.Errorf("notices: got %q, want %q", l.notices, want)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "regexp"
+
+var (
+	glogHeader   = regexp.MustCompile(`(?m)^([IWEF])\d{4} \d{2}:\d{2}:\d{2}\.\d{6} +\d+ ([^:\s]+):\d+\]`)
+	stdLogTime   = regexp.MustCompile(`(?m)^(?:\d{4}/\d{2}/\d{2} (?:\d{2}:\d{2}:\d{2}(?:\.\d{6})? )?|\d{2}:\d{2}:\d{2}(?:\.\d{6})? )`)
+	stdLogSource = regexp.MustCompile(`(?m)^([0/:. ]*)(\S+\.go):\d+: `)
+	slogTextTime = regexp.MustCompile(`(^|\s)time=\S+`)
+	slogTextSrc  = regexp.MustCompile(`(^|\s)source=(?:\S*/)?(\S+?):\d+`)
+	slogJSONTime = regexp.MustCompile(`"time":"[^"]*"`)
+	slogJSONSrc  = regexp.MustCompile(`"source":\{[^}]*\}`)
+	slogJSONFile = regexp.MustCompile(`"file":"(?:[^"]*/)?([^"]*)"`)
+	slogJSONLine = regexp.MustCompile(`"line":\d+`)
+	goroutineID  = regexp.MustCompile(`\bgoroutine \d+`)
+	processID    = regexp.MustCompile(`\b(pid[=:] ?"?)\d+`)
+	anyDigit     = regexp.MustCompile(`\d`)
+)
+
+// zeroDigits replaces every digit of s with 0, keeping the layout of
+// timestamps readable.
+func zeroDigits(s string) string {
+	return anyDigit.ReplaceAllString(s, "0")
+}
+
+// ScrubGlog is a Normalizer masking the variable parts of glog line headers:
+// the timestamp, the thread ID and the source line number. For example,
+// "I0102 15:04:05.123456   4321 server.go:42] started" becomes
+// "I0000 00:00:00.000000 0 server.go:0] started".
+func ScrubGlog(s string) string {
+	return glogHeader.ReplaceAllString(s, "${1}0000 00:00:00.000000 0 ${2}:0]")
+}
+
+// ScrubStdLog is a Normalizer masking the date, time and source line number
+// that the standard log package prefixes lines with, depending on its flags.
+// For example, "2009/11/10 23:00:00 main.go:12: started" becomes
+// "0000/00/00 00:00:00 main.go:0: started".
+func ScrubStdLog(s string) string {
+	s = stdLogTime.ReplaceAllStringFunc(s, zeroDigits)
+	return stdLogSource.ReplaceAllString(s, "${1}${2}:0: ")
+}
+
+// ScrubSlog is a Normalizer masking the time and source attributes written
+// by log/slog's text and JSON handlers. Times have their digits zeroed, and
+// sources are reduced to their file name with line 0.
+func ScrubSlog(s string) string {
+	s = slogTextTime.ReplaceAllStringFunc(s, zeroDigits)
+	s = slogTextSrc.ReplaceAllString(s, "${1}source=${2}:0")
+	s = slogJSONTime.ReplaceAllStringFunc(s, zeroDigits)
+	return slogJSONSrc.ReplaceAllStringFunc(s, func(source string) string {
+		source = slogJSONFile.ReplaceAllString(source, `"file":"${1}"`)
+		return slogJSONLine.ReplaceAllString(source, `"line":0`)
+	})
+}
+
+// ScrubProcessIDs is a Normalizer masking goroutine IDs, as in stack traces
+// ("goroutine 17 [running]"), and process IDs logged as "pid=1234" or
+// "pid: 1234".
+func ScrubProcessIDs(s string) string {
+	s = goroutineID.ReplaceAllString(s, "goroutine 0")
+	return processID.ReplaceAllString(s, "${1}0")
+}
+
+// ScrubLogs is a Normalizer combining ScrubGlog, ScrubStdLog, ScrubSlog and
+// ScrubProcessIDs, for output mixing several log formats.
+func ScrubLogs(s string) string {
+	for _, n := range []Normalizer{ScrubGlog, ScrubStdLog, ScrubSlog, ScrubProcessIDs} {
+		s = n(s)
+	}
+	return s
+}
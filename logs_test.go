@@ -0,0 +1,67 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "testing"
+
+func TestLogScrubbers(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		n       Normalizer
+		in, out string
+	}{
+		{
+			desc: "glog",
+			n:    ScrubGlog,
+			in:   "I0102 15:04:05.123456   4321 server.go:42] started\nE1231 23:59:59.999999 7 db.go:7] failed\nplain\n",
+			out:  "I0000 00:00:00.000000 0 server.go:0] started\nE0000 00:00:00.000000 0 db.go:0] failed\nplain\n",
+		},
+		{
+			desc: "standard log",
+			n:    ScrubStdLog,
+			in:   "2009/11/10 23:00:00 main.go:12: started\n2009/11/10 23:00:00.123456 /src/x/main.go:3: more\n23:00:00 plain 12:00:00\n",
+			out:  "0000/00/00 00:00:00 main.go:0: started\n0000/00/00 00:00:00.000000 /src/x/main.go:0: more\n00:00:00 plain 12:00:00\n",
+		},
+		{
+			desc: "slog text",
+			n:    ScrubSlog,
+			in:   "time=2023-08-04T16:09:59.123-04:00 level=INFO source=/home/me/app/main.go:17 msg=hello count=3\n",
+			out:  "time=0000-00-00T00:00:00.000-00:00 level=INFO source=main.go:0 msg=hello count=3\n",
+		},
+		{
+			desc: "slog JSON",
+			n:    ScrubSlog,
+			in:   `{"time":"2023-08-04T16:09:59.123Z","level":"INFO","source":{"function":"main.main","file":"/home/me/app/main.go","line":17},"msg":"hello","line":5}` + "\n",
+			out:  `{"time":"0000-00-00T00:00:00.000Z","level":"INFO","source":{"function":"main.main","file":"main.go","line":0},"msg":"hello","line":5}` + "\n",
+		},
+		{
+			desc: "process IDs",
+			n:    ScrubProcessIDs,
+			in:   "goroutine 17 [running]:\nstarted pid=4321\nchild pid: 99\n",
+			out:  "goroutine 0 [running]:\nstarted pid=0\nchild pid: 0\n",
+		},
+		{
+			desc: "all",
+			n:    ScrubLogs,
+			in:   "I0102 15:04:05.123456 1 a.go:1] pid=5\n2009/11/10 23:00:00 b.go:2: goroutine 3\n",
+			out:  "I0000 00:00:00.000000 0 a.go:0] pid=0\n0000/00/00 00:00:00 b.go:0: goroutine 0\n",
+		},
+	}
+	for _, test := range tests {
+		if got := test.n(test.in); got != test.out {
+			t.Errorf("%v: got %q want %q", test.desc, got, test.out)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile is the name of the file written by WriteManifest.
+const ManifestFile = "MANIFEST"
+
+// isGoldenFile reports whether name looks like the name of a golden file.
+func isGoldenFile(name string) bool {
+	return strings.HasSuffix(name, ".golden") || isRegexpGolden(name) || isDigestGolden(name) || isPointerGolden(name) || isGzipGolden(name)
+}
+
+// goldenSums returns the hex SHA-256 sum of every golden file under dir,
+// keyed by slash-separated path relative to dir.
+func goldenSums(dir string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isGoldenFile(info.Name()) {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = fmt.Sprintf("%x", sha256.Sum256(data))
+		return nil
+	})
+	return sums, err
+}
+
+// WriteManifest writes a file named ManifestFile to dir, listing the SHA-256
+// sum of every golden file under dir in the format of sha256sum. CI can then
+// use VerifyManifest to cheaply detect golden files edited by hand, without
+// running the tests.
+func WriteManifest(dir string) error {
+	sums, err := goldenSums(dir)
+	if err != nil {
+		return err
+	}
+	files := make([]string, 0, len(sums))
+	for f := range sums {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	buf := &bytes.Buffer{}
+	for _, f := range files {
+		fmt.Fprintf(buf, "%s  %s\n", sums[f], f)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ManifestFile), buf.Bytes(), 0660)
+}
+
+// VerifyManifest checks the golden files under dir against the manifest
+// previously written there by WriteManifest. The returned error lists every
+// golden file that was modified, removed or added since.
+func VerifyManifest(dir string) error {
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return err
+	}
+	want := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("%v:%d: malformed manifest line %q", ManifestFile, lineNum, scanner.Text())
+		}
+		want[fields[1]] = fields[0]
+	}
+	got, err := goldenSums(dir)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for f, sum := range want {
+		gotSum, ok := got[f]
+		switch {
+		case !ok:
+			problems = append(problems, "missing: "+f)
+		case gotSum != sum:
+			problems = append(problems, "modified: "+f)
+		}
+	}
+	for f := range got {
+		if _, ok := want[f]; !ok {
+			problems = append(problems, "not in manifest: "+f)
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("golden files under %v do not match %v:\n  %v", dir, ManifestFile, strings.Join(problems, "\n  "))
+}
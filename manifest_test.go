@@ -0,0 +1,74 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "sub"), 0700); err != nil {
+		t.Fatalf("Unable to create directory: %v", err)
+	}
+	writeFile := func(name, contents string) {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Cannot write file: %v", err)
+		}
+	}
+	writeFile("a.golden", "a")
+	writeFile("sub/b.txt.golden", "b")
+	writeFile("sub/c.golden.re", "c")
+	writeFile("notgolden.txt", "x")
+
+	if err := WriteManifest(dir); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+	manifest, err := ioutil.ReadFile(path.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.golden
+3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d  sub/b.txt.golden
+2e7d2c03a9507ae265ecf5b5356885a53393a2029d241394997265a1a25aefc6  sub/c.golden.re
+`
+	if string(manifest) != want {
+		t.Errorf("manifest: got %q want %q", manifest, want)
+	}
+	if err := VerifyManifest(dir); err != nil {
+		t.Errorf("VerifyManifest of unchanged files: %v", err)
+	}
+
+	writeFile("notgolden.txt", "y")
+	writeFile("sub/b.txt.golden", "edited")
+	writeFile("d.golden", "new")
+	if err := os.Remove(path.Join(dir, "a.golden")); err != nil {
+		t.Fatal(err)
+	}
+	wantErr := "golden files under " + dir + " do not match MANIFEST:\n" +
+		"  missing: a.golden\n" +
+		"  modified: sub/b.txt.golden\n" +
+		"  not in manifest: d.golden"
+	if err := VerifyManifest(dir); err == nil || err.Error() != wantErr {
+		t.Errorf("VerifyManifest of changed files: got %v want %v", err, wantErr)
+	}
+}
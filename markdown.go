@@ -0,0 +1,85 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownFence         = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	markdownSetext        = regexp.MustCompile("^ {0,3}(=+|-+) *$")
+	markdownThematicBreak = regexp.MustCompile(`^ {0,3}([-*_])( *[-*_]){2,} *$`)
+	markdownBullet        = regexp.MustCompile(`^( *)[*+-]( +)`)
+	markdownOrdered       = regexp.MustCompile(`^( *)(\d{1,9})[.)]( +)`)
+	markdownATXHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?: +(.*?))??(?: +#+)? *$`)
+)
+
+// Markdown is a Normalizer rewriting Markdown to one of its equivalent
+// spellings, so that golden files of generated documentation are not
+// sensitive to the style of the generator. It turns underlined headings into
+// "#" headings and drops their closing "#"s, writes bullets as "-", the
+// numbers of ordered list items followed by "." rather than ")", and
+// thematic breaks as "---", and strips trailing whitespace. Fenced code
+// blocks are left alone.
+func Markdown(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	fence := ""
+	for _, line := range lines {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if m := markdownFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			out = append(out, line)
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if m := markdownSetext.FindStringSubmatch(line); m != nil && len(out) > 0 && isMarkdownParagraph(out[len(out)-1]) {
+			prefix := "# "
+			if m[1][0] == '-' {
+				prefix = "## "
+			}
+			out[len(out)-1] = prefix + strings.TrimSpace(out[len(out)-1])
+			continue
+		}
+		switch {
+		case markdownThematicBreak.MatchString(line):
+			line = "---"
+		case markdownATXHeading.MatchString(line):
+			m := markdownATXHeading.FindStringSubmatch(line)
+			line = strings.TrimSpace(m[1] + " " + m[2])
+		case markdownBullet.MatchString(line):
+			line = markdownBullet.ReplaceAllString(line, "${1}-${2}")
+		case markdownOrdered.MatchString(line):
+			line = markdownOrdered.ReplaceAllString(line, "${1}${2}.${3}")
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// isMarkdownParagraph reports whether a normalized line is paragraph text
+// that an underline would turn into a heading.
+func isMarkdownParagraph(line string) bool {
+	return strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") && line != "---" &&
+		!markdownBullet.MatchString(line) && !markdownOrdered.MatchString(line) && !strings.HasPrefix(line, "    ")
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{in: "", out: ""},
+		{in: "Title\n=====\n\nText  \n", out: "# Title\n\nText\n"},
+		{in: "Section\n---\n", out: "## Section\n"},
+		{in: "## Section ##\n#Not a heading\n", out: "## Section\n#Not a heading\n"},
+		{in: "Text\n\n***\n\n_ _ _\n", out: "Text\n\n---\n\n---\n"},
+		{in: "* one\n+ two\n  * nested\n", out: "- one\n- two\n  - nested\n"},
+		{in: "1) one\n2. two\n", out: "1. one\n2. two\n"},
+		{in: "- item\n---\n", out: "- item\n---\n"},
+		{in: "```\n* not a list   \nTitle\n===\n```\n* list\n", out: "```\n* not a list   \nTitle\n===\n```\n- list\n"},
+	}
+	for _, test := range tests {
+		if got := Markdown(test.in); got != test.out {
+			t.Errorf("Markdown(%q): got %q want %q", test.in, got, test.out)
+		}
+	}
+}
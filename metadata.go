@@ -0,0 +1,79 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// metadataPrefix starts each line of the metadata header of a golden file.
+// The header looks like this:
+//
+//     #!golden-meta test: TestRender
+//     #!golden-meta go: go1.21.0
+//     #!golden-meta updated: 2023-08-08T12:00:00Z
+const metadataPrefix = "#!golden-meta "
+
+type metadataEntry struct {
+	key, value string
+}
+
+// splitMetadata splits the contents of a golden file into its metadata
+// header, which is empty if there is none, and the golden data that follows.
+func splitMetadata(contents string) (header string, body string) {
+	rest := contents
+	for strings.HasPrefix(rest, metadataPrefix) {
+		i := strings.Index(rest, "\n")
+		if i < 0 {
+			i = len(rest) - 1
+		}
+		rest = rest[i+1:]
+	}
+	return contents[:len(contents)-len(rest)], rest
+}
+
+// parseMetadata returns the entries of a metadata header.
+func parseMetadata(header string) []metadataEntry {
+	var entries []metadataEntry
+	for _, line := range strings.Split(strings.TrimSuffix(header, "\n"), "\n") {
+		line = strings.TrimPrefix(line, metadataPrefix)
+		if line == "" {
+			continue
+		}
+		key, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		entries = append(entries, metadataEntry{key, value})
+	}
+	return entries
+}
+
+// formatMetadata returns a metadata header holding entries followed by the Go
+// version and the update time.
+func formatMetadata(entries []metadataEntry, updated time.Time) string {
+	buf := &bytes.Buffer{}
+	entries = append(append([]metadataEntry{}, entries...),
+		metadataEntry{"go", runtime.Version()},
+		metadataEntry{"updated", updated.UTC().Format(time.RFC3339)})
+	for _, e := range entries {
+		fmt.Fprintf(buf, "%s%s: %s\n", metadataPrefix, e.key, strings.Replace(e.value, "\n", " ", -1))
+	}
+	return buf.String()
+}
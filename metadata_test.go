@@ -0,0 +1,127 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitMetadata(t *testing.T) {
+	var tests = []struct {
+		in, header, body string
+	}{
+		{in: "", header: "", body: ""},
+		{in: "data\n", header: "", body: "data\n"},
+		{in: "#!golden-meta a: b\ndata\n", header: "#!golden-meta a: b\n", body: "data\n"},
+		{in: "#!golden-meta a: b\n#!golden-meta c: d\n", header: "#!golden-meta a: b\n#!golden-meta c: d\n", body: ""},
+		{in: "#!golden-meta a: b", header: "#!golden-meta a: b", body: ""},
+		{in: "data\n#!golden-meta a: b\n", header: "", body: "data\n#!golden-meta a: b\n"},
+	}
+	for _, test := range tests {
+		header, body := splitMetadata(test.in)
+		if header != test.header || body != test.body {
+			t.Errorf("splitMetadata(%q): got (%q, %q) want (%q, %q)", test.in, header, body, test.header, test.body)
+		}
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	updated := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	got := formatMetadata([]metadataEntry{{"test", "TestFoo"}}, updated)
+	want := "#!golden-meta test: TestFoo\n#!golden-meta go: " + runtime.Version() + "\n#!golden-meta updated: 2017-10-01T12:00:00Z\n"
+	if got != want {
+		t.Errorf("formatMetadata: got %q want %q", got, want)
+	}
+	wantEntries := []metadataEntry{{"test", "TestFoo"}, {"go", runtime.Version()}, {"updated", "2017-10-01T12:00:00Z"}}
+	if entries := parseMetadata(got); !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("parseMetadata(%q): got %v want %v", got, entries, wantEntries)
+	}
+}
+
+func TestUpdateWithMetadataHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/meta.golden")
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+
+	Compare("contents\n", "fake/testdata/meta.golden", WithMetadata("test", "TestUpdateWithMetadataHeader"))
+	written, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body := splitMetadata(string(written))
+	if !strings.HasPrefix(header, "#!golden-meta test: TestUpdateWithMetadataHeader\n#!golden-meta go: ") {
+		t.Errorf("written header: got %q", header)
+	}
+	if body != "contents\n" {
+		t.Errorf("written body: got %q want %q", body, "contents\n")
+	}
+
+	// Updating with the same data keeps the header, including its timestamp.
+	if err := ioutil.WriteFile(goldenPath, []byte("#!golden-meta updated: long ago\ncontents\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	Compare("contents\n", "fake/testdata/meta.golden", WithMetadataHeader())
+	if written, _ := ioutil.ReadFile(goldenPath); string(written) != "#!golden-meta updated: long ago\ncontents\n" {
+		t.Errorf("golden file rewritten although its data did not change: %q", written)
+	}
+
+	// The header is ignored when comparing.
+	updateGolden.Store(false)
+	if diff := Compare("contents\n", "fake/testdata/meta.golden"); diff != "" {
+		t.Errorf("Compare with metadata header: %v", diff)
+	}
+}
+
+func TestUpdateWithTestMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+
+	t.Run("subtest", func(t *testing.T) {
+		Compare("contents\n", "fake/testdata/owned.golden", WithTestMetadata(), WithMetadata("tool", "v1"))
+	})
+	written, err := ioutil.ReadFile(path.Join(dir, "src/fake/testdata/owned.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := splitMetadata(string(written))
+	entries := parseMetadata(header)
+	want := []metadataEntry{{"test", "TestUpdateWithTestMetadata"}, {"tool", "v1"}}
+	if len(entries) < len(want) || !reflect.DeepEqual(entries[:len(want)], want) {
+		t.Errorf("written metadata: got %v, want it to start with %v", entries, want)
+	}
+}
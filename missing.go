@@ -0,0 +1,38 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+)
+
+var missingAsEmpty = newBoolFlag("golden_missing_as_empty", false, "Whether golden files that do not exist compare as empty instead of failing the test, so that the first run shows all of the actual data as a diff.")
+
+// WithMissingAsEmpty makes a golden file that does not exist compare as if it
+// were empty, so that the failure message shows all of the actual data as
+// added lines instead of an error about the missing file. This is useful on
+// the first run of a new test, before -update_golden creates the file. The
+// -golden_missing_as_empty flag enables it for all comparisons.
+func WithMissingAsEmpty() Option {
+	return func(o *options) {
+		o.missingAsEmpty = true
+	}
+}
+
+// isMissingGolden reports whether err, returned while reading a golden file,
+// means the file does not exist and should compare as empty.
+func (o *options) isMissingGolden(err error) bool {
+	return (o.missingAsEmpty || missingAsEmpty.Load()) && errors.Is(err, ErrGoldenNotFound)
+}
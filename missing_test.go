@@ -0,0 +1,71 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMissingAsEmpty(t *testing.T) {
+	env := TestEnv(t)
+	var tests = []struct {
+		desc string
+		flag bool
+		opts []Option
+	}{
+		{desc: "option", opts: []Option{WithMissingAsEmpty()}},
+		{desc: "flag", flag: true},
+		{desc: "storage", opts: []Option{WithMissingAsEmpty(), WithStorage(DirStorage(env.Path("store")))}},
+	}
+	for _, test := range tests {
+		func() {
+			defer func(original bool) { missingAsEmpty.Store(original) }(missingAsEmpty.Load())
+			missingAsEmpty.Store(test.flag)
+			got := Compare("a\nb\n", "new.golden", test.opts...)
+			if !strings.Contains(got, "new.golden") || !strings.Contains(got, "+a\n+b\n") {
+				t.Errorf("%v: Compare with a missing golden file: got %q, want a diff adding all lines", test.desc, got)
+			}
+			if got := Compare("", "new.golden", test.opts...); got != "" {
+				t.Errorf("%v: Compare of empty data with a missing golden file: got %q, want no diff", test.desc, got)
+			}
+		}()
+	}
+
+	// Other errors are still reported.
+	if err := os.MkdirAll(env.Path("dir.golden"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check("", "dir.golden", WithMissingAsEmpty()).Err(); err == nil {
+		t.Errorf("Check with a directory for a golden file: got nil error")
+	}
+}
+
+func TestCompareFuncWithMissingAsEmpty(t *testing.T) {
+	TestEnv(t)
+	gen := func() (string, error) { return "a\n", nil }
+	if got := CompareFunc(gen, "new.golden", WithMissingAsEmpty()); !strings.Contains(got, "+a\n") {
+		t.Errorf("CompareFunc with a missing golden file: got %q, want a diff adding all lines", got)
+	}
+}
+
+func TestContainsWithMissingAsEmpty(t *testing.T) {
+	TestEnv(t)
+	got := Contains("anything\n", "typo.golden", WithMissingAsEmpty())
+	if !strings.HasPrefix(got, "Golden fragment file typo.golden does not exist\n") || !strings.Contains(got, "+anything\n") {
+		t.Errorf("Contains with a missing fragment file: got %q, want a diff adding all lines", got)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// mmapThreshold is the size from which golden files are memory-mapped rather
+// than read into the heap.
+const mmapThreshold = 4 << 20
+
+// readMapped returns the contents of the file at path, along with a function
+// releasing them once they are no longer used. Files of at least
+// mmapThreshold bytes are memory-mapped where possible, so that their
+// contents are backed by the page cache instead of adding to the heap and to
+// the work of the garbage collector. Other files, and large files that cannot
+// be mapped, are read as usual.
+func readMapped(path string) (data []byte, release func(), err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if size := info.Size(); size >= mmapThreshold && int64(int(size)) == size {
+		if data, err := mmapFile(f, int(size)); err == nil {
+			return data, func() { munmap(data) }, nil
+		}
+	}
+	data, err = ioutil.ReadAll(f)
+	return data, func() {}, err
+}
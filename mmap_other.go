@@ -0,0 +1,33 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package golden
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile always fails on this platform, so that files are read instead.
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errors.New("memory mapping is not supported on this platform")
+}
+
+// munmap does nothing on this platform.
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMapped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, size := range []int{0, 10, mmapThreshold, mmapThreshold + 1} {
+		want := bytes.Repeat([]byte("x"), size)
+		p := filepath.Join(dir, "data")
+		if err := ioutil.WriteFile(p, want, 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, release, err := readMapped(p)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("readMapped of %d bytes: got %d bytes, %v", size, len(got), err)
+		}
+		if release != nil {
+			release()
+		}
+	}
+	if _, _, err := readMapped(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("readMapped of a missing file: got %v, want not exist", err)
+	}
+}
+
+func TestCompareLargeGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	data := string(bytes.Repeat([]byte("line\n"), mmapThreshold/5+1))
+	goldenFile := filepath.Join(dir, "large.golden")
+	if err := ioutil.WriteFile(goldenFile, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if diff := Compare(data, goldenFile); diff != "" {
+		t.Errorf("Compare with a large golden file: got a diff of %d bytes", len(diff))
+	}
+	if diff := Compare(data+"more\n", goldenFile); diff == "" {
+		t.Errorf("Compare with a large golden file and different data: got no diff")
+	}
+}
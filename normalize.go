@@ -0,0 +1,69 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A Normalizer rewrites golden or actual data before they are compared, so
+// that differences that do not matter are not reported. See WithNormalizer.
+type Normalizer func(string) string
+
+// dropMatchingLines removes the lines of s that match any of patterns.
+func dropMatchingLines(s string, patterns []*regexp.Regexp) string {
+	lines := strings.SplitAfter(s, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if !matchesAny(strings.TrimSuffix(line, "\n"), patterns) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+func matchesAny(s string, patterns []*regexp.Regexp) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalNewline is a Normalizer ending non-empty data with exactly one
+// newline, dropping extra empty lines at the end or adding a missing final
+// newline.
+func FinalNewline(s string) string {
+	if s = strings.TrimRight(s, "\n"); s == "" {
+		return ""
+	}
+	return s + "\n"
+}
+
+// WithFinalNewline makes the comparison ignore a missing or extra newline at
+// the end of the data. It is also applied when updating, so that golden files
+// always end with exactly one newline, as POSIX tools and many linters
+// expect.
+func WithFinalNewline() Option {
+	return func(o *options) {
+		o.normalizers = append(o.normalizers, FinalNewline)
+		o.writeFormatters = append(o.writeFormatters, FinalNewline)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDropMatchingLines(t *testing.T) {
+	patterns := []*regexp.Regexp{regexp.MustCompile(`^Generated at: `), regexp.MustCompile(`build \d+$`)}
+	var tests = []struct {
+		in, out string
+	}{
+		{in: "", out: ""},
+		{in: "a\nb\n", out: "a\nb\n"},
+		{in: "Generated at: today\na\nb\n", out: "a\nb\n"},
+		{in: "a\nthis is build 1234\nb", out: "a\nb"},
+		{in: "a\nthis is build 1234", out: "a\n"},
+	}
+	for _, test := range tests {
+		if got := dropMatchingLines(test.in, patterns); got != test.out {
+			t.Errorf("dropMatchingLines(%q): got %q want %q", test.in, got, test.out)
+		}
+	}
+}
+
+func TestCompareWithIgnoreLines(t *testing.T) {
+	got := Compare("It reads many bits\nIt exchanges 42 bits\nIt writes many bits\n",
+		"github.com/google/golden/testdata/haiku.txt.golden",
+		WithIgnoreLines(regexp.MustCompile(`^It exchanges`)))
+	if got != "" {
+		t.Errorf("got %q, want no diff", got)
+	}
+}
+
+func TestCompareWithNormalizer(t *testing.T) {
+	got := Compare("IT READS MANY BITS\nIT EXCHANGES MANY BITS\nIT WRITES MANY BITS\n",
+		"github.com/google/golden/testdata/haiku.txt.golden",
+		WithNormalizer(strings.ToLower))
+	if got != "" {
+		t.Errorf("got %q, want no diff", got)
+	}
+}
+
+func TestFinalNewline(t *testing.T) {
+	var tests = []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"\n\n", ""},
+		{"a", "a\n"},
+		{"a\n", "a\n"},
+		{"a\n\n\n", "a\n"},
+		{"a\n\nb", "a\n\nb\n"},
+	}
+	for _, test := range tests {
+		if got := FinalNewline(test.in); got != test.want {
+			t.Errorf("FinalNewline(%q): got %q want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestCompareWithFinalNewline(t *testing.T) {
+	for _, actual := range []string{
+		"It reads many bits\nIt exchanges many bits\nIt writes many bits",
+		"It reads many bits\nIt exchanges many bits\nIt writes many bits\n\n",
+	} {
+		if got := Compare(actual, "github.com/google/golden/testdata/haiku.txt.golden", WithFinalNewline()); got != "" {
+			t.Errorf("Compare(%q): got %q, want no diff", actual, got)
+		}
+	}
+}
+
+func TestUpdateWithFinalNewline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+
+	Compare("no newline", "fake/testdata/newline.golden", WithFinalNewline())
+	if written, _ := ioutil.ReadFile(path.Join(dir, "src/fake/testdata/newline.golden")); string(written) != "no newline\n" {
+		t.Errorf("updated golden file: got %q want %q", written, "no newline\n")
+	}
+}
@@ -0,0 +1,465 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"regexp"
+	"sync"
+)
+
+// An Option configures how Compare checks actual data against a golden file.
+type Option func(*options)
+
+type options struct {
+	// differ, if set, replaces the default unified diff.
+	differ Differ
+	// patience selects the patience algorithm for the default differ.
+	patience bool
+	// ignoreLines lists patterns of lines dropped before comparison.
+	ignoreLines []*regexp.Regexp
+	// normalizers rewrite golden and actual data before comparison.
+	normalizers []Normalizer
+	// writeFormatters rewrite actual data before it is written.
+	writeFormatters []Normalizer
+	// ignoreGoComments makes CompareGoSource ignore comments.
+	ignoreGoComments bool
+	// jsonExclude and jsonMask list the paths of values that CompareJSON
+	// removes and masks.
+	jsonExclude, jsonMask []string
+	// valueFormatters, skipUnexported and skipFields customize how
+	// CompareValue formats values.
+	valueFormatters map[reflect.Type]func(interface{}) string
+	skipUnexported  bool
+	skipFields      map[string]bool
+	// jsonTransform, if set, rewrites decoded JSON documents after
+	// jsonExclude and jsonMask are applied. It is set by format-specific
+	// helpers such as CompareSchema.
+	jsonTransform func(interface{}) interface{}
+	// unorderedRows makes CompareRows sort rows.
+	unorderedRows bool
+	// metadataHeader makes updates write a metadata header.
+	metadataHeader bool
+	// metadata lists additional entries for the metadata header.
+	metadata []metadataEntry
+	// commentPrefix starts comment lines in golden files, if set.
+	commentPrefix string
+	// variables, if not nil, enables variable expansion in golden files and
+	// holds the values of variables passed to WithVariables.
+	variables map[string]string
+	// utf16 transcodes golden files starting with a UTF-16 byte order mark.
+	utf16 bool
+	// testMetadata adds the name of the updating test to the metadata header.
+	testMetadata bool
+	// jsonRecord appends a machine-readable record to mismatch messages.
+	jsonRecord bool
+	// fingerprint appends an environment fingerprint to mismatch messages.
+	fingerprint bool
+	// updateCommand, if set, overrides the update command shown on failure.
+	updateCommand string
+	// parallelism bounds the number of concurrent comparisons in batch APIs.
+	parallelism int
+	// denyList lists patterns that must not be written to golden files.
+	denyList []*regexp.Regexp
+	// reportOnly hides the contents of mismatching data.
+	reportOnly bool
+	// fullContents appends the compared data to failure messages.
+	fullContents bool
+	// canonicalize, if set, converts both the golden and the actual data to
+	// a canonical text form before they are normalized and compared. It is
+	// set by format-specific helpers such as CompareZip.
+	canonicalize func(string) (string, error)
+	// blobStorage, if set, holds the data of pointer golden files.
+	blobStorage Storage
+	// storage, if set, holds golden files instead of the file system.
+	storage Storage
+	// missingAsEmpty makes golden files that do not exist compare as empty.
+	missingAsEmpty bool
+	// debug logs how golden files are resolved, read and written.
+	debug bool
+	// prefix is prepended to relative golden file paths.
+	prefix string
+	// prune makes CompareAll handle stale golden files in pruneDirs, and
+	// CompareDir remove them.
+	prune     bool
+	pruneDirs []string
+	// includeFiles and excludeFiles list glob patterns selecting the files
+	// that CompareDir compares.
+	includeFiles, excludeFiles []string
+	// fileMetadata selects the metadata of files that CompareDir compares.
+	fileMetadata FileMetadata
+	// ctx, if set, bounds reads, hashing and Storage operations. It is set
+	// by CompareContext and CheckContext.
+	ctx context.Context
+	// maxSize is the size of actual data above which comparisons fail, or 0
+	// for defaultMaxSize. allowLarge lifts the limit.
+	maxSize    int64
+	allowLarge bool
+	// hexDiff adds a hexdump diff to mismatches of binary data.
+	hexDiff bool
+	// decompress makes the comparison decompress gzip and zlib data.
+	decompress bool
+}
+
+var defaultOptions struct {
+	sync.Mutex
+	opts []Option
+}
+
+// SetDefaultOptions sets options applied to every comparison before the
+// options passed to it, so that a package can set up its normalizers,
+// scrubbers and other options once, typically in TestMain:
+//
+//     func TestMain(m *testing.M) {
+//       golden.SetDefaultOptions(golden.WithNormalizer(golden.ScrubLogs), golden.WithUpdateCommand("make golden"))
+//       os.Exit(m.Run())
+//     }
+//
+// Options passed to a comparison add to the default ones or override them,
+// as if they came later in the same list. Each call replaces the previous
+// default options; calling SetDefaultOptions without arguments clears them.
+func SetDefaultOptions(opts ...Option) {
+	defaultOptions.Lock()
+	defer defaultOptions.Unlock()
+	defaultOptions.opts = append([]Option(nil), opts...)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	defaultOptions.Lock()
+	defaults := defaultOptions.opts
+	defaultOptions.Unlock()
+	for _, opt := range defaults {
+		opt(o)
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// normalize rewrites golden or actual data as configured before they are
+// compared.
+func (o *options) normalize(s string) string {
+	if len(o.ignoreLines) > 0 {
+		s = dropMatchingLines(s, o.ignoreLines)
+	}
+	for _, n := range o.normalizers {
+		s = n(s)
+	}
+	return s
+}
+
+// formatForWrite rewrites actual data as configured before it is written to
+// a golden file.
+func (o *options) formatForWrite(s string) string {
+	for _, f := range o.writeFormatters {
+		s = f(s)
+	}
+	return s
+}
+
+// updateCommandOrDefault returns the command that failure messages should
+// tell the user to run to update golden files.
+func (o *options) updateCommandOrDefault() string {
+	if o.updateCommand != "" {
+		return o.updateCommand
+	}
+	return formatUpdateCommand()
+}
+
+// withCanonicalizer makes the comparison run canonicalize on both the golden
+// and the actual data first. Updates still write the raw actual data.
+func withCanonicalizer(canonicalize func(string) (string, error)) Option {
+	return func(o *options) {
+		o.canonicalize = canonicalize
+	}
+}
+
+// WithPrefix makes relative golden file paths relative to prefix, such as
+// "github.com/acme/tool/internal/gen/testdata", so that each comparison can
+// name its golden file briefly, as in "case1.golden". Combined with
+// SetDefaultOptions, it sets up a namespace for all the golden files of a
+// package. Paths used as is, such as absolute paths and paths starting with
+// "./", are not prefixed.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// prefixed returns goldenFile with the prefix set by WithPrefix.
+func (o *options) prefixed(goldenFile string) string {
+	if o.prefix == "" || isLiteralPath(goldenFile) {
+		return goldenFile
+	}
+	return path.Join(o.prefix, goldenFile)
+}
+
+// WithDiffer makes Compare describe mismatches with d instead of the default
+// unified diff.
+func WithDiffer(d Differ) Option {
+	return func(o *options) {
+		o.differ = d
+	}
+}
+
+// WithPatienceDiff makes the default unified diff use the patience algorithm,
+// which anchors on lines that occur exactly once in both the golden and the
+// actual data. It produces much more readable hunks when blocks have been
+// reordered, as is common in generated code.
+func WithPatienceDiff() Option {
+	return func(o *options) {
+		o.patience = true
+	}
+}
+
+// WithIgnoreLines drops every line matching any of patterns from both the
+// golden and the actual data before they are compared. This is useful for
+// lines that legitimately change on every run, such as "Generated at:"
+// timestamps or build IDs. The patterns are matched against lines without
+// their trailing newline. Updating a golden file still writes the actual
+// data unchanged.
+func WithIgnoreLines(patterns ...*regexp.Regexp) Option {
+	return func(o *options) {
+		o.ignoreLines = append(o.ignoreLines, patterns...)
+	}
+}
+
+// WithNormalizer runs normalizers in order on both the golden and the actual
+// data before they are compared, after any lines dropped by WithIgnoreLines.
+// Updating a golden file still writes the actual data unchanged.
+func WithNormalizer(normalizers ...Normalizer) Option {
+	return func(o *options) {
+		o.normalizers = append(o.normalizers, normalizers...)
+	}
+}
+
+// WithMetadataHeader makes updates write a metadata header at the top of the
+// golden file, recording when it was last updated and with which Go version.
+// See WithMetadata for adding entries of your own. Metadata headers are always
+// stripped from golden files before comparison, whether or not this option is
+// passed.
+func WithMetadataHeader() Option {
+	return func(o *options) {
+		o.metadataHeader = true
+	}
+}
+
+// WithMetadata adds an entry such as the generating test's name or the
+// version of the tool under test to the metadata header. It implies
+// WithMetadataHeader.
+func WithMetadata(key, value string) Option {
+	return func(o *options) {
+		o.metadataHeader = true
+		o.metadata = append(o.metadata, metadataEntry{key, value})
+	}
+}
+
+// WithUTF16 transcodes golden files that start with a UTF-16 byte order mark
+// to UTF-8 before comparing them. Updating such a golden file writes it as
+// UTF-8. A UTF-8 byte order mark is always ignored, whether or not this
+// option is passed.
+func WithUTF16() Option {
+	return func(o *options) {
+		o.utf16 = true
+	}
+}
+
+// WithTestMetadata adds the name of the test function that last updated the
+// golden file, such as "TestRender", to the metadata header, so that the test
+// owning a golden file can be found from the file alone. The test is found on
+// the call stack; use WithMetadata("test", t.Name()) instead to record the
+// full name of a subtest. It implies WithMetadataHeader.
+func WithTestMetadata() Option {
+	return func(o *options) {
+		o.metadataHeader = true
+		o.testMetadata = true
+	}
+}
+
+// WithJSONRecord appends a line holding a JSON record of the mismatch to the
+// failure message, such as
+//
+//     golden-mismatch: {"test":"TestRender","golden":"testdata/out.golden",...}
+//
+// so that tools reading the output of go test -json can report golden
+// mismatches without parsing the diff. The record gives the golden file, the
+// line, column and byte offset of the first difference and the update
+// command. Setting the GOLDEN_JSON_RECORD environment variable enables it for
+// all comparisons.
+func WithJSONRecord() Option {
+	return func(o *options) {
+		o.jsonRecord = true
+	}
+}
+
+// WithEnvironmentFingerprint appends a line describing the environment to
+// the failure message, such as
+//
+//     Environment: linux/amd64, go1.22.1, TZ=UTC (+00:00), locale=C.UTF-8
+//
+// to help tell whether a mismatch reported from CI but not reproducible
+// locally is due to the operating system, the Go version, the time zone or
+// the locale. Setting the GOLDEN_FINGERPRINT environment variable enables it
+// for all comparisons.
+func WithEnvironmentFingerprint() Option {
+	return func(o *options) {
+		o.fingerprint = true
+	}
+}
+
+// WithUpdateCommand sets the command that the failure message tells the user
+// to run to update golden files, for example when tests are run through a
+// wrapper Makefile. See SetUpdateCommand for changing it for all comparisons.
+func WithUpdateCommand(command string) Option {
+	return func(o *options) {
+		o.updateCommand = command
+	}
+}
+
+// WithParallelism lets batch comparisons such as CompareAll check up to n
+// golden files concurrently. The default is to check them one at a time, or
+// GOMAXPROCS at a time for CompareDir.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// WithPrune makes CompareAll also check the golden files directly in dirs,
+// which are resolved like the golden files it is given and must not hold
+// those of other tests: golden files there that it is not given have no
+// actual data any more, such as the outputs a code generator stopped
+// emitting, and are reported as mismatches. With -update_golden, they are
+// removed instead.
+//
+// It also lets CompareDir remove the golden files under its directory that
+// have no counterpart, which it otherwise only reports. CompareDir ignores
+// dirs.
+func WithPrune(dirs ...string) Option {
+	return func(o *options) {
+		o.prune = true
+		o.pruneDirs = append(o.pruneDirs, dirs...)
+	}
+}
+
+// WithIncludeFiles makes CompareDir only compare the files matching one of
+// the glob patterns, in the syntax of path.Match. A pattern without a slash,
+// such as "*.go", matches file names; one with slashes, such as "api/*.json",
+// matches paths relative to the compared directories.
+func WithIncludeFiles(patterns ...string) Option {
+	return func(o *options) {
+		o.includeFiles = append(o.includeFiles, patterns...)
+	}
+}
+
+// WithExcludeFiles makes CompareDir skip the files and directories matching
+// one of the glob patterns, such as ".DS_Store", "*.tmp" or "node_modules".
+// Patterns are matched as for WithIncludeFiles, except that a pattern
+// without a slash also matches the name of any directory on the path of a
+// file. Excluded files are neither compared, created nor removed.
+func WithExcludeFiles(patterns ...string) Option {
+	return func(o *options) {
+		o.excludeFiles = append(o.excludeFiles, patterns...)
+	}
+}
+
+// WithDenyList makes updates fail instead of writing actual data matching any
+// of patterns, to keep secrets such as API keys out of golden files. Pass
+// DefaultDenyList to guard against common kinds of secrets.
+func WithDenyList(patterns ...*regexp.Regexp) Option {
+	return func(o *options) {
+		o.denyList = append(o.denyList, patterns...)
+	}
+}
+
+// WithReportOnly makes mismatches report only which golden file differs and
+// by how many lines, without echoing any of its contents. This suits golden
+// files holding sensitive or enormous data, whose diff should be inspected
+// out of band.
+func WithReportOnly() Option {
+	return func(o *options) {
+		o.reportOnly = true
+	}
+}
+
+// WithFullContents appends the complete golden and actual data, clearly
+// delimited, to failure messages. For short golden files, this can be easier
+// to read than a diff. It has no effect together with WithReportOnly.
+func WithFullContents() Option {
+	return func(o *options) {
+		o.fullContents = true
+	}
+}
+
+// WithBlobStorage sets where the data of pointer golden files is kept. By
+// default it is kept in the directory named by the GOLDEN_BLOB_DIR
+// environment variable.
+func WithBlobStorage(s Storage) Option {
+	return func(o *options) {
+		o.blobStorage = s
+	}
+}
+
+// WithStorage makes golden files be read from and updated in s instead of
+// the file system, with the golden file name used as the key. This lets
+// teams share golden files between repositories, or keep golden files for
+// enormous datasets out of source control. See HTTPStorage and GCSStorage.
+func WithStorage(s Storage) Option {
+	return func(o *options) {
+		o.storage = s
+	}
+}
+
+// WithIgnoreGoComments makes CompareGoSource ignore comments, for code
+// generators whose comments are not worth pinning down.
+func WithIgnoreGoComments() Option {
+	return func(o *options) {
+		o.ignoreGoComments = true
+	}
+}
+
+// WithJSONExclude makes CompareJSON remove the values at paths such as
+// "$.metadata.creationTimestamp" or "items[*].id" from both the golden and
+// the actual document, and from the actual document before it is written as
+// the golden file. Paths consist of object keys, which can also be written
+// as ['key'], array indices such as [0], and wildcards, written * or [*],
+// matching every key or index.
+func WithJSONExclude(paths ...string) Option {
+	return func(o *options) {
+		o.jsonExclude = append(o.jsonExclude, paths...)
+	}
+}
+
+// WithJSONMask is like WithJSONExclude, but replaces the values with
+// "<masked>" instead of removing them, so that the golden file still shows
+// that they are present.
+func WithJSONMask(paths ...string) Option {
+	return func(o *options) {
+		o.jsonMask = append(o.jsonMask, paths...)
+	}
+}
+
+// WithUnorderedRows makes CompareRows sort the rows of a result set before
+// comparing them, for queries without an ORDER BY clause. Golden files are
+// written with sorted rows too.
+func WithUnorderedRows() Option {
+	return func(o *options) {
+		o.unorderedRows = true
+	}
+}
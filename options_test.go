@@ -0,0 +1,86 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetDefaultOptions(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	Compare("a\n", "a.golden")
+	env.SetUpdating(false)
+
+	SetDefaultOptions(WithNormalizer(strings.ToLower), WithUpdateCommand("make golden"))
+	defer SetDefaultOptions()
+	var tests = []struct {
+		desc   string
+		actual string
+		opts   []Option
+		want   string
+	}{
+		{desc: "default normalizer", actual: "A\n"},
+		{desc: "default update command", actual: "b\n", want: `run "make golden" to update`},
+		{desc: "overridden update command", actual: "b\n", opts: []Option{WithUpdateCommand("make all")}, want: `run "make all" to update`},
+		{desc: "added normalizer", actual: " A\n", opts: []Option{WithNormalizer(strings.TrimSpace)}},
+	}
+	for _, test := range tests {
+		got := Compare(test.actual, "a.golden", test.opts...)
+		if test.want == "" && got != "" || !strings.Contains(got, test.want) {
+			t.Errorf("%v: got %q, want a message containing %q", test.desc, got, test.want)
+		}
+	}
+
+	SetDefaultOptions()
+	if got := Compare("A\n", "a.golden"); got == "" {
+		t.Errorf("Compare after clearing the default options: got no diff")
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	env := TestEnv(t)
+	if err := os.MkdirAll(env.Path("acme/testdata"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	prefix := WithPrefix("acme/testdata")
+	env.SetUpdating(true)
+	Compare("a\n", "case1.golden", prefix)
+	CompareFunc(func() (string, error) { return "b\n", nil }, "case2.golden", prefix)
+	env.SetUpdating(false)
+
+	for _, name := range []string{"case1.golden", "case2.golden"} {
+		if _, err := os.Stat(env.Path("acme/testdata/" + name)); err != nil {
+			t.Errorf("prefixed golden file %v: %v", name, err)
+		}
+	}
+	var tests = []struct {
+		desc string
+		got  string
+	}{
+		{"Compare", Compare("a\n", "case1.golden", prefix)},
+		{"CompareFunc", CompareFunc(func() (string, error) { return "b\n", nil }, "case2.golden", prefix)},
+		{"Check", Check("a\n", "case1.golden", prefix).String()},
+		{"Compare of the full path", Compare("a\n", "acme/testdata/case1.golden")},
+		{"Compare of a literal path", Compare("a\n", env.Path("acme/testdata/case1.golden"), prefix)},
+	}
+	for _, test := range tests {
+		if test.got != "" {
+			t.Errorf("%v: got %q, want no diff", test.desc, test.got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var patchDir = newStringFlag("golden_patch_dir", "", "If set, each mismatching golden file gets a patch under this directory that updates it when applied with git apply from the root of the repository, or with golden apply.")
+
+// patchLines splits s after each newline. Unlike splitLines, it leaves a
+// missing final newline missing, since patches must record it.
+func patchLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// formatPatch returns a patch in git's format that turns the file at path,
+// relative to the root of the repository, from previous into updated.
+func formatPatch(path string, previous, updated string) string {
+	a, b := patchLines(previous), patchLines(updated)
+	codes := opCodesFromMatches(sequenceMatches(a, b), len(a), len(b))
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	writeLine := func(prefix string, line string) {
+		buf.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			buf.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	for _, g := range groupOpCodes(codes, 3) {
+		first, last := g[0], g[len(g)-1]
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", formatRangeUnified(first.i1, last.i2), formatRangeUnified(first.j1, last.j2))
+		for _, c := range g {
+			if c.tag == 'e' {
+				for _, line := range a[c.i1:c.i2] {
+					writeLine(" ", line)
+				}
+				continue
+			}
+			for _, line := range a[c.i1:c.i2] {
+				writeLine("-", line)
+			}
+			for _, line := range b[c.j1:c.j2] {
+				writeLine("+", line)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// formatCreationPatch returns a patch in git's format that creates the file
+// at path, relative to the root of the repository, with contents.
+func formatCreationPatch(path string, contents string) string {
+	header := fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n", path, path, path)
+	hunks := strings.TrimPrefix(formatPatch(path, "", contents), header)
+	return fmt.Sprintf("diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n", path, path) + hunks
+}
+
+// savePatch writes the patch updating the golden file of r with the actual
+// data under the -golden_patch_dir directory, and returns where it went.
+// Golden files kept in a Storage, behind a pointer or compressed with gzip
+// cannot be updated by a text patch, so none is written for them.
+func (r Result) savePatch(previous string) (string, error) {
+	if r.o.storage != nil || isPointerGolden(r.goldenFile) || isGzipGolden(r.goldenFile) {
+		return "", nil
+	}
+	updated := goldenContents(r.goldenFile, previous, r.actual, r.o)
+	if updated == previous && !r.missing {
+		return "", nil
+	}
+	target := r.displayPath
+	if r.missing {
+		// A missing golden file has no path to display yet; the patch
+		// creates it where updating would.
+		goldenPath, err := getFullPathForWrite(r.goldenFile)
+		if err != nil {
+			return "", err
+		}
+		target = displayPath(r.goldenFile, goldenPath, r.o)
+	}
+	// Cleaning the path as if it were absolute keeps the patch inside the
+	// directory.
+	fullPath := filepath.Join(patchDir.Load(), filepath.FromSlash(filepath.Clean("/"+target))+".patch")
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0770); err != nil {
+		return "", err
+	}
+	patch := formatPatch(target, previous, updated)
+	if r.missing {
+		patch = formatCreationPatch(target, updated)
+	}
+	return fullPath, ioutil.WriteFile(fullPath, []byte(patch), 0660)
+}
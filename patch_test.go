@@ -0,0 +1,98 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatPatch(t *testing.T) {
+	tests := []struct {
+		previous, updated string
+		want              string
+	}{
+		{
+			"a\nb\nc\n", "a\nB\nc\n",
+			"diff --git a/x.golden b/x.golden\n--- a/x.golden\n+++ b/x.golden\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"a\nb", "a\nb\n",
+			"diff --git a/x.golden b/x.golden\n--- a/x.golden\n+++ b/x.golden\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			"", "new\n",
+			"diff --git a/x.golden b/x.golden\n--- a/x.golden\n+++ b/x.golden\n@@ -0,0 +1 @@\n+new\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := formatPatch("x.golden", tt.previous, tt.updated); got != tt.want {
+			t.Errorf("formatPatch(%q, %q):\ngot  %q\nwant %q", tt.previous, tt.updated, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSavesPatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	original := patchDir.Load()
+	defer func() { patchDir.Store(original) }()
+	patchDir.Store(filepath.Join(dir, "patches"))
+
+	goldenFile := filepath.Join(dir, "out.golden")
+	if err := ioutil.WriteFile(goldenFile, []byte("same\nold\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := Check("same\nnew\n", goldenFile)
+	wantPath := filepath.Join(patchDir.Load(), filepath.Clean("/"+goldenFile)+".patch")
+	if want := "Patch updating the golden file saved to " + wantPath + "\n"; !strings.HasSuffix(r.String(), want) {
+		t.Errorf("String(): got %q, want suffix %q", r.String(), want)
+	}
+	patch, err := ioutil.ReadFile(wantPath)
+	if err != nil {
+		t.Fatalf("Cannot read patch: %v", err)
+	}
+	if want := "@@ -1,2 +1,2 @@\n same\n-old\n+new\n"; !strings.HasSuffix(string(patch), want) {
+		t.Errorf("patch: got %q, want suffix %q", patch, want)
+	}
+
+	// Equal data gets no patch.
+	os.RemoveAll(patchDir.Load())
+	if r := Check("same\nold\n", goldenFile); !r.Equal() {
+		t.Fatalf("Check with equal data: %v", r)
+	}
+	if _, err := os.Stat(patchDir.Load()); !os.IsNotExist(err) {
+		t.Errorf("patch directory created for equal data")
+	}
+
+	// A missing golden file gets a patch creating it.
+	newFile := filepath.Join(dir, "new.golden")
+	if r := Check("new\n", newFile, WithMissingAsEmpty()); r.Equal() {
+		t.Fatalf("Check with a missing golden file: %v", r)
+	}
+	patch, err = ioutil.ReadFile(filepath.Join(patchDir.Load(), filepath.Clean("/"+newFile)+".patch"))
+	if err != nil {
+		t.Fatalf("Cannot read patch: %v", err)
+	}
+	if want := "new file mode 100644\n--- /dev/null\n+++ b/" + newFile + "\n@@ -0,0 +1 @@\n+new\n"; !strings.HasSuffix(string(patch), want) {
+		t.Errorf("patch creating a golden file: got %q, want suffix %q", patch, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pointerGoldenSuffix marks golden files that only point to their data,
+// which is kept in a Storage under the key named in the pointer.
+const pointerGoldenSuffix = ".golden.ptr"
+
+// pointerVersion is the first line of every pointer golden file.
+const pointerVersion = "golden-pointer v1"
+
+func isPointerGolden(goldenFile string) bool {
+	return strings.HasSuffix(goldenFile, pointerGoldenSuffix)
+}
+
+// blobStorageOrDefault returns the storage set with WithBlobStorage, or a
+// DirStorage for the GOLDEN_BLOB_DIR environment variable.
+func (o *options) blobStorageOrDefault() (Storage, error) {
+	if o.blobStorage != nil {
+		return o.blobStorage, nil
+	}
+	if dir := os.Getenv("GOLDEN_BLOB_DIR"); dir != "" {
+		return DirStorage(dir), nil
+	}
+	return nil, fmt.Errorf("no blob storage; use WithBlobStorage or set GOLDEN_BLOB_DIR")
+}
+
+// blobKey returns the key that data is stored under.
+func blobKey(data string) string {
+	return sumKey(sha256.Sum256([]byte(data)))
+}
+
+// sumKey returns the key of data with the given SHA-256 digest.
+func sumKey(sum [sha256.Size]byte) string {
+	hex := fmt.Sprintf("%x", sum)
+	return "sha256/" + hex[:2] + "/" + hex
+}
+
+// formatPointer returns the contents of a pointer golden file for data.
+func formatPointer(data string) string {
+	return fmt.Sprintf("%v\nkey %v\nsize %d\n", pointerVersion, blobKey(data), len(data))
+}
+
+// readPointer returns the data that the pointer golden file contents refer
+// to, and checks that it has not been corrupted.
+func readPointer(pointer string, o *options) (string, error) {
+	var key string
+	var size int
+	if _, err := fmt.Sscanf(pointer, pointerVersion+"\nkey %s\nsize %d\n", &key, &size); err != nil {
+		return "", fmt.Errorf("malformed pointer: %v", err)
+	}
+	storage, err := o.blobStorageOrDefault()
+	if err != nil {
+		return "", err
+	}
+	data, err := storageRead(o.context(), storage, key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) != size {
+		return "", fmt.Errorf("blob %v is corrupt", key)
+	}
+	sum, err := hashContext(o.context(), string(data))
+	if err != nil {
+		return "", err
+	}
+	if sumKey(sum) != key {
+		return "", fmt.Errorf("blob %v is corrupt", key)
+	}
+	return string(data), nil
+}
+
+// writePointerBlob stores actual so that the pointer golden file written for
+// it can be resolved.
+func writePointerBlob(actual string, o *options) error {
+	storage, err := o.blobStorageOrDefault()
+	if err != nil {
+		return err
+	}
+	return storageWrite(o.context(), storage, blobKey(actual), []byte(actual))
+}
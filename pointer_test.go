@@ -0,0 +1,102 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestFormatPointer(t *testing.T) {
+	got := formatPointer("hello\n")
+	want := `golden-pointer v1
+key sha256/58/5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03
+size 6
+`
+	if got != want {
+		t.Errorf("formatPointer: got %q want %q", got, want)
+	}
+}
+
+func TestComparePointerGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	storage := DirStorage(path.Join(dir, "blobs"))
+	goldenPath := path.Join(dir, "src/fake/testdata/big.bin.golden.ptr")
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	Compare("hello\n", "fake/testdata/big.bin.golden.ptr", WithBlobStorage(storage))
+	restoreFunc()
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != formatPointer("hello\n") {
+		t.Errorf("updated pointer golden: got %q, want %q", got, formatPointer("hello\n"))
+	}
+
+	defer setGoPathForTest(dir)()
+	var tests = []struct {
+		desc   string
+		actual string
+		want   string
+	}{
+		{
+			desc:   "same data",
+			actual: "hello\n",
+			want:   "",
+		},
+		{
+			desc:   "different data",
+			actual: "goodbye\n",
+			want: `--- fake/testdata/big.bin.golden.ptr
++++ fake/testdata/big.bin.actual
+@@ -1,2 +1,2 @@
+-hello
++goodbye
+ 
+`,
+		},
+	}
+	for _, test := range tests {
+		got := Compare(test.actual, "fake/testdata/big.bin.golden.ptr", WithBlobStorage(storage))
+		if !strings.HasSuffix(got, test.want) || (got == "") != (test.want == "") {
+			t.Errorf("%v: got %q, want a diff ending in %q", test.desc, got, test.want)
+		}
+	}
+
+	if err := storage.Write(blobKey("hello\n"), []byte("tampered\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := Check("hello\n", "fake/testdata/big.bin.golden.ptr", WithBlobStorage(storage)).Err(); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("Check with a corrupt blob: got %v, want a corruption error", err)
+	}
+}
+
+func TestReadPointerErrors(t *testing.T) {
+	o := newOptions([]Option{WithBlobStorage(DirStorage(os.TempDir()))})
+	if _, err := readPointer("not a pointer\n", o); err == nil {
+		t.Errorf("readPointer of a malformed pointer: got nil error")
+	}
+	defer setenvForTest(map[string]string{"GOLDEN_BLOB_DIR": ""})()
+	if _, err := readPointer(formatPointer("x"), newOptions(nil)); err == nil {
+		t.Errorf("readPointer without storage: got nil error")
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/golden"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CompareDescriptors compares the proto files in set, rendered with
+// RenderFiles, to the contents of goldenFile. Checking the descriptors of an
+// API into a golden file makes changes to it visible in review, and lets CI
+// catch accidental breaking changes such as renumbered fields:
+//
+//     if diff := protogolden.CompareDescriptors(set, ".../testdata/api.golden"); diff != "" {
+//       t.Error(diff)
+//     }
+//
+// set must be complete: every file imported by one of its files must be in
+// it too, as with protoc --include_imports.
+func CompareDescriptors(set *descriptorpb.FileDescriptorSet, goldenFile string, opts ...golden.Option) string {
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return golden.Fatalf("Error while rendering descriptors: %v", err)
+	}
+	return golden.Compare(RenderFiles(files), goldenFile, opts...)
+}
+
+// CompareRegistry is like CompareDescriptors for the files in a registry,
+// such as protoregistry.GlobalFiles filtered to the packages of an API.
+func CompareRegistry(files *protoregistry.Files, goldenFile string, opts ...golden.Option) string {
+	return golden.Compare(RenderFiles(files), goldenFile, opts...)
+}
+
+// RenderFiles renders the files in a registry as a stable listing of their
+// declarations, one per line. Files are listed by path, and their messages,
+// enums, services and extensions by full name, nested messages and enums
+// included, so that reordering declarations changes nothing. Fields are
+// listed by number and enum values by number, then name:
+//
+//     file acme/users.proto
+//       package acme
+//       import google/protobuf/timestamp.proto
+//       enum acme.Role
+//         value 0 ROLE_UNSPECIFIED
+//       message acme.User
+//         field 1 name string
+//         field 2 emails repeated string
+//         field 3 created google.protobuf.Timestamp
+//         reserved 4 to 5
+//       service acme.Users
+//         rpc Get(acme.GetUserRequest) returns (acme.User)
+func RenderFiles(files *protoregistry.Files) string {
+	var fds []protoreflect.FileDescriptor
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		fds = append(fds, fd)
+		return true
+	})
+	sort.Slice(fds, func(i, j int) bool { return fds[i].Path() < fds[j].Path() })
+	b := &strings.Builder{}
+	for _, fd := range fds {
+		renderFile(b, fd)
+	}
+	return b.String()
+}
+
+// A declaration is a rendered top-level or nested declaration of a file.
+type declaration struct {
+	name protoreflect.FullName
+	text string
+}
+
+func renderFile(b *strings.Builder, fd protoreflect.FileDescriptor) {
+	fmt.Fprintf(b, "file %v\n", fd.Path())
+	if fd.Package() != "" {
+		fmt.Fprintf(b, "  package %v\n", fd.Package())
+	}
+	var imports []string
+	for i := 0; i < fd.Imports().Len(); i++ {
+		imp := fd.Imports().Get(i)
+		kind := ""
+		switch {
+		case imp.IsPublic:
+			kind = " public"
+		case imp.IsWeak:
+			kind = " weak"
+		}
+		imports = append(imports, fmt.Sprintf("  import %v%v\n", imp.Path(), kind))
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		b.WriteString(imp)
+	}
+
+	var decls []declaration
+	var addMessages func(protoreflect.MessageDescriptors)
+	addEnums := func(enums protoreflect.EnumDescriptors) {
+		for i := 0; i < enums.Len(); i++ {
+			decls = append(decls, declaration{enums.Get(i).FullName(), renderEnum(enums.Get(i))})
+		}
+	}
+	addMessages = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			md := messages.Get(i)
+			if md.IsMapEntry() {
+				continue
+			}
+			decls = append(decls, declaration{md.FullName(), renderMessage(md)})
+			addMessages(md.Messages())
+			addEnums(md.Enums())
+			for j := 0; j < md.Extensions().Len(); j++ {
+				decls = append(decls, declaration{md.Extensions().Get(j).FullName(), renderExtension(md.Extensions().Get(j))})
+			}
+		}
+	}
+	addMessages(fd.Messages())
+	addEnums(fd.Enums())
+	for i := 0; i < fd.Services().Len(); i++ {
+		decls = append(decls, declaration{fd.Services().Get(i).FullName(), renderService(fd.Services().Get(i))})
+	}
+	for i := 0; i < fd.Extensions().Len(); i++ {
+		decls = append(decls, declaration{fd.Extensions().Get(i).FullName(), renderExtension(fd.Extensions().Get(i))})
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].name < decls[j].name })
+	for _, d := range decls {
+		b.WriteString(d.text)
+	}
+}
+
+func renderMessage(md protoreflect.MessageDescriptor) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "  message %v\n", md.FullName())
+	fields := make([]protoreflect.FieldDescriptor, md.Fields().Len())
+	for i := range fields {
+		fields[i] = md.Fields().Get(i)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number() < fields[j].Number() })
+	for _, f := range fields {
+		fmt.Fprintf(b, "    field %d %v %v", f.Number(), f.Name(), fieldType(f))
+		if oneof := f.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			fmt.Fprintf(b, " oneof %v", oneof.Name())
+		}
+		b.WriteString("\n")
+	}
+	renderReserved(b, md.ReservedRanges().Len(), func(i int) (int, int) {
+		r := md.ReservedRanges().Get(i)
+		// Message ranges are exclusive of their end.
+		return int(r[0]), int(r[1]) - 1
+	}, md.ReservedNames())
+	for i := 0; i < md.ExtensionRanges().Len(); i++ {
+		r := md.ExtensionRanges().Get(i)
+		fmt.Fprintf(b, "    extensions %v\n", formatRange(int(r[0]), int(r[1])-1))
+	}
+	return b.String()
+}
+
+// fieldType describes the type and cardinality of f.
+func fieldType(f protoreflect.FieldDescriptor) string {
+	if f.IsMap() {
+		return fmt.Sprintf("map<%v, %v>", kindName(f.MapKey()), kindName(f.MapValue()))
+	}
+	switch {
+	case f.Cardinality() == protoreflect.Repeated:
+		return "repeated " + kindName(f)
+	case f.Cardinality() == protoreflect.Required:
+		return "required " + kindName(f)
+	case f.HasOptionalKeyword():
+		return "optional " + kindName(f)
+	}
+	return kindName(f)
+}
+
+// kindName names the type of the values of f.
+func kindName(f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.Message() != nil:
+		return string(f.Message().FullName())
+	case f.Enum() != nil:
+		return string(f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
+
+func renderEnum(ed protoreflect.EnumDescriptor) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "  enum %v\n", ed.FullName())
+	values := make([]protoreflect.EnumValueDescriptor, ed.Values().Len())
+	for i := range values {
+		values[i] = ed.Values().Get(i)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Number() != values[j].Number() {
+			return values[i].Number() < values[j].Number()
+		}
+		return values[i].Name() < values[j].Name()
+	})
+	for _, v := range values {
+		fmt.Fprintf(b, "    value %d %v\n", v.Number(), v.Name())
+	}
+	renderReserved(b, ed.ReservedRanges().Len(), func(i int) (int, int) {
+		r := ed.ReservedRanges().Get(i)
+		// Enum ranges are inclusive of their end.
+		return int(r[0]), int(r[1])
+	}, ed.ReservedNames())
+	return b.String()
+}
+
+// renderReserved writes the n reserved number ranges returned by get, from
+// first to last inclusive, and the reserved names.
+func renderReserved(b *strings.Builder, n int, get func(int) (first, last int), names protoreflect.Names) {
+	var lines []string
+	for i := 0; i < n; i++ {
+		first, last := get(i)
+		lines = append(lines, fmt.Sprintf("    reserved %v\n", formatRange(first, last)))
+	}
+	for i := 0; i < names.Len(); i++ {
+		lines = append(lines, fmt.Sprintf("    reserved %q\n", names.Get(i)))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+func formatRange(first, last int) string {
+	if first == last {
+		return fmt.Sprint(first)
+	}
+	return fmt.Sprintf("%d to %d", first, last)
+}
+
+func renderService(sd protoreflect.ServiceDescriptor) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "  service %v\n", sd.FullName())
+	methods := make([]protoreflect.MethodDescriptor, sd.Methods().Len())
+	for i := range methods {
+		methods[i] = sd.Methods().Get(i)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+	stream := func(streaming bool) string {
+		if streaming {
+			return "stream "
+		}
+		return ""
+	}
+	for _, m := range methods {
+		fmt.Fprintf(b, "    rpc %v(%v%v) returns (%v%v)\n", m.Name(),
+			stream(m.IsStreamingClient()), m.Input().FullName(), stream(m.IsStreamingServer()), m.Output().FullName())
+	}
+	return b.String()
+}
+
+func renderExtension(xd protoreflect.ExtensionDescriptor) string {
+	return fmt.Sprintf("  extend %v field %d %v %v\n", xd.ContainingMessage().FullName(), xd.Number(), xd.FullName(), fieldType(xd))
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func testDescriptorSet() *descriptorpb.FileDescriptorSet {
+	field := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label.Enum(), Type: typ.Enum(), JsonName: proto.String(name)}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional, repeated := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	users := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("acme/users.proto"),
+		Package:    proto.String("acme"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("User"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("created", 3, optional, msg, ".google.protobuf.Timestamp"),
+				field("name", 1, optional, str, ""),
+				field("emails", 2, repeated, str, ""),
+				field("role", 6, optional, descriptorpb.FieldDescriptorProto_TYPE_ENUM, ".acme.Role"),
+			}, ReservedRange: []*descriptorpb.DescriptorProto_ReservedRange{{Start: proto.Int32(4), End: proto.Int32(6)}},
+				ReservedName: []string{"nickname"}},
+			{Name: proto.String("GetUserRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, optional, str, "")}},
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{Name: proto.String("Role"), Value: []*descriptorpb.EnumValueDescriptorProto{
+			{Name: proto.String("ROLE_UNSPECIFIED"), Number: proto.Int32(0)},
+			{Name: proto.String("ADMIN"), Number: proto.Int32(2)},
+			{Name: proto.String("OWNER"), Number: proto.Int32(1)},
+		}}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{Name: proto.String("Users"), Method: []*descriptorpb.MethodDescriptorProto{
+			{Name: proto.String("Watch"), InputType: proto.String(".acme.GetUserRequest"), OutputType: proto.String(".acme.User"), ServerStreaming: proto.Bool(true)},
+			{Name: proto.String("Get"), InputType: proto.String(".acme.GetUserRequest"), OutputType: proto.String(".acme.User")},
+		}}},
+	}
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		users, protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+	}}
+}
+
+const testDescriptorListing = `file acme/users.proto
+  package acme
+  import google/protobuf/timestamp.proto
+  message acme.GetUserRequest
+    field 1 name string
+  enum acme.Role
+    value 0 ROLE_UNSPECIFIED
+    value 1 OWNER
+    value 2 ADMIN
+  message acme.User
+    field 1 name string
+    field 2 emails repeated string
+    field 3 created google.protobuf.Timestamp
+    field 6 role acme.Role
+    reserved "nickname"
+    reserved 4 to 5
+  service acme.Users
+    rpc Get(acme.GetUserRequest) returns (acme.User)
+    rpc Watch(acme.GetUserRequest) returns (stream acme.User)
+file google/protobuf/timestamp.proto
+  package google.protobuf
+  message google.protobuf.Timestamp
+    field 1 seconds int64
+    field 2 nanos int32
+`
+
+func TestRenderFiles(t *testing.T) {
+	files, err := protodesc.NewFiles(testDescriptorSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := RenderFiles(files); got != testDescriptorListing {
+		t.Errorf("RenderFiles: got %q want %q", got, testDescriptorListing)
+	}
+}
+
+func TestCompareDescriptors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := filepath.Join(dir, "api.golden")
+	if err := ioutil.WriteFile(goldenFile, []byte(testDescriptorListing), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	set := testDescriptorSet()
+	if got := CompareDescriptors(set, goldenFile); got != "" {
+		t.Errorf("CompareDescriptors with the same descriptors: got %q, want no diff", got)
+	}
+	// Renumbering a field is a breaking change.
+	set.File[0].MessageType[0].Field[1].Number = proto.Int32(7)
+	if got := CompareDescriptors(set, goldenFile); !strings.Contains(got, "-    field 1 name string\n") || !strings.Contains(got, "+    field 7 name string\n") {
+		t.Errorf("CompareDescriptors with a renumbered field: got %q", got)
+	}
+
+	files := &protoregistry.Files{}
+	if err := files.RegisterFile(timestamppb.File_google_protobuf_timestamp_proto); err != nil {
+		t.Fatal(err)
+	}
+	if got := CompareRegistry(files, goldenFile); !strings.Contains(got, "-file acme/users.proto\n") {
+		t.Errorf("CompareRegistry with a missing file: got %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/golden"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// CompareRPC compares the outcome of a gRPC call, rendered with RenderRPC,
+// to the contents of goldenFile, as CompareProto does for messages:
+//
+//     resp, err := client.GetUser(ctx, req)
+//     if diff := protogolden.CompareRPC(resp, err, ".../testdata/get_user.golden"); diff != "" {
+//       t.Error(diff)
+//     }
+func CompareRPC(resp proto.Message, err error, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	actual, renderErr := o.renderRPC(resp, err)
+	if renderErr != nil {
+		return golden.Fatalf("Error while rendering RPC outcome: %v", renderErr)
+	}
+	return golden.Compare(actual, goldenFile, o.goldenOptions()...)
+}
+
+// RenderRPC renders the outcome of a gRPC call in a canonical text form:
+// its status code, and either the response or the status message and
+// details. WithoutPaths and WithIgnoreFields apply to the response.
+//
+//     code: NotFound
+//     message: "user 42 not found"
+//     detail {
+//       [type.googleapis.com/google.rpc.ErrorInfo]: {
+//         reason: "USER_NOT_FOUND"
+//       }
+//     }
+func RenderRPC(resp proto.Message, err error, opts ...Option) (string, error) {
+	return newOptions(opts).renderRPC(resp, err)
+}
+
+func (o *options) renderRPC(resp proto.Message, err error) (string, error) {
+	b := &strings.Builder{}
+	s := status.Convert(err)
+	fmt.Fprintf(b, "code: %v\n", s.Code())
+	if err != nil {
+		fmt.Fprintf(b, "message: %q\n", s.Message())
+		for _, detail := range s.Proto().GetDetails() {
+			writeBlock(b, "detail", Text(detail))
+		}
+		return b.String(), nil
+	}
+	if resp != nil && resp.ProtoReflect().IsValid() {
+		text, err := o.text(resp)
+		if err != nil {
+			return "", err
+		}
+		writeBlock(b, "response", text)
+	}
+	return b.String(), nil
+}
+
+// writeBlock writes text indented within a block called name.
+func writeBlock(b *strings.Builder, name string, text string) {
+	b.WriteString(name + " {\n")
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			b.WriteString("  " + line)
+		}
+	}
+	if !strings.HasSuffix(text, "\n") && text != "" {
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+func TestRenderRPC(t *testing.T) {
+	notFound, err := status.New(codes.NotFound, "user 42 not found").WithDetails(&errdetails.ErrorInfo{Reason: "USER_NOT_FOUND"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		desc string
+		resp *apipb.Api
+		err  error
+		opts []Option
+		want string
+	}{
+		{
+			desc: "response",
+			resp: testAPI(),
+			opts: []Option{WithoutPaths("methods")},
+			want: "code: OK\nresponse {\n  name: \"Users\"\n  version: \"v1\"\n}\n",
+		},
+		{
+			desc: "status error",
+			err:  notFound.Err(),
+			want: `code: NotFound
+message: "user 42 not found"
+detail {
+  [type.googleapis.com/google.rpc.ErrorInfo]: {
+    reason: "USER_NOT_FOUND"
+  }
+}
+`,
+		},
+		{
+			desc: "other error",
+			err:  errors.New("boom"),
+			want: "code: Unknown\nmessage: \"boom\"\n",
+		},
+		{
+			desc: "nothing",
+			want: "code: OK\n",
+		},
+	}
+	for _, test := range tests {
+		got, err := RenderRPC(test.resp, test.err, test.opts...)
+		if err != nil || got != test.want {
+			t.Errorf("%v: got %q, %v, want %q", test.desc, got, err, test.want)
+		}
+	}
+}
+
+func TestCompareRPC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := filepath.Join(dir, "rpc.golden")
+	if err := ioutil.WriteFile(goldenFile, []byte("code: PermissionDenied\nmessage: \"no\"\n"), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	if got := CompareRPC(nil, status.Error(codes.PermissionDenied, "no"), goldenFile); got != "" {
+		t.Errorf("CompareRPC with the same error: got %q, want no diff", got)
+	}
+	if got := CompareRPC(testAPI(), nil, goldenFile); got == "" {
+		t.Errorf("CompareRPC with a response: got no diff")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/google/golden"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// CompareProtoJSON is like CompareProto for golden files holding messages in
+// their JSON form, such as the bodies of REST responses, rendered with JSON.
+// Golden files written by other means, such as by protojson.Marshal, are
+// compared with StableJSON applied first, so that they need not be updated
+// whenever protojson changes its whitespace.
+func CompareProtoJSON(m proto.Message, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	m, err := o.clear(m)
+	if err != nil {
+		return golden.Fatalf("Error while rendering %v: %v", m.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return golden.Compare(JSON(m), goldenFile, append(o.golden, golden.WithNormalizer(StableJSON))...)
+}
+
+// JSON renders m in the JSON format, indented by two spaces with fields in
+// the order of their declaration. Unlike protojson.Marshal, whose output
+// deliberately varies between builds, it always renders a message the same
+// way.
+func JSON(m proto.Message) string {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		// Marshaling only fails on invalid messages, such as ones with
+		// invalid UTF-8 in strings; render what can be rendered.
+		return StableJSON(protojson.Format(m))
+	}
+	return StableJSON(string(data))
+}
+
+// StableJSON is a Normalizer re-indenting a JSON document, such as one
+// written by protojson, by two spaces with a final newline, which undoes
+// protojson's random whitespace without reordering fields. Data that is not
+// valid JSON is returned unchanged.
+func StableJSON(s string) string {
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, []byte(s)); err != nil {
+		return s
+	}
+	buf := &bytes.Buffer{}
+	json.Indent(buf, compact.Bytes(), "", "  ")
+	buf.WriteString("\n")
+	return buf.String()
+}
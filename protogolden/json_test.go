@@ -0,0 +1,90 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestJSON(t *testing.T) {
+	want := `{
+  "name": "Users",
+  "methods": [
+    {
+      "name": "Get",
+      "requestTypeUrl": "type.googleapis.com/GetRequest"
+    },
+    {
+      "name": "List",
+      "requestTypeUrl": "type.googleapis.com/ListRequest"
+    }
+  ],
+  "version": "v1"
+}
+`
+	if got := JSON(testAPI()); got != want {
+		t.Errorf("JSON: got %q want %q", got, want)
+	}
+}
+
+func TestStableJSON(t *testing.T) {
+	var tests = []struct {
+		in, want string
+	}{
+		{`{"a":  1, "b":[ "x:  y" ]}`, "{\n  \"a\": 1,\n  \"b\": [\n    \"x:  y\"\n  ]\n}\n"},
+		{"{\n  \"a\": 1\n}\n", "{\n  \"a\": 1\n}\n"},
+		{"not json", "not json"},
+	}
+	for _, test := range tests {
+		if got := StableJSON(test.in); got != test.want {
+			t.Errorf("StableJSON(%q): got %q want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestCompareProtoJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := filepath.Join(dir, "api.json.golden")
+	// Golden files written with protojson compare equal, whatever its
+	// whitespace.
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(testAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(goldenFile, data, 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	if got := CompareProtoJSON(testAPI(), goldenFile); got != "" {
+		t.Errorf("CompareProtoJSON with the same message: got %q, want no diff", got)
+	}
+	changed := testAPI()
+	changed.Version = "v2"
+	if got := CompareProtoJSON(changed, goldenFile); !strings.Contains(got, "-  \"version\": \"v1\"\n+  \"version\": \"v2\"\n") {
+		t.Errorf("CompareProtoJSON with a changed message: got %q", got)
+	}
+	if got := CompareProtoJSON(changed, goldenFile, WithoutPaths("version")); !strings.Contains(got, "-  \"version\": \"v1\"\n") {
+		t.Errorf("CompareProtoJSON without version: got %q", got)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protogolden compares protocol buffer messages, and the responses
+// and errors of gRPC calls, to golden files holding them in text format, or
+// messages in JSON format.
+//
+//     func TestServer(t *testing.T) {
+//       resp := server.Handle(req)
+//       if diff := protogolden.CompareProto(resp, ".../testdata/resp.textproto.golden",
+//         protogolden.WithoutPaths("metadata.create_time")); diff != "" {
+//         t.Error(diff)
+//       }
+//     }
+package protogolden
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/golden"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// An Option configures how messages are rendered and compared.
+type Option func(*options)
+
+type options struct {
+	paths   []string
+	ignored map[protoreflect.Name]bool
+	golden  []golden.Option
+}
+
+// WithoutPaths clears the fields at the given field mask paths, such as
+// "metadata.create_time", before rendering a message. Paths going through
+// repeated or map fields apply to each of their elements. This keeps
+// inherently volatile fields such as timestamps out of golden files.
+func WithoutPaths(paths ...string) Option {
+	return func(o *options) {
+		o.paths = append(o.paths, paths...)
+	}
+}
+
+// WithIgnoreFields clears every field with one of the given names, such as
+// "latency_ms" or "request_id", wherever it occurs in a message before
+// rendering it. Unlike WithoutPaths, it does not require knowing where the
+// fields are nested, which suits fields that are inherently nondeterministic
+// throughout a schema.
+func WithIgnoreFields(names ...string) Option {
+	return func(o *options) {
+		if o.ignored == nil {
+			o.ignored = map[protoreflect.Name]bool{}
+		}
+		for _, name := range names {
+			o.ignored[protoreflect.Name(name)] = true
+		}
+	}
+}
+
+// WithGoldenOptions passes opts on to golden.Compare.
+func WithGoldenOptions(opts ...golden.Option) Option {
+	return func(o *options) {
+		o.golden = append(o.golden, opts...)
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// goldenOptions returns the options to pass on to golden.Compare.
+func (o *options) goldenOptions() []golden.Option {
+	return append(o.golden, golden.WithNormalizer(stableSpacing))
+}
+
+// text renders m with Text, after clearing the fields excluded by
+// WithoutPaths and WithIgnoreFields.
+func (o *options) text(m proto.Message) (string, error) {
+	m, err := o.clear(m)
+	if err != nil {
+		return "", err
+	}
+	return Text(m), nil
+}
+
+// clear returns m, or a copy of m with the fields excluded by WithoutPaths
+// and WithIgnoreFields cleared.
+func (o *options) clear(m proto.Message) (proto.Message, error) {
+	if len(o.paths) > 0 || len(o.ignored) > 0 {
+		m = proto.Clone(m)
+		for _, p := range o.paths {
+			if err := clearPath(m.ProtoReflect(), strings.Split(p, ".")); err != nil {
+				return m, fmt.Errorf("clearing %q: %v", p, err)
+			}
+		}
+		clearFieldsNamed(m.ProtoReflect(), o.ignored)
+	}
+	return m, nil
+}
+
+// CompareProto compares m, rendered with Text, to the contents of goldenFile
+// and returns an empty string if they match. Otherwise it returns a diff, as
+// golden.Compare does; like golden.Compare, it overwrites goldenFile if the
+// -update_golden flag is set.
+func CompareProto(m proto.Message, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	actual, err := o.text(m)
+	if err != nil {
+		return golden.Fatalf("Error while rendering %v: %v", m.ProtoReflect().Descriptor().FullName(), err)
+	}
+	return golden.Compare(actual, goldenFile, o.goldenOptions()...)
+}
+
+// Text renders m in the multi-line text format. Unlike prototext.Marshal,
+// whose output deliberately varies between builds, it always renders a
+// message the same way.
+func Text(m proto.Message) string {
+	text, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(m)
+	if err != nil {
+		// Marshaling only fails on invalid messages, such as ones with
+		// invalid UTF-8 in strings; render what can be rendered.
+		return prototext.Format(m)
+	}
+	return stableSpacing(string(text))
+}
+
+// randomSpace matches the space that prototext randomly doubles after field
+// names.
+var randomSpace = regexp.MustCompile(`(?m)^(\s*(?:\[[^\]\s]*\]|[\w.]+)):  `)
+
+// stableSpacing undoes the random spacing of prototext output.
+func stableSpacing(s string) string {
+	return randomSpace.ReplaceAllString(s, "$1: ")
+}
+
+// clearPath clears the field at path in m.
+func clearPath(m protoreflect.Message, path []string) error {
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		return fmt.Errorf("%v has no field %q", m.Descriptor().FullName(), path[0])
+	}
+	if len(path) == 1 {
+		m.Clear(fd)
+		return nil
+	}
+	if fd.Message() == nil || (fd.IsMap() && fd.MapValue().Message() == nil) {
+		return fmt.Errorf("field %v is not a message", fd.FullName())
+	}
+	if !m.Has(fd) {
+		return nil
+	}
+	switch {
+	case fd.IsList():
+		l := m.Mutable(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			if err := clearPath(l.Get(i).Message(), path[1:]); err != nil {
+				return err
+			}
+		}
+	case fd.IsMap():
+		var err error
+		m.Mutable(fd).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+			err = clearPath(v.Message(), path[1:])
+			return err == nil
+		})
+		return err
+	default:
+		return clearPath(m.Mutable(fd).Message(), path[1:])
+	}
+	return nil
+}
+
+// clearFieldsNamed clears the fields of m and of the messages nested in it
+// whose names are in names.
+func clearFieldsNamed(m protoreflect.Message, names map[protoreflect.Name]bool) {
+	if len(names) == 0 {
+		return
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case names[fd.Name()]:
+			m.Clear(fd)
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				clearFieldsNamed(l.Get(i).Message(), names)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				clearFieldsNamed(v.Message(), names)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			clearFieldsNamed(v.Message(), names)
+		}
+		return true
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protogolden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/golden"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+func testAPI() *apipb.Api {
+	return &apipb.Api{
+		Name:    "Users",
+		Version: "v1",
+		Methods: []*apipb.Method{
+			{Name: "Get", RequestTypeUrl: "type.googleapis.com/GetRequest"},
+			{Name: "List", RequestTypeUrl: "type.googleapis.com/ListRequest"},
+		},
+	}
+}
+
+func TestText(t *testing.T) {
+	want := `name: "Users"
+methods: {
+  name: "Get"
+  request_type_url: "type.googleapis.com/GetRequest"
+}
+methods: {
+  name: "List"
+  request_type_url: "type.googleapis.com/ListRequest"
+}
+version: "v1"
+`
+	if got := Text(testAPI()); got != want {
+		t.Errorf("Text: got %q want %q", got, want)
+	}
+}
+
+func TestStableSpacing(t *testing.T) {
+	in := "name:  \"a:  b\"\nsub:  {\n  [ext.field]:  1\n  x: 2\n}\n"
+	want := "name: \"a:  b\"\nsub: {\n  [ext.field]: 1\n  x: 2\n}\n"
+	if got := stableSpacing(in); got != want {
+		t.Errorf("stableSpacing(%q): got %q want %q", in, got, want)
+	}
+}
+
+func TestWithoutPaths(t *testing.T) {
+	var tests = []struct {
+		paths   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			paths: []string{"version", "methods.request_type_url"},
+			want:  "name: \"Users\"\nmethods: {\n  name: \"Get\"\n}\nmethods: {\n  name: \"List\"\n}\n",
+		},
+		{
+			paths: []string{"source_context.file_name"},
+			want:  Text(testAPI()),
+		},
+		{
+			paths:   []string{"nonexistent"},
+			wantErr: true,
+		},
+		{
+			paths:   []string{"name.x"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		m := testAPI()
+		got, err := newOptions([]Option{WithoutPaths(test.paths...)}).text(m)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("text with paths %v: got %q, %v, want %q", test.paths, got, err, test.want)
+		}
+		if !strings.Contains(Text(m), "version") {
+			t.Errorf("text with paths %v modified its argument", test.paths)
+		}
+	}
+}
+
+func TestWithIgnoreFields(t *testing.T) {
+	var tests = []struct {
+		names []string
+		want  string
+	}{
+		{
+			names: []string{"name"},
+			want:  "methods: {\n  request_type_url: \"type.googleapis.com/GetRequest\"\n}\nmethods: {\n  request_type_url: \"type.googleapis.com/ListRequest\"\n}\nversion: \"v1\"\n",
+		},
+		{
+			names: []string{"methods", "nonexistent"},
+			want:  "name: \"Users\"\nversion: \"v1\"\n",
+		},
+	}
+	for _, test := range tests {
+		m := testAPI()
+		got, err := newOptions([]Option{WithIgnoreFields(test.names...)}).text(m)
+		if err != nil || got != test.want {
+			t.Errorf("text ignoring %v: got %q, %v, want %q", test.names, got, err, test.want)
+		}
+		if Text(m) != Text(testAPI()) {
+			t.Errorf("text ignoring %v modified its argument", test.names)
+		}
+	}
+}
+
+func TestCompareProto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := filepath.Join(dir, "api.textproto.golden")
+	// Hand-written golden files may use either spacing.
+	goldenText := strings.Replace(Text(testAPI()), "name: ", "name:  ", -1)
+	if err := ioutil.WriteFile(goldenFile, []byte(goldenText), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	if got := CompareProto(testAPI(), goldenFile); got != "" {
+		t.Errorf("CompareProto with the same message: got %q, want no diff", got)
+	}
+	changed := testAPI()
+	changed.Version = "v2"
+	if got := CompareProto(changed, goldenFile); !strings.Contains(got, "-version: \"v1\"\n+version: \"v2\"\n") {
+		t.Errorf("CompareProto with a changed message: got %q", got)
+	}
+	if got := CompareProto(changed, goldenFile, WithoutPaths("version")); !strings.Contains(got, "-version: \"v1\"\n") {
+		t.Errorf("CompareProto without version: got %q", got)
+	}
+	if got := CompareProto(changed, goldenFile, WithIgnoreFields("version"), WithGoldenOptions(golden.WithIgnoreLines(regexp.MustCompile("^version: ")))); got != "" {
+		t.Errorf("CompareProto ignoring version: got %q, want no diff", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Metadata keys marking a golden file as quarantined.
+const (
+	quarantineUntilKey = "quarantined-until"
+	quarantineOwnerKey = "quarantine-owner"
+)
+
+// quarantineDateLayout is the layout of quarantine expiry dates.
+const quarantineDateLayout = "2006-01-02"
+
+// timeNow returns the current time. Tests replace it.
+var timeNow = time.Now
+
+// A quarantine lets a golden file mismatch until it expires.
+type quarantine struct {
+	until time.Time
+	owner string
+}
+
+func (q *quarantine) String() string {
+	return fmt.Sprintf("quarantined until %v by %v", q.until.Format(quarantineDateLayout), q.owner)
+}
+
+// expired reports whether the quarantine is over at now. It lasts until the
+// end of its last day, in UTC.
+func (q *quarantine) expired(now time.Time) bool {
+	return !now.Before(q.until.AddDate(0, 0, 1))
+}
+
+// parseQuarantine returns the quarantine recorded in a metadata header, or
+// nil if there is none.
+func parseQuarantine(header string) (*quarantine, error) {
+	var q *quarantine
+	var owner string
+	for _, e := range parseMetadata(header) {
+		switch e.key {
+		case quarantineUntilKey:
+			until, err := time.Parse(quarantineDateLayout, e.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quarantine date: %v", err)
+			}
+			q = &quarantine{until: until}
+		case quarantineOwnerKey:
+			owner = e.value
+		}
+	}
+	if q != nil {
+		if owner == "" {
+			return nil, fmt.Errorf("quarantine has no owner")
+		}
+		q.owner = owner
+	}
+	return q, nil
+}
+
+// Quarantine marks the golden file at path as expected to mismatch until the
+// end of the given day, recording the person responsible for it in its
+// metadata header. Mismatches with a quarantined golden file are logged but
+// not reported, so that a known flaky or in-flux output can be tracked
+// without deleting its test. Once the quarantine expires, comparisons fail
+// even if the data matches, until the quarantine is lifted with
+// LiftQuarantine or by updating the golden file.
+func Quarantine(path string, until time.Time, owner string) error {
+	if owner == "" {
+		return fmt.Errorf("quarantine needs an owner")
+	}
+	return rewriteQuarantine(path, []metadataEntry{
+		{quarantineUntilKey, until.Format(quarantineDateLayout)},
+		{quarantineOwnerKey, owner},
+	})
+}
+
+// LiftQuarantine removes the quarantine of the golden file at path.
+func LiftQuarantine(path string) error {
+	return rewriteQuarantine(path, nil)
+}
+
+// rewriteQuarantine replaces the quarantine entries of the metadata header of
+// the golden file at path with entries.
+func rewriteQuarantine(path string, entries []metadataEntry) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	header, body := splitMetadata(string(contents))
+	header = withoutQuarantine(header)
+	for _, e := range entries {
+		header += fmt.Sprintf("%s%s: %s\n", metadataPrefix, e.key, e.value)
+	}
+	return ioutil.WriteFile(path, []byte(header+body), 0660)
+}
+
+// withoutQuarantine removes the quarantine entries from a metadata header,
+// leaving the other lines as they are.
+func withoutQuarantine(header string) string {
+	var kept []string
+	for _, line := range strings.SplitAfter(header, "\n") {
+		key := strings.TrimPrefix(line, metadataPrefix)
+		if i := strings.Index(key, ":"); i >= 0 {
+			key = key[:i]
+		}
+		if key != quarantineUntilKey && key != quarantineOwnerKey {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
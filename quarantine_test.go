@@ -0,0 +1,120 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuarantine(t *testing.T) {
+	var tests = []struct {
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{header: "", want: ""},
+		{header: "#!golden-meta go: go1.21\n", want: ""},
+		{header: "#!golden-meta quarantined-until: 2017-03-01\n#!golden-meta quarantine-owner: alice\n", want: "quarantined until 2017-03-01 by alice"},
+		{header: "#!golden-meta quarantined-until: March\n#!golden-meta quarantine-owner: alice\n", wantErr: true},
+		{header: "#!golden-meta quarantined-until: 2017-03-01\n", wantErr: true},
+	}
+	for _, test := range tests {
+		q, err := parseQuarantine(test.header)
+		got := ""
+		if q != nil {
+			got = q.String()
+		}
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("parseQuarantine(%q): got %q, %v, want %q", test.header, got, err, test.want)
+		}
+	}
+}
+
+func TestQuarantineExpired(t *testing.T) {
+	q := &quarantine{until: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), owner: "alice"}
+	if q.expired(time.Date(2017, 3, 1, 23, 59, 0, 0, time.UTC)) {
+		t.Errorf("quarantine expired during its last day")
+	}
+	if !q.expired(time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("quarantine not expired the day after its last day")
+	}
+}
+
+func TestCompareQuarantined(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/flaky.golden")
+	if err := ioutil.WriteFile(goldenPath, []byte("#!golden-meta test: TestFlaky\nstable\n"), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	if err := Quarantine(goldenPath, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), "alice"); err != nil {
+		t.Fatalf("Quarantine: %v", err)
+	}
+	want := "#!golden-meta test: TestFlaky\n#!golden-meta quarantined-until: 2017-03-01\n#!golden-meta quarantine-owner: alice\nstable\n"
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != want {
+		t.Errorf("quarantined golden file: got %q, want %q", got, want)
+	}
+	defer setGoPathForTest(dir)()
+	defer func() { timeNow = time.Now }()
+
+	timeNow = func() time.Time { return time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC) }
+	r := Check("flaky\n", "fake/testdata/flaky.golden")
+	if r.Equal() || !r.Quarantined() || r.String() != "" {
+		t.Errorf("Check with an active quarantine: got equal %v, quarantined %v and message %q", r.Equal(), r.Quarantined(), r)
+	}
+
+	timeNow = func() time.Time { return time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC) }
+	wantPrefix := "Golden file fake/testdata/flaky.golden was quarantined until 2017-03-01 by alice; fix the actual data, or update the golden file to lift the quarantine\n"
+	if got := Compare("stable\n", "fake/testdata/flaky.golden"); got != wantPrefix {
+		t.Errorf("Compare with an expired quarantine: got %q, want %q", got, wantPrefix)
+	}
+	if got := Compare("flaky\n", "fake/testdata/flaky.golden"); !strings.HasPrefix(got, wantPrefix+"Actual data differs") {
+		t.Errorf("Compare with an expired quarantine: got %q, want prefix %q", got, wantPrefix)
+	}
+
+	// Updating lifts the quarantine, even if the data does not change.
+	defer resetUpdatesForTest()()
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	Compare("stable\n", "fake/testdata/flaky.golden")
+	restoreFunc()
+	want = "#!golden-meta test: TestFlaky\nstable\n"
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != want {
+		t.Errorf("golden file updated after the quarantine expired: got %q, want %q", got, want)
+	}
+	if err := Quarantine(goldenPath, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), "alice"); err != nil {
+		t.Fatalf("Quarantine: %v", err)
+	}
+
+	if err := LiftQuarantine(goldenPath); err != nil {
+		t.Fatalf("LiftQuarantine: %v", err)
+	}
+	if got := Compare("stable\n", "fake/testdata/flaky.golden"); got != "" {
+		t.Errorf("Compare after lifting the quarantine: got %q, want no diff", got)
+	}
+	if err := Quarantine(goldenPath, time.Now(), ""); err == nil {
+		t.Errorf("Quarantine without an owner: got nil error")
+	}
+}
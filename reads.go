@@ -0,0 +1,156 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// readReportEnv names the environment variable holding the path of the read
+// report. Reads are only tracked if it is set.
+const readReportEnv = "GOLDEN_READ_REPORT"
+
+var reads = struct {
+	sync.Mutex
+	byPath map[string]int
+}{byPath: map[string]int{}}
+
+// recordRead records that the golden file at fullPath was read, if read
+// tracking is enabled.
+func recordRead(fullPath string) {
+	if os.Getenv(readReportEnv) == "" {
+		return
+	}
+	if abs, err := filepath.Abs(fullPath); err == nil {
+		fullPath = abs
+	}
+	reads.Lock()
+	defer reads.Unlock()
+	reads.byPath[fullPath]++
+}
+
+func resetReadsForTest() func() {
+	reads.Lock()
+	defer reads.Unlock()
+	original := reads.byPath
+	reads.byPath = map[string]int{}
+	return func() {
+		reads.Lock()
+		defer reads.Unlock()
+		reads.byPath = original
+	}
+}
+
+// writeReadReport appends the golden files read so far to the file named by
+// GOLDEN_READ_REPORT, one per line with the number of reads and the name of
+// the test binary, so that the reports of several packages can accumulate in
+// the same file.
+func writeReadReport() error {
+	report := os.Getenv(readReportEnv)
+	if report == "" {
+		return nil
+	}
+	reads.Lock()
+	paths := make([]string, 0, len(reads.byPath))
+	for p := range reads.byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	buf := &bytes.Buffer{}
+	binary := filepath.Base(os.Args[0])
+	for _, p := range paths {
+		fmt.Fprintf(buf, "%d\t%v\t%v\n", reads.byPath[p], binary, p)
+	}
+	reads.Unlock()
+	f, err := os.OpenFile(report, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0660)
+	if err != nil {
+		return err
+	}
+	// A single write keeps the lines of concurrently running test binaries
+	// from interleaving.
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// AuditReads checks the read report written by test binaries run with
+// GOLDEN_READ_REPORT set against the golden files under dir. It returns a
+// description of every golden file that was never read, which is likely
+// obsolete, and of every golden file read more than once or by more than one
+// test binary, which is likely shared by mistake.
+func AuditReads(report string, dir string) ([]string, error) {
+	f, err := os.Open(report)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	counts := map[string]int{}
+	binaries := map[string][]string{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%v:%d: malformed line", report, line)
+		}
+		count, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v:%d: malformed count: %v", report, line, err)
+		}
+		counts[fields[2]] += count
+		binaries[fields[2]] = append(binaries[fields[2]], fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var findings []string
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isGoldenFile(info.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case counts[p] == 0:
+			findings = append(findings, "never read: "+rel)
+		case len(binaries[p]) > 1:
+			sort.Strings(binaries[p])
+			findings = append(findings, fmt.Sprintf("read by %d test binaries: %v (%v)", len(binaries[p]), rel, strings.Join(binaries[p], ", ")))
+		case counts[p] > 1:
+			findings = append(findings, fmt.Sprintf("read %d times: %v", counts[p], rel))
+		}
+		return nil
+	})
+	return findings, err
+}
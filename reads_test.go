@@ -0,0 +1,88 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.golden", "b.golden", "c.golden", "sub/d.golden", "notes.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := filepath.Join(dir, "reads.txt")
+	defer setenvForTest(map[string]string{readReportEnv: report})()
+	defer resetReadsForTest()()
+
+	if _, _, _, err := readGolden(filepath.Join(dir, "a.golden"), newOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := readGolden(filepath.Join(dir, "b.golden"), newOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := readGolden(filepath.Join(dir, "b.golden"), newOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReadReport(); err != nil {
+		t.Fatalf("writeReadReport: %v", err)
+	}
+	resetReadsForTest()
+	if _, _, _, err := readGolden(filepath.Join(dir, "c.golden"), newOptions(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReadReport(); err != nil {
+		t.Fatalf("writeReadReport: %v", err)
+	}
+	// Another test binary reading c.golden.
+	f, err := os.OpenFile(report, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("1\tother.test\t" + filepath.Join(dir, "c.golden") + "\n")
+	f.Close()
+
+	got, err := AuditReads(report, dir)
+	binary := filepath.Base(os.Args[0])
+	want := []string{
+		"read 2 times: b.golden",
+		"read by 2 test binaries: c.golden (" + strings.Join(sortedKeys(map[string]bool{binary: true, "other.test": true}), ", ") + ")",
+		"never read: sub/d.golden",
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("AuditReads: got %q, %v, want %q", got, err, want)
+	}
+
+	if err := ioutil.WriteFile(report, []byte("x\ty\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AuditReads(report, dir); err == nil {
+		t.Errorf("AuditReads of a malformed report: got nil error")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// jsonRecordEnv names the environment variable that enables mismatch records
+// for all comparisons, as WithJSONRecord does for one.
+const jsonRecordEnv = "GOLDEN_JSON_RECORD"
+
+// jsonRecordPrefix starts the line holding a mismatch record, so that tools
+// can find it among the rest of the test output.
+const jsonRecordPrefix = "golden-mismatch: "
+
+// mismatchRecord is the machine-readable description of a mismatch.
+type mismatchRecord struct {
+	Test          string `json:"test,omitempty"`
+	Golden        string `json:"golden"`
+	Path          string `json:"path,omitempty"`
+	Line          int    `json:"line"`
+	Column        int    `json:"column"`
+	Offset        int    `json:"offset"`
+	ChangedLines  int    `json:"changedLines,omitempty"`
+	UpdateCommand string `json:"updateCommand"`
+}
+
+// wantJSONRecord reports whether mismatches should be described by a record.
+func (o *options) wantJSONRecord() bool {
+	return o.jsonRecord || os.Getenv(jsonRecordEnv) != ""
+}
+
+// jsonRecord returns the line holding the mismatch record of r.
+func (r Result) jsonRecord() string {
+	data, err := json.Marshal(mismatchRecord{
+		Test:          callingTest(),
+		Golden:        r.displayPath,
+		Path:          r.goldenPath,
+		Line:          r.headerLines + r.firstDiff.Line,
+		Column:        r.firstDiff.Column,
+		Offset:        r.firstDiff.Offset,
+		ChangedLines:  r.changedLines,
+		UpdateCommand: r.o.updateCommandOrDefault(),
+	})
+	if err != nil {
+		return fmt.Sprintf("%v{\"error\": %q}\n", jsonRecordPrefix, err)
+	}
+	return jsonRecordPrefix + string(data) + "\n"
+}
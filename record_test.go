@@ -0,0 +1,70 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRecord(t *testing.T) {
+	want := mismatchRecord{
+		Test:          "TestJSONRecord",
+		Golden:        "testdata/haiku.txt.golden",
+		Line:          2,
+		Column:        14,
+		Offset:        32,
+		UpdateCommand: "go test -update_golden",
+	}
+	tests := []struct {
+		name string
+		env  string
+		opts []Option
+		want bool
+	}{
+		{"default", "", nil, false},
+		{"option", "", []Option{WithJSONRecord()}, true},
+		{"environment", "1", nil, true},
+	}
+	for _, tt := range tests {
+		restore := setenvForTest(map[string]string{jsonRecordEnv: tt.env})
+		r := Check("It reads many bits\nIt exchanges twenty bits\nIt writes many bits\n", "github.com/google/golden/testdata/haiku.txt.golden", tt.opts...)
+		msg := r.String()
+		restore()
+		lines := strings.Split(strings.TrimSuffix(msg, "\n"), "\n")
+		last := lines[len(lines)-1]
+		if got := strings.HasPrefix(last, jsonRecordPrefix); got != tt.want {
+			t.Errorf("%v: record present = %v, want %v in %q", tt.name, got, tt.want, msg)
+			continue
+		}
+		if !tt.want {
+			continue
+		}
+		var got mismatchRecord
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(last, jsonRecordPrefix)), &got); err != nil {
+			t.Errorf("%v: cannot parse record %q: %v", tt.name, last, err)
+			continue
+		}
+		if got.Path == "" {
+			t.Errorf("%v: record has no full path", tt.name)
+		}
+		got.Path = ""
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got record %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexpGoldenSuffix marks golden files whose lines are regular expressions.
+const regexpGoldenSuffix = ".golden.re"
+
+func isRegexpGolden(goldenFile string) bool {
+	return strings.HasSuffix(goldenFile, regexpGoldenSuffix)
+}
+
+// matchRegexpGolden matches each line of actual against the anchored regular
+// expression on the same line of patterns. It returns patterns with every
+// line that matched replaced by the actual line, so that diffing the result
+// against actual only shows the lines that did not match.
+func matchRegexpGolden(patterns, actual string) (string, error) {
+	patternLines := strings.Split(patterns, "\n")
+	actualLines := strings.Split(actual, "\n")
+	for i, pattern := range patternLines {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return "", fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if i < len(actualLines) && re.MatchString(actualLines[i]) {
+			patternLines[i] = actualLines[i]
+		}
+	}
+	return strings.Join(patternLines, "\n"), nil
+}
+
+// updateRegexpGolden returns the new contents of a regexp golden file whose
+// previous contents were patterns. Patterns that still match the
+// corresponding line of actual are kept; all other lines are replaced by the
+// quoted actual line.
+func updateRegexpGolden(patterns, actual string) string {
+	patternLines := strings.Split(patterns, "\n")
+	lines := strings.Split(actual, "\n")
+	for i, line := range lines {
+		if i < len(patternLines) {
+			re, err := regexp.Compile("^(?:" + patternLines[i] + ")$")
+			if err == nil && re.MatchString(line) {
+				lines[i] = patternLines[i]
+				continue
+			}
+		}
+		lines[i] = regexp.QuoteMeta(line)
+	}
+	return strings.Join(lines, "\n")
+}
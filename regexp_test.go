@@ -0,0 +1,86 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestCompareRegexpGolden(t *testing.T) {
+	var tests = []struct {
+		actual string
+		want   string
+	}{
+		{
+			actual: "It reads 42 bits\nIt exchanges few bits\nIt writes many bits\n",
+			want:   "",
+		},
+		{
+			actual: "It reads some bits\nIt exchanges few bits\nIt writes many bits\n",
+			want: `Actual data differs from golden data; run "go test -update_golden" to update
+testdata/haiku.txt.golden.re:1:10: first difference (byte offset 9)
+--- testdata/haiku.txt.golden.re
++++ testdata/haiku.txt.actual
+@@ -1,4 +1,4 @@
+-It reads \d+ bits
++It reads some bits
+ It exchanges few bits
+ It writes many bits
+ 
+`,
+		},
+	}
+	for _, test := range tests {
+		got := Compare(test.actual, "github.com/google/golden/testdata/haiku.txt.golden.re")
+		if got != test.want {
+			t.Errorf("Compare(%q): got %q, want %q", test.actual, got, test.want)
+		}
+	}
+}
+
+func TestMatchRegexpGoldenError(t *testing.T) {
+	if _, err := matchRegexpGolden("ok\n(unclosed", "ok\n"); err == nil {
+		t.Errorf("matchRegexpGolden with an invalid expression: got nil error")
+	}
+}
+
+func TestUpdateRegexpGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/log.txt.golden.re")
+	if err := ioutil.WriteFile(goldenPath, []byte("took \\d+ms\nstatus: ok\n"), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+
+	Compare("took 12ms\nstatus: failed (1+1)\n", "fake/testdata/log.txt.golden.re")
+	got, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "took \\d+ms\nstatus: failed \\(1\\+1\\)\n"; string(got) != want {
+		t.Errorf("written contents: got %q, want %q", string(got), want)
+	}
+}
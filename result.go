@@ -0,0 +1,349 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Result describes how actual data compared to a golden file. Results are
+// returned by Check.
+type Result struct {
+	goldenFile string
+	goldenPath string
+	// missing is set if the golden file does not exist and was compared as
+	// empty because of WithMissingAsEmpty.
+	missing bool
+	// displayPath is how messages refer to the golden file.
+	displayPath string
+	// headerLines is the number of lines of its metadata header, which
+	// precede the golden data.
+	headerLines int
+	actual      string
+	equal       bool
+	firstDiff   Position
+	// changedLines is only computed with WithReportOnly.
+	changedLines int
+	// expected and normalized are the compared data; they are only kept
+	// with WithFullContents.
+	expected, normalized string
+	diff                 string
+	// binary is set if the data looked binary, so that diff only describes
+	// it instead of showing lines.
+	binary bool
+	// artifactPath is where the actual data was saved when it did not match
+	// a digest golden file.
+	artifactPath string
+	// patchPath is where the patch updating the golden file was saved.
+	patchPath string
+	// quarantine is set if the golden file is quarantined. quarantined
+	// reports that a mismatch is being ignored because of it, and
+	// quarantineExpired that it has expired.
+	quarantine        *quarantine
+	quarantined       bool
+	quarantineExpired bool
+	err               error
+	o                 *options
+}
+
+// Check compares actual to the contents of goldenFile like Compare does, but
+// neither updates the golden file nor exits on errors. Instead, it returns a
+// Result on top of which callers can build their own reporting, retries or
+// conditional updates:
+//
+//     r := golden.Check(got, "github.com/acme/tool/testdata/out.golden")
+//     if !r.Equal() {
+//       t.Errorf("output differs from %v:\n%v", r.GoldenPath(), r.Diff())
+//     }
+//
+// The -update_golden flag has no effect on Check; call Result.Update instead.
+func Check(actual string, goldenFile string, opts ...Option) Result {
+	o := newOptions(opts)
+	return check(actual, o.prefixed(goldenFile), o)
+}
+
+func check(actual string, goldenFile string, o *options) Result {
+	r := compareGolden(actual, goldenFile, o)
+	if r.err == nil && r.quarantine != nil {
+		if r.quarantine.expired(timeNow()) {
+			r.quarantineExpired = true
+		} else if !r.equal {
+			r.quarantined = true
+			logf("Ignoring mismatch with %v, which is %v", r.displayPath, r.quarantine)
+		}
+	}
+	return r
+}
+
+// compareGolden compares actual to the contents of goldenFile, regardless of
+// any quarantine.
+func compareGolden(actual string, goldenFile string, o *options) Result {
+	r := Result{goldenFile: goldenFile, actual: actual, o: o}
+	if r.err = o.checkSize(goldenFile, actual); r.err != nil {
+		return r
+	}
+	// Most comparisons succeed; confirm those without loading the golden
+	// file when possible.
+	if o.comparesRaw(goldenFile) {
+		if fullPath, err := getFullPathForRead(goldenFile); err == nil && !hasPendingWrite(fullPath) {
+			if equal, err := scanEqual(o.context(), fullPath, actual); err == nil && equal {
+				recordRead(fullPath)
+				if o.debugging() {
+					o.debugf(goldenFile, "read %v (%v) and found it equal to the actual data", fullPath, describeResolution(goldenFile, fullPath, o))
+				}
+				r.goldenPath, r.displayPath, r.equal = fullPath, displayPath(goldenFile, fullPath, o), true
+				return r
+			}
+		}
+	}
+	var header, expected string
+	r.goldenPath, header, expected, r.missing, r.err = readGoldenOrMissing(goldenFile, o)
+	r.displayPath = displayPath(goldenFile, r.goldenPath, o)
+	if r.err != nil {
+		return r
+	}
+	r.headerLines = strings.Count(header, "\n")
+	previous := header + expected
+	expected = o.expandVariables(goldenFile, o.stripComments(expected))
+	if r.quarantine, r.err = parseQuarantine(header); r.err != nil {
+		r.err = fmt.Errorf("golden file %v: %v", r.displayPath, r.err)
+		return r
+	}
+	if isPointerGolden(goldenFile) {
+		if expected, r.err = readPointer(expected, o); r.err != nil {
+			r.err = fmt.Errorf("pointer golden file %v: %v", r.displayPath, r.err)
+			return r
+		}
+	}
+	actual = stripBOM(actual)
+	if o.decompress && !isDigestGolden(goldenFile) {
+		if expected, r.err = decompress(expected); r.err != nil {
+			r.err = fmt.Errorf("golden file %v: %v", r.displayPath, r.err)
+			return r
+		}
+		if actual, r.err = decompress(actual); r.err != nil {
+			r.err = fmt.Errorf("actual data: %v", r.err)
+			return r
+		}
+	}
+	if o.canonicalize != nil {
+		if expected, r.err = o.canonicalize(expected); r.err != nil {
+			r.err = fmt.Errorf("golden file %v: %v", r.displayPath, r.err)
+			return r
+		}
+		if actual, r.err = o.canonicalize(actual); r.err != nil {
+			r.err = fmt.Errorf("actual data: %v", r.err)
+			return r
+		}
+	}
+	if o.debugging() {
+		if names := o.normalizerNames(); len(names) > 0 {
+			o.debugf(goldenFile, "normalizing with %v", strings.Join(names, ", "))
+		}
+	}
+	expected, actual = o.normalize(expected), o.normalize(actual)
+	if isRegexpGolden(goldenFile) {
+		if expected, r.err = matchRegexpGolden(expected, actual); r.err != nil {
+			r.err = fmt.Errorf("regexp golden file %v: %v", r.displayPath, r.err)
+			return r
+		}
+	}
+	if isDigestGolden(goldenFile) {
+		raw := actual
+		if actual, r.err = formatDigestContext(o.context(), actual); r.err != nil {
+			return r
+		}
+		if actual == expected {
+			r.equal = true
+			return r
+		}
+		if r.artifactPath, r.err = saveArtifact(goldenFile, raw); r.err != nil {
+			r.err = fmt.Errorf("saving actual data: %v", r.err)
+			return r
+		}
+	}
+	if expected == actual {
+		r.equal = true
+		return r
+	}
+	differ := o.differ
+	if differ == nil && !o.reportOnly {
+		differ = unifiedDiffer{
+			fromFile: r.displayPath,
+			toFile:   actualFileName(r.displayPath),
+			patience: o.patience,
+		}
+	}
+	if patchDir.Load() != "" {
+		if r.patchPath, r.err = r.savePatch(previous); r.err != nil {
+			r.err = fmt.Errorf("saving patch: %v", r.err)
+			return r
+		}
+	}
+	r.firstDiff = firstDifference(expected, actual)
+	if o.fullContents {
+		r.expected, r.normalized = expected, actual
+	}
+	if o.reportOnly {
+		r.changedLines = countChangedLines(expected, actual)
+		r.diff = fmt.Sprintf("%d lines differ\n", r.changedLines)
+		return r
+	}
+	if o.differ == nil && (isBinary(expected) || isBinary(actual)) {
+		r.binary = true
+		r.diff = binaryDiff(expected, actual)
+		if o.hexDiff {
+			r.diff += hexDiff(expected, actual)
+		}
+		return r
+	}
+	r.diff = differ.Diff(expected, actual)
+	return r
+}
+
+// Equal reports whether the actual data matched the golden file. It is false
+// if the golden file could not be read; see Err.
+func (r Result) Equal() bool {
+	return r.equal
+}
+
+// Diff returns a description of how the actual data differs from the golden
+// file, or the empty string if they are equal. With WithReportOnly, it only
+// tells how many lines differ. If either looks binary, it only gives their
+// sizes and SHA-256 digests, unless a Differ was set with WithDiffer.
+func (r Result) Diff() string {
+	return r.diff
+}
+
+// FirstDifference returns the position of the first byte at which the actual
+// data differs from the golden data, or the zero Position if they are equal.
+// This is often more useful than the diff when comparing two near-identical
+// large files.
+func (r Result) FirstDifference() Position {
+	return r.firstDiff
+}
+
+// Quarantined reports whether the actual data differs from the golden data,
+// but the mismatch is ignored because the golden file is quarantined.
+func (r Result) Quarantined() bool {
+	return r.quarantined
+}
+
+// Err returns the error that prevented the comparison, such as a missing
+// golden file, or nil.
+func (r Result) Err() error {
+	return r.err
+}
+
+// GoldenPath returns the path of the golden file on disk. If it could not be
+// found, GoldenPath returns the path it would be written to by Update, or
+// the empty string if that cannot be determined either.
+func (r Result) GoldenPath() string {
+	if r.goldenPath != "" {
+		return r.goldenPath
+	}
+	if r.o.storage != nil {
+		return r.goldenFile
+	}
+	fullPath, err := getFullPathForWrite(r.goldenFile)
+	if err != nil {
+		return ""
+	}
+	return fullPath
+}
+
+// ActualPath returns the path under which the actual data is shown in the
+// diff, next to GoldenPath.
+func (r Result) ActualPath() string {
+	if goldenPath := r.GoldenPath(); goldenPath != "" {
+		return actualFileName(goldenPath)
+	}
+	return ""
+}
+
+// Update overwrites the golden file with the actual data, as Compare does
+// when the -update_golden flag is set.
+func (r Result) Update() error {
+	return writeGolden(r.goldenFile, r.actual, r.o)
+}
+
+// String returns the failure message that Compare would return: the empty
+// string if the data matched, and a diff with instructions for updating the
+// golden file otherwise.
+func (r Result) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("Error while checking golden file: %v", r.err)
+	}
+	if r.quarantineExpired {
+		msg := fmt.Sprintf("Golden file %v was %v; fix the actual data, or update the golden file to lift the quarantine\n", r.displayPath, r.quarantine)
+		if !r.equal {
+			msg += r.mismatch()
+		}
+		return msg
+	}
+	if r.equal || r.quarantined {
+		return ""
+	}
+	return r.mismatch()
+}
+
+// mismatch describes how the actual data differs from the golden data,
+// followed by the environment fingerprint and its JSON record if requested.
+func (r Result) mismatch() string {
+	msg := r.describeMismatch()
+	if r.o.wantFingerprint() {
+		msg += environmentFingerprint()
+	}
+	if r.o.wantJSONRecord() {
+		msg += r.jsonRecord()
+	}
+	return msg
+}
+
+func (r Result) describeMismatch() string {
+	if r.o.reportOnly {
+		return fmt.Sprintf("Golden mismatch in %v (%d lines differ); run %q to update\n", r.displayPath, r.changedLines, r.o.updateCommandOrDefault())
+	}
+	msg := fmt.Sprintf("Actual data differs from golden data; run %q to update\n%v\n%v", r.o.updateCommandOrDefault(), r.location(), r.diff)
+	if r.artifactPath != "" {
+		msg += fmt.Sprintf("Actual data saved to %v\n", r.artifactPath)
+	}
+	if r.patchPath != "" {
+		msg += fmt.Sprintf("Patch updating the golden file saved to %v\n", r.patchPath)
+	}
+	if r.o.fullContents && !r.binary {
+		msg += delimit("golden data ("+r.displayPath+")", r.expected) + delimit("actual data", r.normalized)
+	}
+	return msg
+}
+
+// location returns the position of the first difference in the golden file
+// as "<path>:<line>:<column>", which editors and their problem matchers can
+// jump to. Lines dropped or changed by normalizers can make it approximate.
+func (r Result) location() string {
+	if r.binary {
+		return fmt.Sprintf("%v: first difference at byte offset %d", r.displayPath, r.firstDiff.Offset)
+	}
+	return fmt.Sprintf("%v:%d:%d: first difference (byte offset %d)", r.displayPath, r.headerLines+r.firstDiff.Line, r.firstDiff.Column, r.firstDiff.Offset)
+}
+
+// delimit returns data between lines marking its beginning and end.
+func delimit(name string, data string) string {
+	if data != "" && !strings.HasSuffix(data, "\n") {
+		data += "\n"
+	}
+	return fmt.Sprintf("----- BEGIN %v -----\n%v----- END %v -----\n", name, data, name)
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/data.txt.golden")
+	if err := ioutil.WriteFile(goldenPath, []byte("old\n"), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+
+	r := Check("old\n", "fake/testdata/data.txt.golden")
+	if !r.Equal() || r.Diff() != "" || r.Err() != nil || r.String() != "" {
+		t.Errorf("Check of equal data: got Equal() %v, Diff() %q, Err() %v, String() %q", r.Equal(), r.Diff(), r.Err(), r.String())
+	}
+
+	r = Check("new\n", "fake/testdata/data.txt.golden")
+	if r.Equal() || r.Err() != nil {
+		t.Errorf("Check of different data: got Equal() %v, Err() %v", r.Equal(), r.Err())
+	}
+	wantDiff := "--- fake/testdata/data.txt.golden\n+++ fake/testdata/data.txt.actual\n@@ -1,2 +1,2 @@\n-old\n+new\n \n"
+	if r.Diff() != wantDiff {
+		t.Errorf("Diff(): got %q want %q", r.Diff(), wantDiff)
+	}
+	if !strings.HasPrefix(r.String(), "Actual data differs from golden data") || !strings.HasSuffix(r.String(), wantDiff) {
+		t.Errorf("String(): got %q", r.String())
+	}
+	if want := (Position{Offset: 0, Line: 1, Column: 1}); r.FirstDifference() != want {
+		t.Errorf("FirstDifference(): got %v want %v", r.FirstDifference(), want)
+	}
+	if r.GoldenPath() != goldenPath {
+		t.Errorf("GoldenPath(): got %q want %q", r.GoldenPath(), goldenPath)
+	}
+	if want := path.Join(dir, "src/fake/testdata/data.txt.actual"); r.ActualPath() != want {
+		t.Errorf("ActualPath(): got %q want %q", r.ActualPath(), want)
+	}
+	if err := r.Update(); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	if got, _ := ioutil.ReadFile(goldenPath); string(got) != "new\n" {
+		t.Errorf("golden file after Update(): got %q want %q", got, "new\n")
+	}
+
+	r = Check("new\n", "fake/testdata/missing.golden")
+	if r.Equal() || r.Err() == nil {
+		t.Errorf("Check of missing golden file: got Equal() %v, Err() %v", r.Equal(), r.Err())
+	}
+	if want := path.Join(dir, "src/fake/testdata/missing.golden"); r.GoldenPath() != want {
+		t.Errorf("GoldenPath() of missing golden file: got %q want %q", r.GoldenPath(), want)
+	}
+}
+
+func TestCheckWithReportOnly(t *testing.T) {
+	r := Check("It reads many bits\nIt exchanges secret bits\nIt writes secret bits\n",
+		"github.com/google/golden/testdata/haiku.txt.golden", WithReportOnly())
+	if want := "2 lines differ\n"; r.Diff() != want {
+		t.Errorf("Diff(): got %q want %q", r.Diff(), want)
+	}
+	want := "Golden mismatch in testdata/haiku.txt.golden (2 lines differ); run \"go test -update_golden\" to update\n"
+	if r.String() != want {
+		t.Errorf("String(): got %q want %q", r.String(), want)
+	}
+}
+
+func TestCheckWithFullContents(t *testing.T) {
+	r := Check("It reads many bits\nIt writes many bits",
+		"github.com/google/golden/testdata/haiku.txt.golden", WithFullContents())
+	want := `Actual data differs from golden data; run "go test -update_golden" to update
+testdata/haiku.txt.golden:2:4: first difference (byte offset 22)
+--- testdata/haiku.txt.golden
++++ testdata/haiku.txt.actual
+@@ -1,4 +1,2 @@
+ It reads many bits
+-It exchanges many bits
+ It writes many bits
+-
+----- BEGIN golden data (testdata/haiku.txt.golden) -----
+It reads many bits
+It exchanges many bits
+It writes many bits
+----- END golden data (testdata/haiku.txt.golden) -----
+----- BEGIN actual data -----
+It reads many bits
+It writes many bits
+----- END actual data -----
+`
+	if r.String() != want {
+		t.Errorf("String(): got %q want %q", r.String(), want)
+	}
+}
+
+func TestCheckLocationSkipsMetadataHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	goldenFile := path.Join(dir, "meta.golden")
+	if err := ioutil.WriteFile(goldenFile, []byte("#!golden-meta test: TestFoo\n#!golden-meta go: go1\nsame\nold\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := Check("same\nnew\n", goldenFile)
+	want := goldenFile + ":4:1: first difference (byte offset 5)\n"
+	if got := r.String(); !strings.Contains(got, "\n"+want) {
+		t.Errorf("String(): got %q, want it to contain %q", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CompareRows compares a query's result set to goldenFile, as Compare does.
+// The rows are rendered as an aligned table with a header of column names:
+//
+//     id | name  | email
+//     ---+-------+------
+//     1  | alice | NULL
+//     2  | bob   | 0x00ff
+//
+// SQL NULL is rendered as NULL, byte slices that are not valid UTF-8 in hex,
+// and times in RFC 3339 format in UTC. Values that would be ambiguous, such
+// as ones containing "|" or line breaks, or the string "NULL", are quoted.
+// Unless the query has an ORDER BY clause, pass WithUnorderedRows so that the
+// order of rows does not matter. CompareRows consumes and closes rows; if
+// reading them fails, the error is returned as the failure message.
+func CompareRows(rows *sql.Rows, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	return CompareFunc(func() (string, error) {
+		return renderRows(rows, o.unorderedRows)
+	}, goldenFile, opts...)
+}
+
+// renderRows renders rows as a table, sorting them if sorted is set.
+func renderRows(rows *sql.Rows, sorted bool) (string, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var table [][]string
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return "", err
+		}
+		row := make([]string, len(columns))
+		for i, v := range values {
+			row[i] = formatSQLValue(v)
+		}
+		table = append(table, row)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if sorted {
+		sort.Slice(table, func(i, j int) bool {
+			return strings.Join(table[i], "\x00") < strings.Join(table[j], "\x00")
+		})
+	}
+	return formatTable(columns, table), nil
+}
+
+// formatSQLValue renders a value scanned into an interface{}.
+func formatSQLValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("0x%x", v)
+		}
+		s = string(v)
+	case string:
+		s = v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+	if s == "NULL" || s == "" || strings.ContainsAny(s, "|\n\r\t") || strings.TrimSpace(s) != s || !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatTable renders rows under a header of columns, padding each column to
+// its widest cell.
+func formatTable(columns []string, rows [][]string) string {
+	widths := make([]int, len(columns))
+	for _, row := range append([][]string{columns}, rows...) {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	buf := &bytes.Buffer{}
+	writeRow := func(row []string, pad string, sep string) {
+		for i, cell := range row {
+			if i > 0 {
+				buf.WriteString(sep)
+			}
+			buf.WriteString(cell)
+			if i < len(row)-1 {
+				buf.WriteString(strings.Repeat(pad, widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		buf.WriteString("\n")
+	}
+	writeRow(columns, " ", " | ")
+	separator := make([]string, len(columns))
+	for i, w := range widths {
+		separator[i] = strings.Repeat("-", w)
+	}
+	writeRow(separator, "-", "-+-")
+	for _, row := range rows {
+		writeRow(row, " ", " | ")
+	}
+	return buf.String()
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDriver serves the rows registered under a query's text.
+type fakeDriver struct{}
+
+var fakeResults = map[string]*fakeRows{}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+	next    int
+}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("unsupported") }
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return 0 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("unsupported") }
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	r := *fakeResults[s.query]
+	return &r, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next == len(r.values) {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+func init() {
+	sql.Register("golden_fake", fakeDriver{})
+}
+
+func TestCompareRows(t *testing.T) {
+	fakeResults["users"] = &fakeRows{
+		columns: []string{"id", "name", "data"},
+		values: [][]driver.Value{
+			{int64(2), "bob", []byte{0, 0xff}},
+			{int64(1), "alice", nil},
+			{int64(10), "a|b", time.Date(2017, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600))},
+			{int64(3), "NULL", []byte("text")},
+		},
+	}
+	fakeResults["broken"] = &fakeRows{columns: []string{"id"}, err: errors.New("connection reset")}
+	db, err := sql.Open("golden_fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+
+	var tests = []struct {
+		query string
+		opts  []Option
+		want  string
+	}{
+		{
+			query: "users",
+			want: `id | name   | data
+---+--------+---------------------
+2  | bob    | 0x00ff
+1  | alice  | NULL
+10 | "a|b"  | 2017-01-02T02:04:05Z
+3  | "NULL" | text
+`,
+		},
+		{
+			query: "users",
+			opts:  []Option{WithUnorderedRows()},
+			want: `id | name   | data
+---+--------+---------------------
+1  | alice  | NULL
+10 | "a|b"  | 2017-01-02T02:04:05Z
+2  | bob    | 0x00ff
+3  | "NULL" | text
+`,
+		},
+	}
+	goldenPath := path.Join(dir, "src/fake/testdata/rows.golden")
+	for _, test := range tests {
+		rows, err := db.Query(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restoreFunc := enableUpdateGoldenForTest(dir)
+		CompareRows(rows, "fake/testdata/rows.golden", test.opts...)
+		restoreFunc()
+		if got, _ := ioutil.ReadFile(goldenPath); string(got) != test.want {
+			t.Errorf("CompareRows(%q) wrote %q, want %q", test.query, got, test.want)
+		}
+	}
+
+	rows, err := db.Query("broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := CompareRows(rows, "fake/testdata/rows.golden"); !strings.Contains(got, "connection reset") {
+		t.Errorf("CompareRows with a failing result set: got %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// scanChunkSize is how much of a golden file scanEqual reads at a time.
+const scanChunkSize = 64 << 10
+
+// scanBuffers holds pointers to buffers of scanChunkSize bytes for reuse by
+// scanEqual. Pointers avoid allocating when putting the slices back.
+var scanBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, scanChunkSize)
+		return &buf
+	},
+}
+
+// comparesRaw reports whether golden files are compared to actual data byte
+// for byte, without being transformed first.
+func (o *options) comparesRaw(goldenFile string) bool {
+	return o.storage == nil && o.canonicalize == nil && len(o.ignoreLines) == 0 && len(o.normalizers) == 0 &&
+		o.commentPrefix == "" && !o.utf16 && o.variables == nil &&
+		!isRegexpGolden(goldenFile) && !isDigestGolden(goldenFile) && !isPointerGolden(goldenFile) && !isGzipGolden(goldenFile)
+}
+
+// scanEqual reports whether the file at fullPath holds exactly actual,
+// reading it a chunk at a time and stopping at the first difference, so that
+// equal data is confirmed without loading the whole file. It reports false
+// when the file starts like a byte order mark or a metadata header, which
+// need the full comparison. It gives up when ctx is done.
+func scanEqual(ctx context.Context, fullPath string, actual string) (bool, error) {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != int64(len(actual)) || strings.HasPrefix(actual, utf8BOM) || strings.HasPrefix(actual, metadataPrefix) {
+		return false, nil
+	}
+	chunk := scanBuffers.Get().(*[]byte)
+	defer scanBuffers.Put(chunk)
+	buf := *chunk
+	if len(actual) < len(buf) {
+		buf = buf[:len(actual)+1]
+	}
+	offset := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		n, err := f.Read(buf)
+		if offset+n > len(actual) || string(buf[:n]) != actual[offset:offset+n] {
+			return false, nil
+		}
+		offset += n
+		if err == io.EOF {
+			return offset == len(actual), nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanEqual(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	long := strings.Repeat("0123456789abcdef", scanChunkSize/8)
+	var tests = []struct {
+		golden, actual string
+		want           bool
+	}{
+		{"", "", true},
+		{"data\n", "data\n", true},
+		{"data\n", "date\n", false},
+		{"data\n", "data", false},
+		{"data", "data\n", false},
+		{long, long, true},
+		{long, long[:len(long)-1] + "x", false},
+		{"x" + long[1:], long, false},
+		{metadataPrefix + "test: TestFoo\n", metadataPrefix + "test: TestFoo\n", false},
+	}
+	p := filepath.Join(dir, "scan.golden")
+	for _, test := range tests {
+		if err := ioutil.WriteFile(p, []byte(test.golden), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := scanEqual(context.Background(), p, test.actual); got != test.want || err != nil {
+			t.Errorf("scanEqual(%.20q, %.20q): got %v, %v want %v", test.golden, test.actual, got, err, test.want)
+		}
+	}
+	if _, err := scanEqual(context.Background(), filepath.Join(dir, "missing"), ""); !os.IsNotExist(err) {
+		t.Errorf("scanEqual of a missing file: got %v, want not exist", err)
+	}
+}
+
+func TestComparesRaw(t *testing.T) {
+	var tests = []struct {
+		goldenFile string
+		opts       []Option
+		want       bool
+	}{
+		{"a.golden", nil, true},
+		{"a.golden", []Option{WithMetadataHeader(), WithParallelism(2)}, true},
+		{"a.golden", []Option{WithNormalizer(strings.ToLower)}, false},
+		{"a.golden", []Option{WithComments("")}, false},
+		{"a.golden", []Option{WithUTF16()}, false},
+		{"a.golden.re", nil, false},
+		{"a.golden.sha256", nil, false},
+	}
+	for _, test := range tests {
+		if got := newOptions(test.opts).comparesRaw(test.goldenFile); got != test.want {
+			t.Errorf("comparesRaw(%q) with %d options: got %v want %v", test.goldenFile, len(test.opts), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// CompareSchema is like CompareJSON for JSON Schema and OpenAPI documents,
+// such as generated API specifications. Besides formatting and key order, it
+// ignores differences that do not change what the document means:
+//
+//   - the order of the items of required, type and enum, and of the schemas
+//     of allOf, anyOf and oneOf, such as a list of $refs
+//   - the order of OpenAPI parameters
+//   - keywords set to their default values, such as "deprecated": false,
+//     "additionalProperties": true or "minItems": 0
+//
+// Property, definition and path names are never taken for keywords, and
+// default values and examples are compared as is. Updating the golden file
+// writes the document in this normalized form.
+func CompareSchema(actual string, goldenFile string, opts ...Option) string {
+	return Compare(actual, goldenFile, append(opts, func(o *options) {
+		o.jsonTransform = normalizeSchema
+	}, withJSON())...)
+}
+
+// schemaNameMaps lists the keywords whose values map names, rather than
+// keywords, to schemas or other objects.
+var schemaNameMaps = map[string]bool{
+	"properties": true, "patternProperties": true, "definitions": true, "$defs": true,
+	"dependentSchemas": true, "schemas": true, "paths": true, "responses": true,
+	"requestBodies": true, "headers": true, "securitySchemes": true, "links": true,
+	"callbacks": true, "content": true, "encoding": true, "variables": true,
+}
+
+// schemaData lists the keywords whose values are data rather than schemas.
+var schemaData = map[string]bool{
+	"default": true, "example": true, "examples": true, "const": true, "enum": true, "mapping": true,
+}
+
+// schemaSets lists the keywords whose arrays are unordered.
+var schemaSets = map[string]bool{
+	"required": true, "type": true, "enum": true, "allOf": true, "anyOf": true, "oneOf": true, "parameters": true,
+}
+
+// schemaDefaults gives the default values of keywords, in canonical JSON.
+var schemaDefaults = map[string][]string{
+	"deprecated": {"false"}, "nullable": {"false"}, "readOnly": {"false"}, "writeOnly": {"false"},
+	"uniqueItems": {"false"}, "additionalProperties": {"true"}, "minLength": {"0"},
+	"minItems": {"0"}, "minProperties": {"0"}, "exclusiveMinimum": {"false"},
+	"exclusiveMaximum": {"false"}, "allowEmptyValue": {"false"},
+	// Both a schema's list of required properties and a parameter's flag.
+	"required": {"[]", "false"},
+}
+
+// normalizeSchema normalizes a decoded JSON Schema or OpenAPI document as
+// described for CompareSchema.
+func normalizeSchema(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSchemaDefault(key, value) {
+				delete(v, key)
+				continue
+			}
+			switch names, ok := value.(map[string]interface{}); {
+			case schemaData[key]:
+			case schemaNameMaps[key] && ok:
+				for name, entry := range names {
+					names[name] = normalizeSchema(entry)
+				}
+			default:
+				v[key] = normalizeSchema(value)
+			}
+			if items, ok := v[key].([]interface{}); ok && schemaSets[key] {
+				sortJSONValues(items)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeSchema(item)
+		}
+	}
+	return v
+}
+
+// isSchemaDefault reports whether value is the default value of keyword.
+func isSchemaDefault(keyword string, value interface{}) bool {
+	defaults, ok := schemaDefaults[keyword]
+	if !ok {
+		return false
+	}
+	text := canonicalJSONText(value)
+	for _, d := range defaults {
+		if text == d {
+			return true
+		}
+	}
+	return false
+}
+
+// sortJSONValues sorts decoded JSON values by their canonical JSON text.
+func sortJSONValues(values []interface{}) {
+	keys := make([]string, len(values))
+	for i, v := range values {
+		keys[i] = canonicalJSONText(v)
+	}
+	sort.Sort(byKey{values, keys})
+}
+
+type byKey struct {
+	values []interface{}
+	keys   []string
+}
+
+func (b byKey) Len() int           { return len(b.values) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// canonicalJSONText returns v encoded as compact JSON with sorted keys.
+func canonicalJSONText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestNormalizeSchema(t *testing.T) {
+	o := newOptions(nil)
+	o.jsonTransform = normalizeSchema
+	var tests = []struct {
+		in, want string
+	}{
+		{
+			in:   `{"required": ["b", "a"], "type": ["string", "null"], "enum": ["y", "x"]}`,
+			want: `{"enum":["x","y"],"required":["a","b"],"type":["null","string"]}`,
+		},
+		{
+			in:   `{"oneOf": [{"$ref": "#/b"}, {"$ref": "#/a"}], "items": {"allOf": [{"minimum": 2}, {"$ref": "#/c"}]}}`,
+			want: `{"items":{"allOf":[{"$ref":"#/c"},{"minimum":2}]},"oneOf":[{"$ref":"#/a"},{"$ref":"#/b"}]}`,
+		},
+		{
+			in:   `{"deprecated": false, "additionalProperties": true, "minItems": 0, "required": [], "readOnly": true}`,
+			want: `{"readOnly":true}`,
+		},
+		{
+			// Property names are not keywords, and defaults and examples are data.
+			in:   `{"properties": {"required": {"type": "boolean", "default": false}, "tags": {"example": ["b", "a"], "uniqueItems": false}}}`,
+			want: `{"properties":{"required":{"default":false,"type":"boolean"},"tags":{"example":["b","a"]}}}`,
+		},
+		{
+			in:   `{"paths": {"/x": {"get": {"parameters": [{"in": "query", "name": "b", "required": false}, {"in": "path", "name": "a", "required": true}]}}}}`,
+			want: `{"paths":{"/x":{"get":{"parameters":[{"in":"path","name":"a","required":true},{"in":"query","name":"b"}]}}}}`,
+		},
+		{
+			// The order of ordinary arrays is significant.
+			in:   `{"prefixItems": [{"type": "string"}, {"type": "integer"}], "tags": ["b", "a"]}`,
+			want: `{"prefixItems":[{"type":"string"},{"type":"integer"}],"tags":["b","a"]}`,
+		},
+	}
+	for _, test := range tests {
+		got, err := o.canonicalJSON(test.in)
+		if err != nil {
+			t.Errorf("canonicalJSON(%q): %v", test.in, err)
+			continue
+		}
+		if want, _ := newOptions(nil).canonicalJSON(test.want); got != want {
+			t.Errorf("canonicalJSON(%q): got %q, want %q", test.in, got, want)
+		}
+	}
+}
+
+func TestCompareSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	CompareSchema(`{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}}}`, "fake/testdata/schema.json.golden")
+	restoreFunc()
+
+	defer setGoPathForTest(dir)()
+	var tests = []struct {
+		actual   string
+		wantDiff bool
+	}{
+		{actual: `{"required": ["name", "id"], "properties": {"id": {"type": "integer", "nullable": false}}, "type": "object"}`},
+		{actual: `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`, wantDiff: true},
+		{actual: `{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "string"}}}`, wantDiff: true},
+	}
+	for _, test := range tests {
+		if got := CompareSchema(test.actual, "fake/testdata/schema.json.golden"); (got != "") != test.wantDiff {
+			t.Errorf("CompareSchema(%q): got %q, want diff %v", test.actual, got, test.wantDiff)
+		}
+	}
+}
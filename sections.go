@@ -0,0 +1,204 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A Section is a labeled output compared with CompareSections.
+type Section struct {
+	Name string
+	Data string
+}
+
+// CompareSections compares several labeled outputs of a test, such as its
+// stdout, stderr and a generated config file, to a single golden file that
+// holds each of them after a line naming it, as in txtar archives:
+//
+//     -- stdout --
+//     Wrote 3 files
+//     -- stderr --
+//     warning: no config found
+//
+// Each section is compared on its own, and the message tells where in the
+// golden file each differing section starts, followed by its diff. Sections
+// missing from either side are reported too. Text before the first section
+// is a comment and is ignored. A final newline is added to sections lacking
+// one, and a section's data must not contain lines that look like section
+// names.
+//
+// If the -update_golden flag is set, the golden file is overwritten with the
+// sections in the given order, after the comment of the previous golden file.
+func CompareSections(sections []Section, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFile = o.prefixed(goldenFile)
+	if shouldUpdateGolden() {
+		actual, err := formatSections(sections)
+		if err == nil {
+			err = writeGolden(goldenFile, sectionsComment(goldenFile, o)+actual, o)
+		}
+		if err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	fullPath, header, body, err := readGolden(goldenFile, o)
+	if err != nil {
+		return Fatalf("Error while checking golden file: %v", err)
+	}
+	display := displayPath(goldenFile, fullPath, o)
+	expected, err := parseSections(body)
+	if err != nil {
+		return Fatalf("Error while checking golden file %v: %v", display, err)
+	}
+	actual := map[string]string{}
+	for _, s := range sections {
+		if _, ok := actual[s.Name]; ok {
+			return Fatalf("Error while checking golden file %v: duplicate actual section %q", display, s.Name)
+		}
+		actual[s.Name] = withFinalNewline(s.Data)
+	}
+
+	headerLines := strings.Count(header, "\n")
+	buf := &bytes.Buffer{}
+	var differing []string
+	seen := map[string]bool{}
+	for _, e := range expected {
+		seen[e.name] = true
+		got, ok := actual[e.name]
+		if !ok {
+			differing = append(differing, e.name)
+			fmt.Fprintf(buf, "%v:%d: section %q is missing from the actual data\n", display, headerLines+e.line, e.name)
+			continue
+		}
+		want, got := o.normalize(o.expandVariables(goldenFile, o.stripComments(e.data))), o.normalize(got)
+		if want == got {
+			continue
+		}
+		differing = append(differing, e.name)
+		fmt.Fprintf(buf, "%v:%d: section %q differs\n", display, headerLines+e.line, e.name)
+		switch {
+		case o.reportOnly:
+			fmt.Fprintf(buf, "%d lines differ\n", countChangedLines(want, got))
+		case o.differ != nil:
+			buf.WriteString(o.differ.Diff(want, got))
+		default:
+			buf.WriteString(unifiedDiffer{
+				fromFile: display + "#" + e.name,
+				toFile:   actualFileName(display) + "#" + e.name,
+				patience: o.patience,
+			}.Diff(want, got))
+		}
+	}
+	for _, s := range sections {
+		if !seen[s.Name] {
+			differing = append(differing, s.Name)
+			fmt.Fprintf(buf, "%v: section %q is missing from the golden file\n", display, s.Name)
+		}
+	}
+	if len(differing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Actual data differs from golden data in sections %v; run %q to update\n%v",
+		strings.Join(differing, ", "), o.updateCommandOrDefault(), buf)
+}
+
+// goldenSection is a section parsed from a golden file.
+type goldenSection struct {
+	name string
+	// line is the line of the golden file naming the section.
+	line int
+	data string
+}
+
+// sectionName returns the name of the section that line, without its
+// newline, starts, or false if it does not start a section.
+func sectionName(line string) (string, bool) {
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < len("-- x --") {
+		return "", false
+	}
+	name := strings.TrimSpace(line[len("-- ") : len(line)-len(" --")])
+	return name, name != ""
+}
+
+// parseSections splits the body of a golden file into its sections.
+func parseSections(body string) ([]goldenSection, error) {
+	var sections []goldenSection
+	seen := map[string]bool{}
+	for i, line := range strings.SplitAfter(body, "\n") {
+		name, ok := sectionName(strings.TrimSuffix(line, "\n"))
+		switch {
+		case ok && seen[name]:
+			return nil, fmt.Errorf("line %d: duplicate section %q", i+1, name)
+		case ok:
+			seen[name] = true
+			sections = append(sections, goldenSection{name: name, line: i + 1})
+		case len(sections) > 0:
+			sections[len(sections)-1].data += line
+		}
+	}
+	return sections, nil
+}
+
+// sectionsComment returns the text before the first section of the golden
+// file goldenFile, or the empty string if it cannot be read.
+func sectionsComment(goldenFile string, o *options) string {
+	_, _, body, err := readGolden(goldenFile, o)
+	if err != nil {
+		return ""
+	}
+	lines := strings.SplitAfter(body, "\n")
+	for i, line := range lines {
+		if _, ok := sectionName(strings.TrimSuffix(line, "\n")); ok {
+			return strings.Join(lines[:i], "")
+		}
+	}
+	return body
+}
+
+// formatSections renders sections as a golden file.
+func formatSections(sections []Section) (string, error) {
+	buf := &bytes.Buffer{}
+	seen := map[string]bool{}
+	for _, s := range sections {
+		if name, ok := sectionName("-- " + s.Name + " --"); !ok || name != s.Name || strings.Contains(s.Name, "\n") {
+			return "", fmt.Errorf("invalid section name %q", s.Name)
+		}
+		if seen[s.Name] {
+			return "", fmt.Errorf("duplicate section %q", s.Name)
+		}
+		seen[s.Name] = true
+		data := withFinalNewline(s.Data)
+		for _, line := range strings.SplitAfter(data, "\n") {
+			if _, ok := sectionName(strings.TrimSuffix(line, "\n")); ok {
+				return "", fmt.Errorf("section %q contains a line that names a section: %q", s.Name, line)
+			}
+		}
+		fmt.Fprintf(buf, "-- %v --\n%v", s.Name, data)
+	}
+	return buf.String(), nil
+}
+
+// withFinalNewline returns s with a newline appended unless it is empty or
+// already ends in one.
+func withFinalNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestCompareSections(t *testing.T) {
+	stdout := Section{"stdout", "Wrote 3 files\n"}
+	stderr := Section{"stderr", "warning: no config found\nwarning: using defaults\n"}
+	config := Section{"config", "name: test"}
+	var tests = []struct {
+		sections []Section
+		want     string
+	}{
+		{[]Section{stdout, stderr, config}, ""},
+		// Order does not matter.
+		{[]Section{config, stderr, stdout}, ""},
+		{
+			[]Section{stdout, {"stderr", "warning: no config found\n"}, config},
+			`Actual data differs from golden data in sections stderr; run "go test -update_golden" to update
+testdata/sections.txt.golden:4: section "stderr" differs
+--- testdata/sections.txt.golden#stderr
++++ testdata/sections.txt.actual#stderr
+@@ -1,3 +1,2 @@
+ warning: no config found
+-warning: using defaults
+ 
+`,
+		},
+		{
+			[]Section{stdout, stderr, {"exit", "1"}},
+			`Actual data differs from golden data in sections config, exit; run "go test -update_golden" to update
+testdata/sections.txt.golden:7: section "config" is missing from the actual data
+testdata/sections.txt.golden: section "exit" is missing from the golden file
+`,
+		},
+	}
+	for _, test := range tests {
+		if got := CompareSections(test.sections, "github.com/google/golden/testdata/sections.txt.golden"); got != test.want {
+			t.Errorf("CompareSections(%v): got %q, want %q", test.sections, got, test.want)
+		}
+	}
+}
+
+func TestUpdateSections(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	CompareSections([]Section{{"stdout", "out"}, {"stderr", ""}, {"config", "a: b\n"}}, "sections.golden")
+	data, err := ioutil.ReadFile(env.Path("sections.golden"))
+	if want := "-- stdout --\nout\n-- stderr --\n-- config --\na: b\n"; string(data) != want || err != nil {
+		t.Errorf("golden file: got %q, %v want %q", data, err, want)
+	}
+	env.SetUpdating(false)
+	if diff := CompareSections([]Section{{"stdout", "out\n"}, {"stderr", ""}, {"config", "a: b\n"}}, "sections.golden"); diff != "" {
+		t.Errorf("CompareSections after update: %v", diff)
+	}
+
+	// The comment before the first section is kept.
+	if err := ioutil.WriteFile(env.Path("sections.golden"), []byte("Output of the tool.\n\n-- stdout --\nold\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env.SetUpdating(true)
+	CompareSections([]Section{{"stdout", "new\n"}}, "sections.golden")
+	data, err = ioutil.ReadFile(env.Path("sections.golden"))
+	if want := "Output of the tool.\n\n-- stdout --\nnew\n"; string(data) != want || err != nil {
+		t.Errorf("golden file with a comment: got %q, %v want %q", data, err, want)
+	}
+}
+
+func TestFormatSectionsErrors(t *testing.T) {
+	var tests = []struct {
+		sections []Section
+		want     string
+	}{
+		{[]Section{{"", "data"}}, `invalid section name ""`},
+		{[]Section{{" out", "data"}}, `invalid section name " out"`},
+		{[]Section{{"out", "a"}, {"out", "b"}}, `duplicate section "out"`},
+		{[]Section{{"out", "a\n-- err --\n"}}, `section "out" contains a line that names a section: "-- err --\n"`},
+	}
+	for _, test := range tests {
+		if _, err := formatSections(test.sections); err == nil || err.Error() != test.want {
+			t.Errorf("formatSections(%v): got %v want %v", test.sections, err, test.want)
+		}
+	}
+	if _, err := parseSections("-- a --\n-- a --\n"); err == nil || err.Error() != `line 2: duplicate section "a"` {
+		t.Errorf("parseSections with a duplicate section: got %v", err)
+	}
+}
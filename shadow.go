@@ -0,0 +1,95 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// shadowPath returns where -update_golden_dir writes the golden file found at
+// fullPath: at its path relative to the root of the repository, under the
+// -update_golden_dir directory.
+func shadowPath(goldenFile string, fullPath string, o *options) string {
+	// Cleaning the path as if it were absolute keeps the file inside the
+	// directory.
+	return filepath.Join(updateGoldenDir.Load(), filepath.FromSlash(filepath.Clean("/"+displayPath(goldenFile, fullPath, o))))
+}
+
+// writeShadowGolden writes the result of calling contents on the previous
+// contents of the golden file at fullPath to shadow, leaving fullPath alone.
+// Nothing is written if the golden file would not change.
+func writeShadowGolden(fullPath string, shadow string, contents func(previous string) string) (updateStatus, error) {
+	previous, err := ioutil.ReadFile(fullPath)
+	status := statusModified
+	switch {
+	case os.IsNotExist(err):
+		status = statusCreated
+	case err != nil:
+		return status, err
+	}
+	actual := contents(string(previous))
+	if status == statusModified && string(previous) == actual {
+		return statusUnchanged, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(shadow), 0770); err != nil {
+		return status, err
+	}
+	return status, ioutil.WriteFile(shadow, []byte(actual), 0660)
+}
+
+// PromoteGoldens copies every file in the tree under shadowDir, as written by
+// tests run with -update_golden_dir, to the same relative path under the
+// current directory, which should be the root of the repository. It returns
+// the promoted files. Either all files are promoted or none is.
+func PromoteGoldens(shadowDir string) ([]string, error) {
+	updated := map[string]string{}
+	err := filepath.Walk(shadowDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%v is not a regular file", p)
+		}
+		rel, err := filepath.Rel(shadowDir, p)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		updated[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(updated))
+	for f := range updated {
+		if err := os.MkdirAll(filepath.Dir(filepath.FromSlash(f)), 0770); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, replaceFiles(files, updated)
+}
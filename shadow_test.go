@@ -0,0 +1,89 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateGoldenDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	repo, shadow := filepath.Join(dir, "repo"), filepath.Join(dir, "shadow")
+	for name, contents := range map[string]string{".git/HEAD": "", "pkg/testdata/changed.golden": "old\n", "pkg/testdata/same.golden": "same\n"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(repo, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original := updateGoldenDir.Load()
+	defer func() { updateGoldenDir.Store(original) }()
+	updateGoldenDir.Store(shadow)
+	defer resetUpdatesForTest()()
+
+	if !Updating() {
+		t.Errorf("Updating() with -update_golden_dir: got false")
+	}
+	testdata := filepath.Join(repo, "pkg/testdata")
+	Compare("new\n", filepath.Join(testdata, "changed.golden"))
+	Compare("same\n", filepath.Join(testdata, "same.golden"))
+	Compare("created\n", filepath.Join(testdata, "created.golden"))
+
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return "<" + err.Error() + ">"
+		}
+		return string(data)
+	}
+	if got := read(filepath.Join(testdata, "changed.golden")); got != "old\n" {
+		t.Errorf("golden file updated in place: got %q", got)
+	}
+	for name, want := range map[string]string{"changed.golden": "new\n", "created.golden": "created\n"} {
+		if got := read(filepath.Join(shadow, "pkg/testdata", name)); got != want {
+			t.Errorf("shadow copy of %v: got %q want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(shadow, "pkg/testdata/same.golden")); !os.IsNotExist(err) {
+		t.Errorf("unchanged golden file copied to the shadow directory")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	files, err := PromoteGoldens(shadow)
+	if want := []string{"pkg/testdata/changed.golden", "pkg/testdata/created.golden"}; err != nil || !reflect.DeepEqual(files, want) {
+		t.Errorf("PromoteGoldens: got %v, %v, want %v", files, err, want)
+	}
+	for name, want := range map[string]string{"changed.golden": "new\n", "created.golden": "created\n", "same.golden": "same\n"} {
+		if got := read(filepath.Join(testdata, name)); got != want {
+			t.Errorf("%v after PromoteGoldens: got %q want %q", name, got, want)
+		}
+	}
+}
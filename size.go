@@ -0,0 +1,60 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+)
+
+// defaultMaxSize is the size of actual data above which comparisons fail
+// unless WithAllowLarge is passed. It is half of what most Git hosts accept
+// in a single file.
+const defaultMaxSize = 50 << 20
+
+// WithMaxSize makes comparisons fail as soon as the actual data is larger
+// than n bytes, instead of the default of 50 MiB, so that a buggy generator
+// cannot write a huge golden file that then gets committed. It is often
+// passed to SetDefaultOptions to lower the limit for a whole package.
+// Digest and pointer golden files, which keep their data out of the
+// repository, have no limit.
+func WithMaxSize(n int64) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithAllowLarge lifts the limit set with WithMaxSize, or the default one,
+// for golden files that are meant to be huge.
+func WithAllowLarge() Option {
+	return func(o *options) {
+		o.allowLarge = true
+	}
+}
+
+// checkSize returns an error if actual is too large to be compared to or
+// written to goldenFile.
+func (o *options) checkSize(goldenFile string, actual string) error {
+	if o.allowLarge || isDigestGolden(goldenFile) || isPointerGolden(goldenFile) {
+		return nil
+	}
+	limit := o.maxSize
+	if limit == 0 {
+		limit = defaultMaxSize
+	}
+	if int64(len(actual)) <= limit {
+		return nil
+	}
+	return categorize(fmt.Errorf("actual data for %v is %d bytes, more than the limit of %d; pass WithAllowLarge if it is meant to be this large, or use a %v or %v file", goldenFile, len(actual), limit, digestGoldenSuffix, pointerGoldenSuffix), ErrTooLarge)
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckSize(t *testing.T) {
+	var tests = []struct {
+		goldenFile string
+		size       int
+		opts       []Option
+		want       bool
+	}{
+		{"small.golden", 10, nil, false},
+		{"big.golden", defaultMaxSize + 1, nil, true},
+		{"big.golden", defaultMaxSize + 1, []Option{WithAllowLarge()}, false},
+		{"big.golden.sha256", defaultMaxSize + 1, nil, false},
+		{"big.golden.ptr", defaultMaxSize + 1, nil, false},
+		{"limited.golden", 10, []Option{WithMaxSize(10)}, false},
+		{"limited.golden", 11, []Option{WithMaxSize(10)}, true},
+		{"limited.golden", 11, []Option{WithMaxSize(10), WithAllowLarge()}, false},
+	}
+	for _, test := range tests {
+		err := newOptions(test.opts).checkSize(test.goldenFile, strings.Repeat("x", test.size))
+		if got := errors.Is(err, ErrTooLarge); got != test.want {
+			t.Errorf("checkSize(%v, %d bytes): got %v, want too large: %v", test.goldenFile, test.size, err, test.want)
+		}
+	}
+}
+
+func TestCompareTooLarge(t *testing.T) {
+	env := TestEnv(t)
+	l := &recordingLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	env.SetUpdating(true)
+	Compare("0123456789", "huge.golden", WithMaxSize(4))
+	if _, err := os.Stat(env.Path("huge.golden")); !os.IsNotExist(err) {
+		t.Errorf("golden file written although the actual data is too large: %v", err)
+	}
+	env.SetUpdating(false)
+	Compare("0123456789", "huge.golden", WithMaxSize(4))
+	if len(l.errors) != 2 || !strings.Contains(l.errors[1], "10 bytes, more than the limit of 4") {
+		t.Errorf("Compare with too much actual data: got errors %q", l.errors)
+	}
+	if r := Check("0123456789", "huge.golden", WithMaxSize(4)); !errors.Is(r.Err(), ErrTooLarge) {
+		t.Errorf("Check with too much actual data: got error %v, want %v", r.Err(), ErrTooLarge)
+	}
+}
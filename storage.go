@@ -0,0 +1,171 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// A Storage holds golden data by key, outside of the repository. It is used
+// for the data of pointer golden files (see WithBlobStorage), and can stand
+// in for the file system altogether (see WithStorage).
+type Storage interface {
+	// Read returns the data stored under key. The error satisfies
+	// os.IsNotExist if there is none.
+	Read(key string) ([]byte, error)
+	// Write stores data under key, replacing any previous data.
+	Write(key string, data []byte) error
+}
+
+// DirStorage returns a Storage keeping each blob in a file under dir, such as
+// a shared cache directory. Keys must be slash-separated relative paths that
+// stay within dir.
+func DirStorage(dir string) Storage {
+	return dirStorage{dir}
+}
+
+type dirStorage struct {
+	dir string
+}
+
+// path returns the file holding the blob stored under key.
+func (s dirStorage) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q: not a relative path within %v", key, s.dir)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s dirStorage) Read(key string) ([]byte, error) {
+	fullPath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(fullPath)
+}
+
+func (s dirStorage) Write(key string, data []byte) error {
+	fullPath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0770); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fullPath, data, 0660)
+}
+
+// HTTPStorage returns a Storage keeping each blob at baseURL+"/"+key, with
+// each slash-separated segment of key escaped, read with GET and written with
+// PUT requests sent through client. If client is
+// nil, http.DefaultClient is used. The Storage is a ContextStorage.
+func HTTPStorage(baseURL string, client *http.Client) Storage {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return httpStorage{strings.TrimSuffix(baseURL, "/"), client}
+}
+
+// GCSStorage returns a Storage keeping each blob as an object in the given
+// Google Cloud Storage bucket. client must authorize its requests, for
+// example one returned by golang.org/x/oauth2/google.DefaultClient.
+func GCSStorage(bucket string, client *http.Client) Storage {
+	return HTTPStorage("https://storage.googleapis.com/"+bucket, client)
+}
+
+type httpStorage struct {
+	baseURL string
+	client  *http.Client
+}
+
+// url returns the URL of the blob stored under key.
+func (s httpStorage) url(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.baseURL + "/" + strings.Join(segments, "/")
+}
+
+func (s httpStorage) Read(key string) ([]byte, error) {
+	return s.ReadContext(context.Background(), key)
+}
+
+func (s httpStorage) ReadContext(ctx context.Context, key string) ([]byte, error) {
+	target := s.url(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &os.PathError{Op: "read", Path: target, Err: os.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("reading %v: %v", target, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (s httpStorage) Write(key string, data []byte) error {
+	return s.WriteContext(context.Background(), key, data)
+}
+
+func (s httpStorage) WriteContext(ctx context.Context, key string, data []byte) error {
+	target := s.url(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("writing %v: %v", target, resp.Status)
+	}
+	return nil
+}
+
+// writeStoredGolden is like writeGoldenFile for golden files kept in s.
+func writeStoredGolden(ctx context.Context, s Storage, key string, contents func(previous string) string) (updateStatus, error) {
+	previous, err := storageRead(ctx, s, key)
+	status := statusModified
+	switch {
+	case os.IsNotExist(err):
+		status = statusCreated
+	case err != nil:
+		return status, err
+	}
+	actual := contents(string(previous))
+	if status == statusModified && string(previous) == actual {
+		return statusUnchanged, nil
+	}
+	return status, storageWrite(ctx, s, key, []byte(actual))
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDirStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	s := DirStorage(dir)
+	if _, err := s.Read("a/b"); !os.IsNotExist(err) {
+		t.Errorf("Read of a missing key: got %v, want a not-exist error", err)
+	}
+	if err := s.Write("a/b", []byte("data")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, err := s.Read("a/b"); err != nil || string(got) != "data" {
+		t.Errorf("Read: got %q, %v, want %q", got, err, "data")
+	}
+	for _, key := range []string{"", "../outside", "a/../../outside", "/abs", "a//b", "./a"} {
+		if err := s.Write(key, []byte("data")); err == nil {
+			t.Errorf("Write(%q): got nil error", key)
+		}
+		if _, err := s.Read(key); err == nil || os.IsNotExist(err) {
+			t.Errorf("Read(%q): got %v, want an invalid key error", key, err)
+		}
+	}
+}
+
+// fakeServer is an HTTP server storing PUT request bodies by path.
+type fakeServer struct {
+	sync.Mutex
+	blobs map[string][]byte
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := s.blobs[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		s.blobs[r.URL.Path] = data
+	default:
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}
+}
+
+func TestHTTPStorage(t *testing.T) {
+	fake := &fakeServer{blobs: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s := HTTPStorage(server.URL+"/bucket/", nil)
+	if _, err := s.Read("a/b"); !os.IsNotExist(err) {
+		t.Errorf("Read of a missing key: got %v, want a not-exist error", err)
+	}
+	if err := s.Write("a/b", []byte("data")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, err := s.Read("a/b"); err != nil || string(got) != "data" {
+		t.Errorf("Read: got %q, %v, want %q", got, err, "data")
+	}
+	// Keys are escaped.
+	if err := s.Write("a/b c?d#e%f", []byte("escaped")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := string(fake.blobs["/bucket/a/b c?d#e%f"]); got != "escaped" {
+		t.Errorf("blob stored under a key needing escaping: got %q, want %q", got, "escaped")
+	}
+	if got, err := s.Read("a/b c?d#e%f"); err != nil || string(got) != "escaped" {
+		t.Errorf("Read of a key needing escaping: got %q, %v, want %q", got, err, "escaped")
+	}
+
+	forbidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer forbidden.Close()
+	s = HTTPStorage(forbidden.URL, nil)
+	if _, err := s.Read("a"); err == nil || os.IsNotExist(err) {
+		t.Errorf("Read from a failing server: got %v, want an error", err)
+	}
+	if err := s.Write("a", nil); err == nil {
+		t.Errorf("Write to a failing server: got nil error")
+	}
+}
+
+func TestCompareWithStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	storage := DirStorage(dir)
+	defer resetUpdatesForTest()()
+
+	// The search roots are empty, so only the storage can be used.
+	restoreFunc := enableUpdateGoldenForTest(dir + "/nonexistent")
+	Compare("hello\n", "shared/greeting.golden", WithStorage(storage))
+	restoreFunc()
+	if got := UpdateSummary(); !strings.HasPrefix(got, "Golden update summary: 1 created, 0 modified, 0 unchanged") {
+		t.Errorf("UpdateSummary: got %q", got)
+	}
+
+	if got := Compare("hello\n", "shared/greeting.golden", WithStorage(storage)); got != "" {
+		t.Errorf("Compare with matching data: got %q, want no diff", got)
+	}
+	r := Check("goodbye\n", "shared/greeting.golden", WithStorage(storage))
+	if r.Equal() || r.GoldenPath() != "shared/greeting.golden" {
+		t.Errorf("Check with different data: got equal %v and golden path %q", r.Equal(), r.GoldenPath())
+	}
+	if err := Check("", "shared/missing.golden", WithStorage(storage)).Err(); !os.IsNotExist(err) {
+		t.Errorf("Check of a missing golden file: got %v, want a not-exist error", err)
+	}
+}
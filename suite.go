@@ -0,0 +1,210 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Suite checks the golden files of a test binary as a whole. Tests compare
+// their data through the Suite, which keeps track of every golden file they
+// use, and once all tests have run it reports how many comparisons failed,
+// golden files under its directory that no test used, and tests that updated
+// the same golden file with different contents. Create one in TestMain:
+//
+//     var suite = golden.NewSuite("testdata")
+//
+//     func TestMain(m *testing.M) {
+//       os.Exit(suite.Run(m))
+//     }
+//
+//     func TestRender(t *testing.T) {
+//       if diff := suite.Compare(render(), "./testdata/render.golden"); diff != "" {
+//         t.Error(diff)
+//       }
+//     }
+type Suite struct {
+	// dir is the absolute path of the directory checked for unused golden
+	// files, or empty.
+	dir  string
+	opts []Option
+
+	mu                            sync.Mutex
+	compared, mismatched, updated int
+	used                          map[string]bool
+	conflicts                     []string
+}
+
+// NewSuite returns a Suite whose comparisons pass opts on to Compare. Golden
+// files under dir, a path relative to the package directory, that no test
+// compares against are reported as unused; pass the empty string to skip
+// that check. It is also skipped when only some tests run, as with -run.
+func NewSuite(dir string, opts ...Option) *Suite {
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+	return &Suite{dir: dir, opts: opts, used: map[string]bool{}}
+}
+
+// Compare compares actual to goldenFile like the package-level Compare, and
+// records the comparison for the report. Conflicting updates are reported as
+// a mismatch of the calling test rather than by exiting the test binary.
+func (s *Suite) Compare(actual string, goldenFile string, opts ...Option) string {
+	o := newOptions(append(append([]Option(nil), s.opts...), opts...))
+	goldenFile = o.prefixed(goldenFile)
+	if shouldUpdateGolden() {
+		err := writeGolden(goldenFile, actual, o)
+		if conflict, ok := err.(*conflictError); ok {
+			s.record(goldenFile, o, func() { s.conflicts = append(s.conflicts, conflict.Error()) })
+			return fmt.Sprintf("Error while updating golden file: %v\n", conflict)
+		}
+		if err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		s.record(goldenFile, o, func() { s.updated++ })
+		return ""
+	}
+	r := check(actual, goldenFile, o)
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	msg := r.String()
+	s.record(r.goldenPath, o, func() {
+		if msg != "" {
+			s.mismatched++
+		}
+	})
+	return msg
+}
+
+// record counts a comparison with goldenFile, marks it as used and calls f
+// with the Suite locked.
+func (s *Suite) record(goldenFile string, o *options, f func()) {
+	fullPath := goldenFile
+	if o.storage == nil && !filepath.IsAbs(fullPath) {
+		if p, err := getFullPathForWrite(goldenFile); err == nil {
+			fullPath = p
+		}
+		if abs, err := filepath.Abs(fullPath); err == nil {
+			fullPath = abs
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.compared++
+	s.used[filepath.Clean(fullPath)] = true
+	f()
+}
+
+// Finalize returns a report on the comparisons made through the Suite, such
+// as
+//
+//     Golden suite: 12 comparisons, 1 mismatched, 0 updated
+//       unused:   testdata/old.golden
+//
+// It returns an error if there are unused golden files or conflicting
+// updates. It is meant to be called once all tests have run; Run does so.
+func (s *Suite) Finalize() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Golden suite: %d comparisons, %d mismatched, %d updated\n", s.compared, s.mismatched, s.updated)
+	var unused []string
+	if s.dir != "" && !runsSomeTests() {
+		var err error
+		if unused, err = s.unusedGoldenFiles(); err != nil {
+			return buf.String(), fmt.Errorf("listing golden files: %v", err)
+		}
+	}
+	for _, p := range unused {
+		fmt.Fprintf(buf, "  %-10v %v\n", "unused:", p)
+	}
+	for _, c := range s.conflicts {
+		fmt.Fprintf(buf, "  %-10v %v\n", "conflict:", c)
+	}
+	if len(unused) > 0 || len(s.conflicts) > 0 {
+		return buf.String(), fmt.Errorf("%d unused golden files and %d conflicting updates", len(unused), len(s.conflicts))
+	}
+	return buf.String(), nil
+}
+
+// unusedGoldenFiles returns the golden files under the Suite's directory that
+// were not compared against, relative to the current directory if they are
+// under it.
+// s.mu must be held.
+func (s *Suite) unusedGoldenFiles() ([]string, error) {
+	var unused []string
+	err := filepath.Walk(s.dir, func(p string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && p == s.dir {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || !isGoldenFileName(p) || s.used[filepath.Clean(p)] {
+			return nil
+		}
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, p); err == nil && !strings.HasPrefix(rel, "..") {
+				p = rel
+			}
+		}
+		unused = append(unused, filepath.ToSlash(p))
+		return nil
+	})
+	sort.Strings(unused)
+	return unused, err
+}
+
+// isGoldenFileName reports whether p names a golden file of any kind.
+func isGoldenFileName(p string) bool {
+	return isGoldenFile(p) || strings.Contains(filepath.Base(p), ".approved.")
+}
+
+// runsSomeTests reports whether go test was asked to run only some tests, in
+// which case golden files used by the other tests would look unused.
+func runsSomeTests() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the tests in m as the package-level Run does, then prints the
+// report of Finalize to stderr. It returns a failing exit code if the tests
+// failed or Finalize returned an error.
+func (s *Suite) Run(m interface{ Run() int }) int {
+	code := Run(m)
+	report, err := s.Finalize()
+	fmt.Fprint(os.Stderr, report)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Golden suite failed: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	env := TestEnv(t)
+	files := map[string]string{
+		"testdata/a.golden":      "a\n",
+		"testdata/b.golden":      "b\n",
+		"testdata/old.golden":    "old\n",
+		"testdata/old.golden.re": "old\n",
+		"testdata/README":        "not a golden file\n",
+	}
+	for name, data := range files {
+		if err := os.MkdirAll(filepath.Dir(env.Path(name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(env.Path(name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unused := []string{filepath.ToSlash(env.Path("testdata/old.golden")), filepath.ToSlash(env.Path("testdata/old.golden.re"))}
+
+	// Unused golden files are only reported when all tests run.
+	for _, name := range []string{"test.run", "test.skip"} {
+		original := flag.Lookup(name).Value.String()
+		flag.Set(name, "")
+		defer flag.Set(name, original)
+	}
+
+	s := NewSuite(env.Path("testdata"))
+	if diff := s.Compare("a\n", "testdata/a.golden"); diff != "" {
+		t.Errorf("Suite.Compare of equal data: %v", diff)
+	}
+	if diff := s.Compare("c\n", "testdata/b.golden"); diff == "" {
+		t.Errorf("Suite.Compare of different data: got no diff")
+	}
+	report, err := s.Finalize()
+	want := "Golden suite: 2 comparisons, 1 mismatched, 0 updated\n" +
+		"  unused:    " + unused[0] + "\n" +
+		"  unused:    " + unused[1] + "\n"
+	if report != want || err == nil {
+		t.Errorf("Finalize: got %q, %v want %q and an error", report, err, want)
+	}
+
+	// Conflicting updates are reported instead of exiting.
+	env.SetUpdating(true)
+	writers.byTarget[env.Path("testdata/b.golden")] = updateWriter{test: "TestOther", actual: "b\n"}
+	if diff := s.Compare("c\n", "testdata/b.golden"); !strings.Contains(diff, "conflicting updates") {
+		t.Errorf("Suite.Compare with a conflicting update: got %q", diff)
+	}
+	s.Compare("old\n", "testdata/old.golden")
+	s.Compare("old\n", "testdata/old.golden.re")
+	report, err = s.Finalize()
+	want = "Golden suite: 5 comparisons, 1 mismatched, 2 updated\n" +
+		"  conflict:  conflicting updates of " + env.Path("testdata/b.golden") + ": TestOther and TestSuite produce different contents\n"
+	if report != want || err == nil || err.Error() != "0 unused golden files and 1 conflicting updates" {
+		t.Errorf("Finalize: got %q, %v want %q and an error", report, err, want)
+	}
+
+	flag.Set("test.run", "TestSuite")
+	if report, _ := NewSuite(env.Path("testdata")).Finalize(); strings.Contains(report, "unused") {
+		t.Errorf("Finalize with -run: got %q, want no unused golden files", report)
+	}
+
+	// Without a directory, there is nothing to report.
+	if _, err := NewSuite("").Finalize(); err != nil {
+		t.Errorf("Finalize of an empty Suite: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// updateStatus describes what updating a single golden file did to it.
+type updateStatus int
+
+// The statuses are ordered from the strongest to the weakest.
+const (
+	statusCreated updateStatus = iota
+	statusModified
+	statusUnchanged
+)
+
+func (s updateStatus) String() string {
+	switch s {
+	case statusCreated:
+		return "created"
+	case statusModified:
+		return "modified"
+	case statusUnchanged:
+		return "unchanged"
+	}
+	return fmt.Sprintf("updateStatus(%d)", int(s))
+}
+
+var updates = struct {
+	sync.Mutex
+	byFile map[string]updateStatus
+}{byFile: map[string]updateStatus{}}
+
+// recordUpdate records what updating goldenFile did to it. Of several updates
+// of the same file, the summary reports the strongest: a file created and then
+// updated again was still created.
+func recordUpdate(goldenFile string, status updateStatus) {
+	updates.Lock()
+	defer updates.Unlock()
+	if previous, ok := updates.byFile[goldenFile]; ok && previous < status {
+		status = previous
+	}
+	updates.byFile[goldenFile] = status
+}
+
+// updateWriter records which test last updated a golden file, and with what.
+// Since subtests share the name of their outermost test function, they are
+// told apart by the goroutine that t.Run starts for each of them.
+type updateWriter struct {
+	test      string
+	goroutine uint64
+	actual    string
+}
+
+var writers = struct {
+	sync.Mutex
+	byTarget map[string]updateWriter
+}{byTarget: map[string]updateWriter{}}
+
+// claimUpdate records that the calling test is about to update target, the
+// full path or storage key of a golden file, with actual. It returns an error
+// naming both tests if a different test or subtest already updated target
+// with different contents during this run, since one of the two updates would
+// otherwise be silently lost.
+func claimUpdate(target string, actual string) error {
+	w := updateWriter{test: callingTest(), goroutine: currentGoroutine(), actual: actual}
+	writers.Lock()
+	defer writers.Unlock()
+	previous, ok := writers.byTarget[target]
+	if ok && (previous.test != w.test || previous.goroutine != w.goroutine) && previous.actual != actual {
+		return &conflictError{target, previous.test, w.test}
+	}
+	writers.byTarget[target] = w
+	return nil
+}
+
+// A conflictError reports that two tests, or two subtests of first if second
+// is the same, updated the same golden file with different contents.
+type conflictError struct {
+	target        string
+	first, second string
+}
+
+// Is makes conflicting updates match ErrUpdateRefused.
+func (e *conflictError) Is(target error) bool {
+	return target == ErrUpdateRefused
+}
+
+func (e *conflictError) Error() string {
+	if e.first == e.second {
+		return fmt.Sprintf("conflicting updates of %v: two subtests of %v produce different contents", e.target, testOrUnknown(e.first))
+	}
+	return fmt.Sprintf("conflicting updates of %v: %v and %v produce different contents", e.target, testOrUnknown(e.first), testOrUnknown(e.second))
+}
+
+func testOrUnknown(test string) string {
+	if test == "" {
+		return "<unknown test>"
+	}
+	return test
+}
+
+func resetUpdatesForTest() func() {
+	updates.Lock()
+	writers.Lock()
+	defer updates.Unlock()
+	defer writers.Unlock()
+	originalUpdates, originalWriters := updates.byFile, writers.byTarget
+	updates.byFile = map[string]updateStatus{}
+	writers.byTarget = map[string]updateWriter{}
+	return func() {
+		updates.Lock()
+		writers.Lock()
+		defer updates.Unlock()
+		defer writers.Unlock()
+		updates.byFile, writers.byTarget = originalUpdates, originalWriters
+	}
+}
+
+// UpdateSummary returns a report listing every golden file that was created,
+// modified or left unchanged by -update_golden so far in this process. It
+// returns the empty string if no golden file has been updated.
+func UpdateSummary() string {
+	updates.Lock()
+	defer updates.Unlock()
+	if len(updates.byFile) == 0 {
+		return ""
+	}
+	byStatus := map[updateStatus][]string{}
+	for goldenFile, status := range updates.byFile {
+		byStatus[status] = append(byStatus[status], goldenFile)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Golden update summary: %d created, %d modified, %d unchanged\n",
+		len(byStatus[statusCreated]), len(byStatus[statusModified]), len(byStatus[statusUnchanged]))
+	for _, status := range []updateStatus{statusCreated, statusModified, statusUnchanged} {
+		files := byStatus[status]
+		sort.Strings(files)
+		for _, goldenFile := range files {
+			fmt.Fprintf(buf, "  %-10v %v\n", status.String()+":", goldenFile)
+		}
+	}
+	return buf.String()
+}
+
+// Run runs the tests in m and returns its exit code. Unless another package
+// already defined it, Run first registers -update as a short alias of
+// -update_golden. While the tests run, updates of golden files are buffered, so
+// that each file is written only once, when all tests have finished, no matter
+// how many tests update it; tests reading it in the meantime see its new
+// contents. Errors reported with Fatalf write the buffered updates first, in
+// case the Logger exits, but those of a test binary that panics or calls
+// os.Exit are lost. If -update_golden is set, Run then prints UpdateSummary to
+// stderr. If the GOLDEN_READ_REPORT environment variable is set, it appends the
+// golden files read by the tests to the file it names, for use with AuditReads.
+// It is meant to be called from TestMain:
+//
+//     func TestMain(m *testing.M) {
+//       os.Exit(golden.Run(m))
+//     }
+func Run(m interface{ Run() int }) int {
+	// The alias is registered this late so that flags defined by the
+	// packages under test, which are initialized after this one, take
+	// precedence instead of panicking.
+	if !flag.Parsed() {
+		registerUpdateAlias(flag.CommandLine)
+	}
+	bufferWrites()
+	code := m.Run()
+	if err := flushWrites(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while updating golden files: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	if shouldUpdateGolden() {
+		fmt.Fprint(os.Stderr, UpdateSummary())
+	}
+	if err := writeReadReport(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while writing golden read report: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,133 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	for name, contents := range map[string]string{"b.golden": "same", "c.golden": "old"} {
+		if err := ioutil.WriteFile(path.Join(dir, "src/fake/testdata", name), []byte(contents), 0600); err != nil {
+			t.Fatalf("Cannot write fake golden file: %v", err)
+		}
+	}
+	restoreFunc := enableUpdateGoldenForTest(dir)
+	defer restoreFunc()
+	defer resetUpdatesForTest()()
+
+	if got := UpdateSummary(); got != "" {
+		t.Errorf("UpdateSummary() before any update: got %q, want empty", got)
+	}
+	Compare("new", "fake/testdata/a.golden")
+	Compare("same", "fake/testdata/b.golden")
+	Compare("new", "fake/testdata/c.golden")
+	Compare("new", "fake/testdata/d.golden")
+	// Updating the same files again does not weaken their status.
+	Compare("new", "fake/testdata/a.golden")
+	Compare("new", "fake/testdata/c.golden")
+	want := `Golden update summary: 2 created, 1 modified, 1 unchanged
+  created:   fake/testdata/a.golden
+  created:   fake/testdata/d.golden
+  modified:  fake/testdata/c.golden
+  unchanged: fake/testdata/b.golden
+`
+	if got := UpdateSummary(); got != want {
+		t.Errorf("UpdateSummary(): got %q, want %q", got, want)
+	}
+}
+
+type fakeM struct {
+	ran bool
+}
+
+func (m *fakeM) Run() int {
+	m.ran = true
+	return 3
+}
+
+func TestRun(t *testing.T) {
+	m := &fakeM{}
+	if got, want := Run(m), 3; got != want {
+		t.Errorf("Run(): got %v, want %v", got, want)
+	}
+	if !m.ran {
+		t.Errorf("Run() did not run the tests")
+	}
+}
+
+func TestClaimUpdate(t *testing.T) {
+	defer resetUpdatesForTest()()
+	writers.byTarget["other.golden"] = updateWriter{test: "TestOther", actual: "old\n"}
+
+	tests := []struct {
+		target  string
+		actual  string
+		wantErr string
+	}{
+		{"new.golden", "a\n", ""},
+		{"new.golden", "b\n", ""}, // Same test.
+		{"other.golden", "old\n", ""},
+		{"other.golden", "new\n", "conflicting updates of other.golden: TestOther and TestClaimUpdate produce different contents"},
+	}
+	for _, tt := range tests {
+		if tt.target == "other.golden" {
+			writers.byTarget["other.golden"] = updateWriter{test: "TestOther", actual: "old\n"}
+		}
+		err := claimUpdate(tt.target, tt.actual)
+		if got := fmt.Sprint(err); (err != nil || tt.wantErr != "") && got != tt.wantErr {
+			t.Errorf("claimUpdate(%q, %q) = %v, want %v", tt.target, tt.actual, err, tt.wantErr)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// Subtests of a table-driven test run on goroutines of their own.
+	defer resetUpdatesForTest()()
+	for _, actual := range []string{"a\n", "b\n"} {
+		t.Run(strings.TrimSpace(actual), func(t *testing.T) {
+			err := claimUpdate("table.golden", actual)
+			if want := "conflicting updates of table.golden: two subtests of TestClaimUpdate produce different contents"; actual == "b\n" && fmt.Sprint(err) != want {
+				t.Errorf("claimUpdate from a second subtest: got %v, want %v", err, want)
+			}
+		})
+	}
+
+	goldenFile := filepath.Join(dir, "shared.golden")
+	writers.byTarget[goldenFile] = updateWriter{test: "TestOther", actual: "old\n"}
+	if err := writeGolden(goldenFile, "new\n", newOptions(nil)); err == nil || !strings.Contains(err.Error(), "TestOther and TestClaimUpdate") {
+		t.Errorf("writeGolden of a golden file updated by another test: got %v, want conflict", err)
+	}
+	if _, err := os.Stat(goldenFile); !os.IsNotExist(err) {
+		t.Errorf("writeGolden wrote %v despite the conflict", goldenFile)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tableGap matches the padding between the cells of a table row: a tab, or
+// at least two spaces, possibly mixed with tabs.
+var tableGap = regexp.MustCompile(`\t[ \t]*| [ \t]+`)
+
+// AlignTables is a Normalizer laying out whitespace-padded tables, such as
+// the output of text/tabwriter, in a canonical way: the cells of each row are
+// separated by exactly two spaces, without padding to a column width, and
+// trailing whitespace is removed. A table is a run of at least two
+// consecutive lines that each have a gap of a tab or several spaces after
+// their indentation; other lines are kept as they are. Widening a single
+// cell, which realigns its whole column, then only changes the line holding
+// it. Empty cells cannot be told apart from padding, so they are dropped.
+func AlignTables(s string) string {
+	lines := strings.Split(s, "\n")
+	for start := 0; start < len(lines); {
+		end := start
+		for end < len(lines) && isTableRow(lines[end]) {
+			end++
+		}
+		if end-start >= 2 {
+			for i := start; i < end; i++ {
+				lines[i] = alignTableRow(lines[i])
+			}
+		}
+		if end == start {
+			end++
+		}
+		start = end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WithAlignedTables makes the comparison ignore how the columns of tables are
+// padded, as described for AlignTables. Golden files are still written as
+// they are.
+func WithAlignedTables() Option {
+	return func(o *options) {
+		o.normalizers = append(o.normalizers, AlignTables)
+	}
+}
+
+// splitIndent splits line into its indentation and the rest.
+func splitIndent(line string) (indent, rest string) {
+	rest = strings.TrimLeft(line, " \t")
+	return line[:len(line)-len(rest)], rest
+}
+
+// isTableRow reports whether line has a gap between cells.
+func isTableRow(line string) bool {
+	_, rest := splitIndent(strings.TrimRight(line, " \t\r"))
+	return tableGap.MatchString(rest)
+}
+
+// alignTableRow returns line with its cells separated by two spaces.
+func alignTableRow(line string) string {
+	cr := ""
+	if strings.HasSuffix(line, "\r") {
+		line, cr = strings.TrimSuffix(line, "\r"), "\r"
+	}
+	indent, rest := splitIndent(strings.TrimRight(line, " \t"))
+	return indent + tableGap.ReplaceAllString(rest, "  ") + cr
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"strings"
+	"testing"
+	"text/tabwriter"
+)
+
+func TestAlignTables(t *testing.T) {
+	var tests = []struct {
+		desc, in, want string
+	}{
+		{
+			desc: "table",
+			in:   "NAME      READY   AGE\nweb-1     1/1     3d  \ndb        0/1     10m\n",
+			want: "NAME  READY  AGE\nweb-1  1/1  3d\ndb  0/1  10m\n",
+		},
+		{
+			desc: "tabs and indentation",
+			in:   "  a\tb\n  cc\t\td\n",
+			want: "  a  b\n  cc  d\n",
+		},
+		{
+			desc: "single line is not a table",
+			in:   "Done.  Next step\nplain text\n",
+			want: "Done.  Next step\nplain text\n",
+		},
+		{
+			desc: "table between prose",
+			in:   "Results:\nkey    value\nlonger value   x\n\nThe end.   Bye\r\n",
+			want: "Results:\nkey  value\nlonger value  x\n\nThe end.   Bye\r\n",
+		},
+		{
+			desc: "CRLF",
+			in:   "a   b\r\ncc  d\r\n",
+			want: "a  b\r\ncc  d\r\n",
+		},
+	}
+	for _, test := range tests {
+		if got := AlignTables(test.in); got != test.want {
+			t.Errorf("%v: AlignTables(%q): got %q, want %q", test.desc, test.in, got, test.want)
+		}
+	}
+}
+
+// tabulate formats rows with text/tabwriter.
+func tabulate(rows ...string) string {
+	buf := &strings.Builder{}
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		w.Write([]byte(row + "\n"))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+func TestCompareWithAlignedTables(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	Compare(tabulate("NAME\tSTATUS", "a\tok", "b\tok"), "table.golden")
+	env.SetUpdating(false)
+
+	widened := tabulate("NAME\tSTATUS", "a-much-longer-name\tok", "b\tok")
+	if got := Compare(widened, "table.golden", WithAlignedTables()); !strings.Contains(got, "-a  ok\n+a-much-longer-name  ok\n b  ok\n") || strings.Contains(got, "-NAME") {
+		t.Errorf("Compare of a widened table WithAlignedTables: got %q, want only the widened row to differ", got)
+	}
+	if got := Compare(tabulate("NAME\tSTATUS", "a\tok", "b\tok"), "table.golden", WithAlignedTables()); got != "" {
+		t.Errorf("Compare of the same table WithAlignedTables: got %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+)
+
+// A Template is a template such as a *text/template.Template or a
+// *html/template.Template.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// CompareTemplate executes tmpl with data and compares the result to
+// goldenFile like Compare. If executing the template fails, the error is
+// returned as the failure message.
+//
+// Whitespace that templates leave behind around actions is normalized in both
+// the golden and the actual data, and when updating the golden file: spaces
+// and tabs at the end of lines are dropped and the data ends with exactly one
+// newline. Runs of empty lines are collapsed into one, or dropped entirely if
+// tmpl is an *html/template.Template, since they do not matter in HTML.
+func CompareTemplate(tmpl Template, data interface{}, goldenFile string, opts ...Option) string {
+	_, isHTML := tmpl.(*htmltemplate.Template)
+	normalize := templateWhitespace(isHTML)
+	return CompareFunc(func() (string, error) {
+		buf := &strings.Builder{}
+		err := tmpl.Execute(buf, data)
+		return buf.String(), err
+	}, goldenFile, append(opts, func(o *options) {
+		o.normalizers = append(o.normalizers, normalize)
+		o.writeFormatters = append(o.writeFormatters, normalize)
+	})...)
+}
+
+// templateWhitespace returns a Normalizer for CompareTemplate, dropping empty
+// lines if html is set.
+func templateWhitespace(html bool) Normalizer {
+	return func(s string) string {
+		lines := strings.Split(s, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" && (html || len(kept) > 0 && kept[len(kept)-1] == "") {
+				continue
+			}
+			kept = append(kept, line)
+		}
+		return FinalNewline(strings.Join(kept, "\n"))
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	htmltemplate "html/template"
+	"io/ioutil"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+)
+
+func TestCompareTemplate(t *testing.T) {
+	env := TestEnv(t)
+	text := texttemplate.Must(texttemplate.New("text").Parse("Hello {{.}}  \n{{if false}}\n{{end}}\n\n\nBye"))
+	html := htmltemplate.Must(htmltemplate.New("html").Parse("<p>\n  {{.}}  \n\n</p>\n\n<br>\n"))
+	var tests = []struct {
+		tmpl       Template
+		goldenFile string
+		written    string
+	}{
+		{text, "text.golden", "Hello <world>\n\nBye\n"},
+		{html, "html.golden", "<p>\n  &lt;world&gt;\n</p>\n<br>\n"},
+	}
+	for _, test := range tests {
+		env.SetUpdating(true)
+		CompareTemplate(test.tmpl, "<world>", test.goldenFile)
+		if data, err := ioutil.ReadFile(env.Path(test.goldenFile)); string(data) != test.written || err != nil {
+			t.Errorf("%v: got %q, %v want %q", test.goldenFile, data, err, test.written)
+		}
+		env.SetUpdating(false)
+		if diff := CompareTemplate(test.tmpl, "<world>", test.goldenFile); diff != "" {
+			t.Errorf("CompareTemplate of %v after update: %v", test.goldenFile, diff)
+		}
+		if diff := CompareTemplate(test.tmpl, "<moon>", test.goldenFile); diff == "" {
+			t.Errorf("CompareTemplate of %v with other data: got no diff", test.goldenFile)
+		}
+	}
+
+	failing := texttemplate.Must(texttemplate.New("failing").Parse("{{.Missing}}"))
+	if got := CompareTemplate(failing, 42, "text.golden"); !strings.HasPrefix(got, "Error generating actual data for text.golden:") {
+		t.Errorf("CompareTemplate of a failing template: got %q", got)
+	}
+}
+
+func TestTemplateWhitespace(t *testing.T) {
+	var tests = []struct {
+		in         string
+		text, html string
+	}{
+		{"", "", ""},
+		{"a", "a\n", "a\n"},
+		{"a \t\n\n\n\nb\n\n", "a\n\nb\n", "a\nb\n"},
+		{"\r\n\na\r\n", "\na\n", "a\n"},
+	}
+	for _, test := range tests {
+		if got := templateWhitespace(false)(test.in); got != test.text {
+			t.Errorf("templateWhitespace(false)(%q): got %q want %q", test.in, got, test.text)
+		}
+		if got := templateWhitespace(true)(test.in); got != test.html {
+			t.Errorf("templateWhitespace(true)(%q): got %q want %q", test.in, got, test.html)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiEscape matches CSI sequences such as colors and cursor movements, OSC
+// sequences such as window titles and hyperlinks, and other two-character
+// escapes.
+var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// StripANSI is a Normalizer removing ANSI escape sequences, such as colors,
+// from terminal output.
+func StripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// CarriageReturns is a Normalizer keeping only what a terminal would finally
+// show of lines that are redrawn using carriage returns, such as progress
+// bars. Text after the last carriage return of a line replaces the text
+// before it.
+func CarriageReturns(s string) string {
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		if j := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); j >= 0 {
+			lines[i] = line[j+1:]
+		}
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// spinnerFrame matches a line starting with a frame of a common spinner
+// animation. ASCII spinners such as "|/-\" are not recognized, since they
+// cannot be told apart from list items or tables.
+var spinnerFrame = regexp.MustCompile(`^(\s*)(?:[\x{2800}-\x{28FF}]|[◐◓◑◒◴◷◶◵]) `)
+
+// CollapseSpinners is a Normalizer replacing the spinner frame at the start
+// of a line with "*", and dropping lines that then repeat the previous one,
+// so that output does not depend on how long an operation took.
+func CollapseSpinners(s string) string {
+	lines := strings.Split(s, "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		if spinnerFrame.MatchString(line) {
+			line = spinnerFrame.ReplaceAllString(line, "${1}* ")
+			if i > 0 && len(kept) > 0 && kept[len(kept)-1] == line {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Unwrap returns a Normalizer joining lines that a terminal width columns
+// wide wrapped, that is, lines exactly width characters long with the line
+// following them. This makes output of tools that wrap to the terminal width
+// comparable whatever the width it was produced with.
+func Unwrap(width int) Normalizer {
+	return func(s string) string {
+		lines := strings.Split(s, "\n")
+		var out []string
+		joined := false
+		for _, line := range lines {
+			if joined {
+				out[len(out)-1] += line
+			} else {
+				out = append(out, line)
+			}
+			joined = utf8.RuneCountInString(line) == width
+		}
+		return strings.Join(out, "\n")
+	}
+}
+
+// TerminalOutput returns a Normalizer for the output of command-line tools
+// meant for a terminal: it runs StripANSI, CarriageReturns and
+// CollapseSpinners, then Unwrap(width) unless width is 0.
+func TerminalOutput(width int) Normalizer {
+	pipeline := []Normalizer{StripANSI, CarriageReturns, CollapseSpinners}
+	if width > 0 {
+		pipeline = append(pipeline, Unwrap(width))
+	}
+	return func(s string) string {
+		for _, n := range pipeline {
+			s = n(s)
+		}
+		return s
+	}
+}
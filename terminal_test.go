@@ -0,0 +1,40 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import "testing"
+
+func TestTerminalNormalizers(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		n       Normalizer
+		in, out string
+	}{
+		{desc: "colors", n: StripANSI, in: "\x1b[1;31merror:\x1b[0m failed\n", out: "error: failed\n"},
+		{desc: "hyperlink", n: StripANSI, in: "\x1b]8;;http://x\x1b\\link\x1b]8;;\x07\n", out: "link\n"},
+		{desc: "cursor", n: StripANSI, in: "a\x1b[2K\x1b[1Gb", out: "ab"},
+		{desc: "progress", n: CarriageReturns, in: "10%\r50%\r100%\ndone\r\n", out: "100%\ndone\n"},
+		{desc: "spinner", n: CollapseSpinners, in: "⠋ Loading\n⠙ Loading\n⠹ Loading\n◐ Saving\ndone\n", out: "* Loading\n* Saving\ndone\n"},
+		{desc: "no spinner", n: CollapseSpinners, in: "- item\n- item\n", out: "- item\n- item\n"},
+		{desc: "long line", n: Unwrap(5), in: "abcdefghij\nxyz\nabc\n", out: "abcdefghij\nxyz\nabc\n"},
+		{desc: "wrap", n: Unwrap(5), in: "abcde\nfghij\nxyz\nabc\n", out: "abcdefghijxyz\nabc\n"},
+		{desc: "pipeline", n: TerminalOutput(4), in: "\x1b[32m⠋ wait\x1b[0m\r⠙ wait\nabcd\nef\n", out: "* wait\nabcdef\n"},
+	}
+	for _, test := range tests {
+		if got := test.n(test.in); got != test.out {
+			t.Errorf("%v: got %q want %q", test.desc, got, test.out)
+		}
+	}
+}
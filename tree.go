@@ -0,0 +1,146 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompareTree compares the files under actualDir, such as the output of a
+// code generator, to a manifest in goldenFile listing the path, size and
+// SHA-256 of each of them, sorted by path:
+//
+//     api/v1.json 1532 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//     api/v2.json 2048 60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752
+//
+// Checking a single small golden file cheaply verifies the structure and
+// contents of huge output trees, at the cost of not showing how the files
+// differ: the diff only tells which files were added, removed or changed. The
+// added and changed files are then saved to the directory given by the
+// -golden_artifacts_dir flag, where they can be inspected or compared to
+// their previous versions on demand. WithIncludeFiles and WithExcludeFiles
+// select the files listed, as for CompareDir.
+func CompareTree(actualDir string, goldenFile string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFile = o.prefixed(goldenFile)
+	actual, err := renderTree(actualDir, o)
+	if err != nil {
+		return Fatalf("Error while reading actual data: %v", err)
+	}
+	if shouldUpdateGolden() {
+		if err := writeGolden(goldenFile, actual, o); err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	r := check(actual, goldenFile, o)
+	if r.err != nil {
+		return Fatalf("Error while checking golden file: %v", r.err)
+	}
+	msg := r.String()
+	if msg == "" {
+		return ""
+	}
+	_, _, expected, err := readGolden(goldenFile, o)
+	if err != nil {
+		return Fatalf("Error while checking golden file: %v", err)
+	}
+	dir, n, err := saveTreeArtifacts(actualDir, goldenFile, changedTreeFiles(expected, actual))
+	if err != nil {
+		return msg + fmt.Sprintf("Error while saving the changed files: %v\n", err)
+	}
+	if n > 0 {
+		msg += fmt.Sprintf("The %d added or changed files were saved to %v\n", n, dir)
+	}
+	return msg
+}
+
+// renderTree returns the manifest of the files under dir, as described for
+// CompareTree.
+func renderTree(dir string, o *options) (string, error) {
+	files, err := listFiles(dir, "", o)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf := &strings.Builder{}
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(buf, "%v %d %x\n", name, len(data), sha256.Sum256(data))
+	}
+	return buf.String(), nil
+}
+
+// parseTree returns the size and hash of each path in a manifest written by
+// renderTree. Paths may contain spaces; sizes and hashes cannot.
+func parseTree(manifest string) map[string]string {
+	entries := map[string]string{}
+	for _, line := range strings.Split(manifest, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		sum := strings.Join(fields[len(fields)-2:], " ")
+		entries[strings.TrimSpace(strings.TrimSuffix(line, sum))] = sum
+	}
+	return entries
+}
+
+// changedTreeFiles returns the paths listed in the actual manifest that the
+// expected one lacks or lists with a different size or hash.
+func changedTreeFiles(expected, actual string) []string {
+	want := parseTree(expected)
+	var changed []string
+	for name, sum := range parseTree(actual) {
+		if want[name] != sum {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// saveTreeArtifacts copies the files under actualDir at the given paths to
+// the artifacts directory, and returns the directory and how many it copied.
+func saveTreeArtifacts(actualDir string, goldenFile string, names []string) (string, int, error) {
+	dir := filepath.Join(artifactsDirOrDefault(), filepath.Base(actualFileName(goldenFile)))
+	for i, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(actualDir, filepath.FromSlash(name)))
+		if err != nil {
+			return dir, i, err
+		}
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0770); err != nil {
+			return dir, i, err
+		}
+		if err := ioutil.WriteFile(p, data, 0660); err != nil {
+			return dir, i, err
+		}
+	}
+	return dir, len(names), nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChangedTreeFiles(t *testing.T) {
+	expected := "a.txt 1 aa\nb c.txt 2 bb\nd.txt 3 dd\n"
+	actual := "a.txt 1 aa\nb c.txt 2 cc\ne.txt 4 ee\n"
+	want := []string{"b c.txt", "e.txt"}
+	if got := changedTreeFiles(expected, actual); !reflect.DeepEqual(got, want) {
+		t.Errorf("changedTreeFiles: got %q, want %q", got, want)
+	}
+}
+
+func TestCompareTree(t *testing.T) {
+	env := TestEnv(t)
+	originalArtifactsDir := artifactsDir.Load()
+	defer func() { artifactsDir.Store(originalArtifactsDir) }()
+	artifactsDir.Store(env.Path("artifacts"))
+	actualDir := env.Path("out")
+	if err := os.MkdirAll(filepath.Join(actualDir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "hello\n", "sub/b.txt": "b\n", "skip.tmp": "x"} {
+		if err := ioutil.WriteFile(filepath.Join(actualDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := []Option{WithExcludeFiles("*.tmp")}
+
+	env.SetUpdating(true)
+	if got := CompareTree(actualDir, "tree.golden", opts...); got != "" {
+		t.Errorf("CompareTree with -update_golden: got %q", got)
+	}
+	env.SetUpdating(false)
+	want := "a.txt 6 5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03\n" +
+		"sub/b.txt 2 "
+	if got, _ := ioutil.ReadFile(env.Path("tree.golden")); !strings.HasPrefix(string(got), want) || strings.Count(string(got), "\n") != 2 {
+		t.Errorf("manifest: got %q, want two lines starting with %q", got, want)
+	}
+	if got := CompareTree(actualDir, "tree.golden", opts...); got != "" {
+		t.Errorf("CompareTree after updating: got %q, want no diff", got)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(actualDir, "sub/b.txt"), []byte("changed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got := CompareTree(actualDir, "tree.golden", opts...)
+	saved := filepath.Join(env.Path("artifacts"), "tree.actual")
+	if !strings.Contains(got, "-sub/b.txt 2 ") || !strings.Contains(got, "+sub/b.txt 8 ") ||
+		!strings.HasSuffix(got, "The 1 added or changed files were saved to "+saved+"\n") {
+		t.Errorf("CompareTree after changing a file: got %q", got)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(saved, "sub/b.txt")); string(data) != "changed\n" {
+		t.Errorf("saved file: got %q, want %q", data, "changed\n")
+	}
+	if _, err := os.Stat(filepath.Join(saved, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("unchanged file a.txt was saved: %v", err)
+	}
+}
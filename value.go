@@ -0,0 +1,322 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompareValue formats v as text and compares it to goldenFile like Compare.
+// The text is meant to stay the same across runs, platforms and Go versions,
+// unlike the output of fmt's %#v:
+//
+//     main.Config{
+//       Name: "api",
+//       Ports: []int{
+//         80,
+//         443,
+//       },
+//       Labels: map[string]string{
+//         "env": "prod",
+//         "team": "infra",
+//       },
+//       Parent: &main.Config{
+//         Name: "base",
+//         Ports: []int{},
+//         Labels: map[string]string(nil),
+//         Parent: nil,
+//       },
+//     }
+//
+// Map entries are sorted by key, pointers are followed and shown with a
+// leading "&", and a pointer or map that refers back to a value being
+// formatted is shown as "<cycle *T>" rather than followed forever. Floats are
+// formatted with the fewest digits that represent them exactly, with NaN and
+// infinities spelled out. Functions and channels are only shown as "<func>"
+// and "<chan T>", since their addresses change between runs.
+//
+// Types with noisy internals can be shown differently with
+// WithValueFormatter, and fields left out with WithoutUnexportedFields and
+// WithoutFields.
+func CompareValue(v interface{}, goldenFile string, opts ...Option) string {
+	return Compare(formatValue(v, newOptions(opts)), goldenFile, opts...)
+}
+
+// WithValueFormatter makes CompareValue show values of the type of example
+// as returned by format, for types whose fields make poor golden data:
+//
+//     golden.WithValueFormatter(time.Time{}, func(v interface{}) string {
+//       return v.(time.Time).Format(time.RFC3339)
+//     })
+//
+// The type must match exactly; a formatter for T also applies to the values
+// that *T pointers point to, but not to other types with the same
+// underlying type. Values of unexported fields are not passed to formatters,
+// since package reflect does not allow it.
+func WithValueFormatter(example interface{}, format func(interface{}) string) Option {
+	return func(o *options) {
+		if o.valueFormatters == nil {
+			o.valueFormatters = map[reflect.Type]func(interface{}) string{}
+		}
+		o.valueFormatters[reflect.TypeOf(example)] = format
+	}
+}
+
+// WithoutUnexportedFields makes CompareValue leave out the unexported fields
+// of structs.
+func WithoutUnexportedFields() Option {
+	return func(o *options) {
+		o.skipUnexported = true
+	}
+}
+
+// WithoutFields makes CompareValue leave out struct fields by name. A name
+// such as "ID" leaves out fields of that name in any struct, and one such as
+// "Config.ID" only those of structs whose type is named Config.
+func WithoutFields(names ...string) Option {
+	return func(o *options) {
+		if o.skipFields == nil {
+			o.skipFields = map[string]bool{}
+		}
+		for _, name := range names {
+			o.skipFields[name] = true
+		}
+	}
+}
+
+// formatValue formats v for CompareValue.
+func formatValue(v interface{}, o *options) string {
+	f := &valueFormatter{o: o, visiting: map[visit]bool{}}
+	f.format(reflect.ValueOf(v), 0)
+	f.buf.WriteString("\n")
+	return f.buf.String()
+}
+
+// A visit identifies a pointer or map being formatted, to detect cycles.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type valueFormatter struct {
+	buf      bytes.Buffer
+	o        *options
+	visiting map[visit]bool
+}
+
+// line starts a new line indented by depth levels.
+func (f *valueFormatter) line(depth int) {
+	f.buf.WriteString("\n")
+	f.buf.WriteString(strings.Repeat("  ", depth))
+}
+
+func (f *valueFormatter) format(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		f.buf.WriteString("nil")
+		return
+	}
+	if format, ok := f.o.valueFormatters[v.Type()]; ok && v.CanInterface() {
+		f.buf.WriteString(format(v.Interface()))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		f.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		f.buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f.buf.WriteString(formatFloat(v.Float(), v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		c, bits := v.Complex(), v.Type().Bits()/2
+		im := formatFloat(imag(c), bits)
+		if !strings.HasPrefix(im, "-") && !strings.HasPrefix(im, "+") {
+			im = "+" + im
+		}
+		fmt.Fprintf(&f.buf, "(%v%vi)", formatFloat(real(c), bits), im)
+	case reflect.String:
+		f.buf.WriteString(strconv.Quote(v.String()))
+	case reflect.Interface:
+		f.format(v.Elem(), depth)
+	case reflect.Ptr:
+		if v.IsNil() {
+			f.buf.WriteString("nil")
+			return
+		}
+		if !f.enter(v) {
+			fmt.Fprintf(&f.buf, "<cycle %v>", v.Type())
+			return
+		}
+		defer f.leave(v)
+		f.buf.WriteString("&")
+		f.format(v.Elem(), depth)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			fmt.Fprintf(&f.buf, "%v(nil)", v.Type())
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			fmt.Fprintf(&f.buf, "%v(%q)", v.Type(), v.Bytes())
+			return
+		}
+		fmt.Fprintf(&f.buf, "%v{", v.Type())
+		for i := 0; i < v.Len(); i++ {
+			f.line(depth + 1)
+			f.format(v.Index(i), depth+1)
+			f.buf.WriteString(",")
+		}
+		if v.Len() > 0 {
+			f.line(depth)
+		}
+		f.buf.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			fmt.Fprintf(&f.buf, "%v(nil)", v.Type())
+			return
+		}
+		if !f.enter(v) {
+			fmt.Fprintf(&f.buf, "<cycle %v>", v.Type())
+			return
+		}
+		defer f.leave(v)
+		fmt.Fprintf(&f.buf, "%v{", v.Type())
+		keys := v.MapKeys()
+		sortValues(keys)
+		for _, k := range keys {
+			f.line(depth + 1)
+			f.format(k, depth+1)
+			f.buf.WriteString(": ")
+			f.format(v.MapIndex(k), depth+1)
+			f.buf.WriteString(",")
+		}
+		if len(keys) > 0 {
+			f.line(depth)
+		}
+		f.buf.WriteString("}")
+	case reflect.Struct:
+		fmt.Fprintf(&f.buf, "%v{", v.Type())
+		shown := 0
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if f.skipField(v.Type(), field) {
+				continue
+			}
+			f.line(depth + 1)
+			fmt.Fprintf(&f.buf, "%v: ", field.Name)
+			f.format(v.Field(i), depth+1)
+			f.buf.WriteString(",")
+			shown++
+		}
+		if shown > 0 {
+			f.line(depth)
+		}
+		f.buf.WriteString("}")
+	case reflect.Func:
+		if v.IsNil() {
+			f.buf.WriteString("nil")
+			return
+		}
+		f.buf.WriteString("<func>")
+	case reflect.Chan:
+		if v.IsNil() {
+			f.buf.WriteString("nil")
+			return
+		}
+		fmt.Fprintf(&f.buf, "<%v>", v.Type())
+	default:
+		fmt.Fprintf(&f.buf, "<%v>", v.Type())
+	}
+}
+
+// skipField reports whether field of the struct type t is left out.
+func (f *valueFormatter) skipField(t reflect.Type, field reflect.StructField) bool {
+	if f.o.skipUnexported && field.PkgPath != "" {
+		return true
+	}
+	return f.o.skipFields[field.Name] || t.Name() != "" && f.o.skipFields[t.Name()+"."+field.Name]
+}
+
+// enter records that the pointer or map v is being formatted, and reports
+// false if it already is.
+func (f *valueFormatter) enter(v reflect.Value) bool {
+	k := visit{v.Pointer(), v.Type()}
+	if f.visiting[k] {
+		return false
+	}
+	f.visiting[k] = true
+	return true
+}
+
+func (f *valueFormatter) leave(v reflect.Value) {
+	delete(f.visiting, visit{v.Pointer(), v.Type()})
+}
+
+// formatFloat formats a float of the given size with the fewest digits that
+// represent it exactly.
+func formatFloat(x float64, bits int) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, bits)
+}
+
+// sortValues sorts map keys: numbers and strings by value, false before true,
+// and other keys by their formatted text.
+func sortValues(keys []reflect.Value) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Kind() == reflect.Interface {
+			a, b = a.Elem(), b.Elem()
+		}
+		if a.IsValid() && b.IsValid() && a.Kind() == b.Kind() {
+			switch a.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return a.Int() < b.Int()
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return a.Uint() < b.Uint()
+			case reflect.Float32, reflect.Float64:
+				return a.Float() < b.Float() || math.IsNaN(a.Float()) && !math.IsNaN(b.Float())
+			case reflect.String:
+				return a.String() < b.String()
+			case reflect.Bool:
+				return !a.Bool() && b.Bool()
+			}
+		}
+		return sortKey(a) < sortKey(b)
+	})
+}
+
+// sortKey returns the text that keys of mixed or composite types are sorted
+// by.
+func sortKey(v reflect.Value) string {
+	f := &valueFormatter{o: &options{}, visiting: map[visit]bool{}}
+	if v.IsValid() {
+		fmt.Fprintf(&f.buf, "%v ", v.Type())
+	}
+	f.format(v, 0)
+	return f.buf.String()
+}
@@ -0,0 +1,168 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+type valueTestConfig struct {
+	Name   string
+	Ports  []int
+	Labels map[string]string
+	Parent *valueTestConfig
+}
+
+type valueTestNode struct {
+	Value int
+	next  *valueTestNode
+}
+
+func TestFormatValue(t *testing.T) {
+	cycle := &valueTestNode{Value: 1}
+	cycle.next = &valueTestNode{Value: 2, next: cycle}
+	shared := &valueTestNode{Value: 3}
+	var tests = []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "nil"},
+		{true, "true"},
+		{int8(-3), "-3"},
+		{uint64(math.MaxUint64), "18446744073709551615"},
+		{0.1, "0.1"},
+		{float32(0.1), "0.1"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+		{complex(1, -2.5), "(1-2.5i)"},
+		{"a\"b\n", `"a\"b\n"`},
+		{[]byte("hi"), `[]uint8("hi")`},
+		{[]int(nil), "[]int(nil)"},
+		{[]int{}, "[]int{}"},
+		{[2]bool{true, false}, "[2]bool{\n  true,\n  false,\n}"},
+		{map[int]string{10: "ten", 9: "nine", -1: "minus one"}, "map[int]string{\n  -1: \"minus one\",\n  9: \"nine\",\n  10: \"ten\",\n}"},
+		{map[interface{}]int{"b": 1, 2: 2, "a": 3}, "map[interface {}]int{\n  2: 2,\n  \"a\": 3,\n  \"b\": 1,\n}"},
+		{(*int)(nil), "nil"},
+		{func() {}, "<func>"},
+		{make(chan int), "<chan int>"},
+		{
+			&valueTestConfig{Name: "api", Ports: []int{80}, Labels: map[string]string{"team": "infra", "env": "prod"}, Parent: &valueTestConfig{Name: "base"}},
+			`&golden.valueTestConfig{
+  Name: "api",
+  Ports: []int{
+    80,
+  },
+  Labels: map[string]string{
+    "env": "prod",
+    "team": "infra",
+  },
+  Parent: &golden.valueTestConfig{
+    Name: "base",
+    Ports: []int(nil),
+    Labels: map[string]string(nil),
+    Parent: nil,
+  },
+}`,
+		},
+		{cycle, `&golden.valueTestNode{
+  Value: 1,
+  next: &golden.valueTestNode{
+    Value: 2,
+    next: <cycle *golden.valueTestNode>,
+  },
+}`},
+		// Values referenced twice without a cycle are shown twice.
+		{[]*valueTestNode{shared, shared}, `[]*golden.valueTestNode{
+  &golden.valueTestNode{
+    Value: 3,
+    next: nil,
+  },
+  &golden.valueTestNode{
+    Value: 3,
+    next: nil,
+  },
+}`},
+	}
+	for _, test := range tests {
+		if got := formatValue(test.v, newOptions(nil)); got != test.want+"\n" {
+			t.Errorf("formatValue(%#v): got %q want %q", test.v, got, test.want+"\n")
+		}
+	}
+}
+
+func TestFormatValueOptions(t *testing.T) {
+	type event struct {
+		ID   int
+		When time.Time
+		Node *valueTestNode
+		at   time.Time
+	}
+	when := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	v := event{ID: 7, When: when, Node: &valueTestNode{Value: 1}, at: when}
+	rfc3339 := WithValueFormatter(time.Time{}, func(v interface{}) string {
+		return v.(time.Time).Format(time.RFC3339)
+	})
+	var tests = []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"formatter and no unexported fields", []Option{rfc3339, WithoutUnexportedFields()}, `golden.event{
+  ID: 7,
+  When: 2017-03-01T12:00:00Z,
+  Node: &golden.valueTestNode{
+    Value: 1,
+  },
+}`},
+		{"fields", []Option{rfc3339, WithoutFields("ID", "valueTestNode.Value", "event.at", "other.When")}, `golden.event{
+  When: 2017-03-01T12:00:00Z,
+  Node: &golden.valueTestNode{
+    next: nil,
+  },
+}`},
+		{"all fields", []Option{rfc3339, WithoutFields("ID", "When", "Node", "at")}, "golden.event{}"},
+		{"pointer formatter", []Option{WithoutUnexportedFields(), WithoutFields("When"), WithValueFormatter(&valueTestNode{}, func(v interface{}) string {
+			return fmt.Sprintf("node %d", v.(*valueTestNode).Value)
+		})}, `golden.event{
+  ID: 7,
+  Node: node 1,
+}`},
+	}
+	for _, test := range tests {
+		if got := formatValue(v, newOptions(test.opts)); got != test.want+"\n" {
+			t.Errorf("%v: got %q want %q", test.name, got, test.want+"\n")
+		}
+	}
+}
+
+func TestCompareValue(t *testing.T) {
+	env := TestEnv(t)
+	v := map[string][]float64{"b": {1.5, 2}, "a": {math.Inf(1)}}
+	env.SetUpdating(true)
+	CompareValue(v, "value.golden")
+	env.SetUpdating(false)
+	for i := 0; i < 10; i++ {
+		if diff := CompareValue(v, "value.golden"); diff != "" {
+			t.Fatalf("CompareValue after update: %v", diff)
+		}
+	}
+	v["a"][0] = 1
+	if diff := CompareValue(v, "value.golden"); diff == "" {
+		t.Errorf("CompareValue of a changed value: got no diff")
+	}
+}
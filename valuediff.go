@@ -0,0 +1,88 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// CompareWithDiff compares got to the value kept as JSON in goldenFile using
+// diff, which returns an empty string if want and got are equal and a
+// description of their differences otherwise. This lets tests reuse the
+// options they already pass to github.com/google/go-cmp, such as
+// cmpopts.IgnoreFields or cmpopts.EquateApprox, for golden checks:
+//
+//     diff := golden.CompareWithDiff(got, ".../testdata/report.json.golden", func(want, got interface{}) string {
+//       return cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9))
+//     })
+//
+// The golden file is decoded into a new value of the same type as got, which
+// must therefore survive a round trip through encoding/json; unexported
+// fields, for example, are not kept. Since diff decides what counts as a
+// difference, the golden file is not compared as text, and a mismatch is
+// described by diff's output rather than by a unified diff.
+//
+// If the -update_golden flag is set, goldenFile is overwritten with got
+// encoded as indented JSON.
+func CompareWithDiff(got interface{}, goldenFile string, diff func(want, got interface{}) string, opts ...Option) string {
+	o := newOptions(opts)
+	goldenFile = o.prefixed(goldenFile)
+	if got == nil {
+		return Fatalf("Error while checking golden file %v: cannot compare a nil value", goldenFile)
+	}
+	if shouldUpdateGolden() {
+		actual, err := marshalValue(got)
+		if err == nil {
+			err = writeGolden(goldenFile, actual, o)
+		}
+		if err != nil {
+			return Fatalf("Error while updating golden file: %v", err)
+		}
+		return ""
+	}
+	fullPath, _, expected, err := readGolden(goldenFile, o)
+	if err != nil {
+		return Fatalf("Error while checking golden file: %v", err)
+	}
+	display := displayPath(goldenFile, fullPath, o)
+	want := reflect.New(reflect.TypeOf(got))
+	if err := json.Unmarshal([]byte(o.expandVariables(goldenFile, o.stripComments(expected))), want.Interface()); err != nil {
+		return Fatalf("Error while checking golden file %v: %v", display, err)
+	}
+	d := diff(want.Elem().Interface(), got)
+	if d == "" {
+		return ""
+	}
+	if !strings.HasSuffix(d, "\n") {
+		d += "\n"
+	}
+	return fmt.Sprintf("Actual value differs from golden value in %v; run %q to update\n%v", display, o.updateCommandOrDefault(), d)
+}
+
+// marshalValue encodes v as indented JSON for CompareWithDiff.
+func marshalValue(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	e := json.NewEncoder(buf)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		return "", fmt.Errorf("encoding actual value: %v", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+)
+
+type valueDiffReport struct {
+	Name  string
+	Score float64
+	Tags  map[string]bool
+}
+
+// approxDiff stands in for cmp.Diff with cmpopts.EquateApprox.
+func approxDiff(want, got interface{}) string {
+	w, g := want.(valueDiffReport), got.(valueDiffReport)
+	var diffs []string
+	if w.Name != g.Name {
+		diffs = append(diffs, fmt.Sprintf("Name: %q != %q", w.Name, g.Name))
+	}
+	if math.Abs(w.Score-g.Score) > 1e-6 {
+		diffs = append(diffs, fmt.Sprintf("Score: %v != %v", w.Score, g.Score))
+	}
+	return strings.Join(diffs, "\n")
+}
+
+func TestCompareWithDiff(t *testing.T) {
+	env := TestEnv(t)
+	report := valueDiffReport{Name: "q3", Score: 0.3000001, Tags: map[string]bool{"b": true, "a": false}}
+	env.SetUpdating(true)
+	CompareWithDiff(report, "report.json.golden", approxDiff)
+	data, err := ioutil.ReadFile(env.Path("report.json.golden"))
+	want := "{\n  \"Name\": \"q3\",\n  \"Score\": 0.3000001,\n  \"Tags\": {\n    \"a\": false,\n    \"b\": true\n  }\n}\n"
+	if string(data) != want || err != nil {
+		t.Errorf("golden file: got %q, %v want %q", data, err, want)
+	}
+
+	env.SetUpdating(false)
+	var tests = []struct {
+		got  valueDiffReport
+		want string
+	}{
+		{report, ""},
+		// Differences that diff ignores do not matter.
+		{valueDiffReport{Name: "q3", Score: 0.3}, ""},
+		{valueDiffReport{Name: "q4", Score: 0.5}, "Actual value differs from golden value in report.json.golden; run \"go test -update_golden\" to update\nName: \"q3\" != \"q4\"\nScore: 0.3000001 != 0.5\n"},
+	}
+	for _, test := range tests {
+		if got := CompareWithDiff(test.got, "report.json.golden", approxDiff); got != test.want {
+			t.Errorf("CompareWithDiff(%+v): got %q want %q", test.got, got, test.want)
+		}
+	}
+}
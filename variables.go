@@ -0,0 +1,196 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var registeredVariables struct {
+	sync.Mutex
+	byName map[string]string
+}
+
+// RegisterVariable sets the value that ${name} expands to in golden files
+// compared with WithVariables, such as a hostname that differs between
+// machines. It panics if name is not made of letters, digits and underscores.
+func RegisterVariable(name, value string) {
+	if !variableName.MatchString(name) {
+		panic("golden: RegisterVariable called with invalid name " + name)
+	}
+	registeredVariables.Lock()
+	defer registeredVariables.Unlock()
+	if registeredVariables.byName == nil {
+		registeredVariables.byName = map[string]string{}
+	}
+	registeredVariables.byName[name] = value
+}
+
+// WithVariables makes variables such as ${TESTDATA_DIR} in golden files
+// expand to their values before comparing, so that golden files embedding
+// absolute paths or hostnames stay portable across machines. TESTDATA_DIR is
+// the absolute path of the testdata directory of the package under test;
+// other variables are set with RegisterVariable or vars, which takes
+// precedence. References to unknown variables are left alone. Dollar signs
+// right before a "{" are doubled to stand for themselves: $${ stands for a
+// literal ${, and $$${HOME} for a dollar sign followed by the value of HOME.
+//
+// Updating a golden file replaces the values of the variables in the actual
+// data with references to them. Variables are not expanded in regexp, digest
+// and pointer golden files.
+func WithVariables(vars map[string]string) Option {
+	return func(o *options) {
+		if o.variables == nil {
+			o.variables = map[string]string{}
+		}
+		for name, value := range vars {
+			o.variables[name] = value
+		}
+	}
+}
+
+// expandsVariables reports whether variables are expanded in goldenFile.
+func (o *options) expandsVariables(goldenFile string) bool {
+	return o.variables != nil && !isRegexpGolden(goldenFile) && !isDigestGolden(goldenFile) && !isPointerGolden(goldenFile)
+}
+
+// variableValues returns the values of all variables by name.
+func (o *options) variableValues() map[string]string {
+	values := map[string]string{}
+	if dir, err := filepath.Abs("testdata"); err == nil {
+		values["TESTDATA_DIR"] = dir
+	}
+	registeredVariables.Lock()
+	for name, value := range registeredVariables.byName {
+		values[name] = value
+	}
+	registeredVariables.Unlock()
+	for name, value := range o.variables {
+		values[name] = value
+	}
+	return values
+}
+
+// expandVariables expands the variables in the golden data s of goldenFile.
+func (o *options) expandVariables(goldenFile string, s string) string {
+	if !o.expandsVariables(goldenFile) || !strings.Contains(s, "${") {
+		return s
+	}
+	values := o.variableValues()
+	buf := &strings.Builder{}
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			buf.WriteString(s)
+			return buf.String()
+		}
+		// Of a run of n dollar signs before "{", pairs stand for single
+		// dollar signs and an odd one out starts a reference.
+		start := i
+		for start > 0 && s[start-1] == '$' {
+			start--
+		}
+		n := i + 1 - start
+		buf.WriteString(s[:start] + strings.Repeat("$", n/2))
+		if n%2 == 0 {
+			buf.WriteString("{")
+			s = s[i+len("${"):]
+			continue
+		}
+		s = s[i:]
+		end := strings.Index(s, "}")
+		if end < 0 {
+			buf.WriteString(s)
+			return buf.String()
+		}
+		if value, ok := values[s[len("${"):end]]; ok {
+			buf.WriteString(value)
+		} else {
+			buf.WriteString(s[:end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// contractVariables replaces the values of variables in actual data with
+// references to them, escaping literal references, so that expandVariables
+// restores the data.
+func (o *options) contractVariables(goldenFile string, s string) string {
+	if !o.expandsVariables(goldenFile) {
+		return s
+	}
+	values := o.variableValues()
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	// Longer values are replaced first, so that a directory does not mask
+	// another within it.
+	sort.Slice(names, func(i, j int) bool {
+		if a, b := values[names[i]], values[names[j]]; len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return names[i] < names[j]
+	})
+	var first [256]bool
+	for _, name := range names {
+		first[values[name][0]] = true
+	}
+	buf := &strings.Builder{}
+	// dollars counts the dollar signs not written yet, which are doubled if
+	// followed by "{" or a reference, as expandVariables expects.
+	dollars := 0
+	flushDollars := func(double bool) {
+		if double {
+			dollars *= 2
+		}
+		buf.WriteString(strings.Repeat("$", dollars))
+		dollars = 0
+	}
+next:
+	for i := 0; i < len(s); {
+		if first[s[i]] {
+			for _, name := range names {
+				if strings.HasPrefix(s[i:], values[name]) {
+					flushDollars(true)
+					buf.WriteString("${" + name + "}")
+					i += len(values[name])
+					continue next
+				}
+			}
+		}
+		switch s[i] {
+		case '$':
+			dollars++
+		case '{':
+			flushDollars(true)
+			buf.WriteByte('{')
+		default:
+			flushDollars(false)
+			buf.WriteByte(s[i])
+		}
+		i++
+	}
+	flushDollars(false)
+	return buf.String()
+}
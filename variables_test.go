@@ -0,0 +1,97 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandVariables(t *testing.T) {
+	o := newOptions([]Option{WithVariables(map[string]string{"HOST": "db.local", "DIR": "/srv/data", "SUB": "/srv/data/sub"})})
+	var tests = []struct {
+		golden, actual string
+	}{
+		{"", ""},
+		{"connect to ${HOST}\n", "connect to db.local\n"},
+		{"${SUB}/x ${DIR}/y\n", "/srv/data/sub/x /srv/data/y\n"},
+		{"${UNKNOWN} ${HOST", "${UNKNOWN} ${HOST"},
+		{"$${HOST} $$${HOST}", "${HOST} $db.local"},
+		{"$$$${HOST}", "$${HOST}"},
+		{"$$${DIR}/x", "$/srv/data/x"},
+		{"cost: $5", "cost: $5"},
+	}
+	for _, test := range tests {
+		if got := o.expandVariables("a.golden", test.golden); got != test.actual {
+			t.Errorf("expandVariables(%q): got %q want %q", test.golden, got, test.actual)
+		}
+		if got := o.expandVariables("a.golden", o.contractVariables("a.golden", test.actual)); got != test.actual {
+			t.Errorf("expandVariables(contractVariables(%q)): got %q", test.actual, got)
+		}
+	}
+	for actual, want := range map[string]string{
+		"db.local:/srv/data/sub": "${HOST}:${SUB}",
+		"$db.local":              "$$${HOST}",
+		"${x} $${x}":             "$${x} $$$${x}",
+		"$$":                     "$$",
+	} {
+		if got := o.contractVariables("a.golden", actual); got != want {
+			t.Errorf("contractVariables(%q): got %q want %q", actual, got, want)
+		}
+	}
+	if got := o.expandVariables("a.golden.re", "${HOST}"); got != "${HOST}" {
+		t.Errorf("expandVariables of a regexp golden file: got %q", got)
+	}
+	if got := newOptions(nil).expandVariables("a.golden", "${HOST}"); got != "${HOST}" {
+		t.Errorf("expandVariables without WithVariables: got %q", got)
+	}
+}
+
+func TestCompareWithVariables(t *testing.T) {
+	env := TestEnv(t)
+	registeredVariables.Lock()
+	original := registeredVariables.byName
+	registeredVariables.byName = nil
+	registeredVariables.Unlock()
+	defer func() {
+		registeredVariables.Lock()
+		registeredVariables.byName = original
+		registeredVariables.Unlock()
+	}()
+	RegisterVariable("HOST", "db.local")
+
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := "config " + filepath.Join(testdata, "config.json") + " for db.local\n"
+	env.SetUpdating(true)
+	Compare(actual, "vars.golden", WithVariables(nil))
+	data, err := ioutil.ReadFile(env.Path("vars.golden"))
+	if want := "config ${TESTDATA_DIR}/config.json for ${HOST}\n"; string(data) != want || err != nil {
+		t.Errorf("golden file: got %q, %v want %q", data, err, want)
+	}
+	env.SetUpdating(false)
+	if diff := Compare(actual, "vars.golden", WithVariables(nil)); diff != "" {
+		t.Errorf("Compare with variables: %v", diff)
+	}
+	if diff := Compare(actual, "vars.golden", WithVariables(map[string]string{"HOST": "db.remote"})); diff == "" {
+		t.Errorf("Compare with an overridden variable: got no diff")
+	}
+	if diff := Compare(actual, "vars.golden"); diff == "" {
+		t.Errorf("Compare without WithVariables: got no diff")
+	}
+}
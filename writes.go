@@ -0,0 +1,168 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// pendingWrites buffers the updates of golden files while Run runs the
+// tests, so that each file is written once however many tests update it.
+var pendingWrites struct {
+	sync.Mutex
+	enabled bool
+	byPath  map[string]pendingWrite
+}
+
+// pendingWrite is the buffered update of a golden file.
+type pendingWrite struct {
+	contents string
+	// previous holds the contents of the file before the run, if existed.
+	previous string
+	existed  bool
+}
+
+// bufferWrites starts buffering the updates of golden files.
+func bufferWrites() {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	pendingWrites.enabled = true
+	pendingWrites.byPath = map[string]pendingWrite{}
+}
+
+// resetWritesForTest stops buffering for a test, and returns a function
+// restoring the buffered updates and whether buffering was on.
+func resetWritesForTest() func() {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	originalEnabled, originalByPath := pendingWrites.enabled, pendingWrites.byPath
+	pendingWrites.enabled, pendingWrites.byPath = false, nil
+	return func() {
+		pendingWrites.Lock()
+		defer pendingWrites.Unlock()
+		pendingWrites.enabled, pendingWrites.byPath = originalEnabled, originalByPath
+	}
+}
+
+// pendingContents returns the buffered contents of the golden file fullPath,
+// if it has any.
+func pendingContents(fullPath string) (string, bool) {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	w, ok := pendingWrites.byPath[fullPath]
+	return w.contents, ok
+}
+
+// hasPendingWrite reports whether the golden file fullPath has buffered
+// contents.
+func hasPendingWrite(fullPath string) bool {
+	_, ok := pendingContents(fullPath)
+	return ok
+}
+
+// hasPendingWrites reports whether any golden file has buffered contents.
+func hasPendingWrites() bool {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	return len(pendingWrites.byPath) > 0
+}
+
+// bufferWrite is writeGoldenFile while buffering is on. It reports false if
+// buffering is off.
+func bufferWrite(fullPath string, contents func(previous string) string) (updateStatus, bool, error) {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	if !pendingWrites.enabled {
+		return 0, false, nil
+	}
+	w, ok := pendingWrites.byPath[fullPath]
+	if !ok {
+		previous, err := ioutil.ReadFile(fullPath)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return 0, true, err
+		default:
+			w.previous, w.contents, w.existed = string(previous), string(previous), true
+		}
+	}
+	actual := contents(w.contents)
+	status := statusModified
+	switch {
+	case !w.existed && !ok:
+		status = statusCreated
+	case actual == w.contents:
+		status = statusUnchanged
+	}
+	w.contents = actual
+	pendingWrites.byPath[fullPath] = w
+	return status, true, nil
+}
+
+// flushWrites stops buffering and writes the buffered updates, backing up
+// the previous contents if -backup_golden is set.
+func flushWrites() error {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	pendingWrites.enabled = false
+	err := writePendingLocked()
+	pendingWrites.byPath = nil
+	return err
+}
+
+// savePendingWrites writes the buffered updates so far without stopping
+// buffering, so that they are kept if the process exits before flushWrites,
+// as it does when the Logger reports an error with log.Fatalf.
+func savePendingWrites() error {
+	pendingWrites.Lock()
+	defer pendingWrites.Unlock()
+	return writePendingLocked()
+}
+
+// writePendingLocked writes the buffered updates. pendingWrites must be
+// locked.
+func writePendingLocked() error {
+	paths := make([]string, 0, len(pendingWrites.byPath))
+	for p := range pendingWrites.byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var failed []string
+	for _, p := range paths {
+		w := pendingWrites.byPath[p]
+		if w.existed && w.contents == w.previous {
+			continue
+		}
+		var err error
+		if w.existed && shouldBackupGolden() {
+			err = ioutil.WriteFile(p+".bak", []byte(w.previous), 0660)
+		}
+		if err == nil {
+			err = ioutil.WriteFile(p, []byte(w.contents), 0660)
+		}
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("writing golden files: %v", strings.Join(failed, "; "))
+	}
+	return nil
+}
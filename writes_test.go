@@ -0,0 +1,81 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// runFunc is a testing.M running f.
+type runFunc func() int
+
+func (f runFunc) Run() int { return f() }
+
+func TestBufferedWrites(t *testing.T) {
+	env := TestEnv(t)
+	env.SetUpdating(true)
+	defer setenvForTest(map[string]string{"GOLDEN_READ_REPORT": ""})()
+	backupGolden.Store(true)
+	if err := ioutil.WriteFile(env.Path("a.golden"), []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(env.Path("same.golden"), []byte("same\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	Run(runFunc(func() int {
+		Compare("one\n", "a.golden")
+		Compare("two\n", "a.golden")
+		Compare("new\n", "b.golden")
+		Compare("changed\n", "same.golden")
+		Compare("same\n", "same.golden")
+		if data, _ := ioutil.ReadFile(env.Path("a.golden")); string(data) != "old\n" {
+			t.Errorf("a.golden before the tests finish: got %q, want it unchanged", data)
+		}
+		if got := Check("two\n", "a.golden").String(); got != "" {
+			t.Errorf("Check of a buffered golden file: got %q, want no diff", got)
+		}
+		env.SetUpdating(false)
+		if got := Check("new\n", "b.golden").String(); got != "" {
+			t.Errorf("Check of a buffered new golden file: got %q, want no diff", got)
+		}
+		env.SetUpdating(true)
+
+		// Errors are reported after writing the buffered updates, in case
+		// the Logger exits.
+		l := &recordingLogger{}
+		SetLogger(l)
+		defer SetLogger(nil)
+		Fatalf("failed")
+		if data, _ := ioutil.ReadFile(env.Path("b.golden")); string(data) != "new\n" || len(l.errors) != 1 {
+			t.Errorf("b.golden after Fatalf: got %q, want it written", data)
+		}
+		return 0
+	}))
+
+	for name, want := range map[string]string{"a.golden": "two\n", "a.golden.bak": "old\n", "b.golden": "new\n", "same.golden": "same\n"} {
+		if got, err := ioutil.ReadFile(env.Path(name)); string(got) != want || err != nil {
+			t.Errorf("%v after the tests finish: got %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(env.Path("same.golden.bak")); !os.IsNotExist(err) {
+		t.Errorf("backup of a golden file updated back to its contents: got %v, want none", err)
+	}
+	if hasPendingWrite(env.Path("a.golden")) {
+		t.Errorf("hasPendingWrite after the tests finish: got true")
+	}
+}
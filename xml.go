@@ -0,0 +1,95 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CompareXML compares XML documents, ignoring differences that XML
+// serializers are free to make: the order of attributes, whitespace between
+// elements, namespace prefixes and the XML declaration. Both documents are
+// rendered with one element, text or comment per line, so any remaining
+// difference is reported as a diff of those lines. Updating the golden file
+// writes the actual document unchanged.
+func CompareXML(actual string, goldenFile string, opts ...Option) string {
+	return Compare(actual, goldenFile, append(opts, withCanonicalizer(canonicalXML))...)
+}
+
+// canonicalXML renders the XML document data in canonical form.
+func canonicalXML(data string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader(data))
+	buf := &bytes.Buffer{}
+	depth := 0
+	line := func(format string, args ...interface{}) {
+		buf.WriteString(strings.Repeat("  ", depth))
+		fmt.Fprintf(buf, format, args...)
+		buf.WriteString("\n")
+	}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var attrs []string
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				attrs = append(attrs, fmt.Sprintf(" %v=%q", xmlName(a.Name), a.Value))
+			}
+			sort.Strings(attrs)
+			line("<%v%v>", xmlName(tok.Name), strings.Join(attrs, ""))
+			depth++
+		case xml.EndElement:
+			depth--
+			line("</%v>", xmlName(tok.Name))
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				escaped := &bytes.Buffer{}
+				xml.EscapeText(escaped, []byte(text))
+				line("%v", escaped)
+			}
+		case xml.Comment:
+			line("<!--%s-->", tok)
+		case xml.ProcInst:
+			if tok.Target != "xml" {
+				line("<?%v %s?>", tok.Target, tok.Inst)
+			}
+		case xml.Directive:
+			line("<!%s>", tok)
+		}
+	}
+	return buf.String(), nil
+}
+
+// xmlName returns name qualified with its namespace URI rather than a
+// prefix, which is arbitrary.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
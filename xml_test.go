@@ -0,0 +1,93 @@
+// Copyright 2017 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalXML(t *testing.T) {
+	in := `<?xml version="1.0"?>
+<!-- feed -->
+<f:feed xmlns:f="http://example.com/feed" b="2" a="1">
+  <f:entry>Hello &amp; goodbye</f:entry>
+  <empty/>
+</f:feed>
+`
+	want := `<!-- feed -->
+<{http://example.com/feed}feed a="1" b="2">
+  <{http://example.com/feed}entry>
+    Hello &amp; goodbye
+  </{http://example.com/feed}entry>
+  <empty>
+  </empty>
+</{http://example.com/feed}feed>
+`
+	got, err := canonicalXML(in)
+	if err != nil || got != want {
+		t.Errorf("canonicalXML: got %q, %v, want %q", got, err, want)
+	}
+	if _, err := canonicalXML("<a></b>"); err == nil {
+		t.Errorf("canonicalXML of malformed XML: got nil error")
+	}
+}
+
+func TestCompareXML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goldendata_test")
+	if err != nil {
+		t.Fatalf("Unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(path.Join(dir, "src/fake/testdata"), 0700); err != nil {
+		t.Fatalf("Unable to create testdata directory: %v", err)
+	}
+	golden := `<a:root xmlns:a="urn:x" id="1" lang="en"><a:item>one</a:item></a:root>`
+	if err := ioutil.WriteFile(path.Join(dir, "src/fake/testdata/doc.xml.golden"), []byte(golden), 0600); err != nil {
+		t.Fatalf("Cannot write fake golden file: %v", err)
+	}
+	defer setGoPathForTest(dir)()
+
+	var tests = []struct {
+		actual string
+		want   string
+	}{
+		{
+			actual: "<root xmlns=\"urn:x\" lang=\"en\" id=\"1\">\n  <item>one</item>\n</root>\n",
+			want:   "",
+		},
+		{
+			actual: `<b:root xmlns:b="urn:x" id="1" lang="en"><b:item>two</b:item></b:root>`,
+			want: `@@ -1,6 +1,6 @@
+ <{urn:x}root id="1" lang="en">
+   <{urn:x}item>
+-    one
++    two
+   </{urn:x}item>
+ </{urn:x}root>
+ 
+`,
+		},
+	}
+	for _, test := range tests {
+		got := CompareXML(test.actual, "fake/testdata/doc.xml.golden")
+		if !strings.HasSuffix(got, test.want) || (got == "") != (test.want == "") {
+			t.Errorf("CompareXML(%q): got %q, want a diff ending in %q", test.actual, got, test.want)
+		}
+	}
+}